@@ -1,54 +1,211 @@
 package main
 
 import (
+	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
 	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
+// legacyExpiredAtLayout is the layout gifts.expired_at was stored in while it
+// was a string.
+const legacyExpiredAtLayout = "2006-01-02 15:04:05"
+
+// legacyGift lets the migrator rename the old string expired_at column, also on
+// databases that need the column type to rename it.
+type legacyGift struct {
+	LegacyExpiredAt string `gorm:"column:legacy_expired_at;type:varchar(255)"`
+}
+
+func (legacyGift) TableName() string {
+	return "gifts"
+}
+
+// dataMigration records the one-off data migrations that already ran, so they
+// never run twice.
+type dataMigration struct {
+	Name      string    `gorm:"type:varchar(100);primaryKey"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (dataMigration) TableName() string {
+	return "data_migrations"
+}
+
+// timestampsToUTC names the conversion of MySQL timestamps written in the
+// server time zone to UTC.
+const timestampsToUTC = "timestamps_to_utc"
+
 func main() {
 	viper := config.NewViper()
 	log := config.NewLogrus(viper)
 	db := config.NewDatabase()
 
+	// timestamps used to be written in the time zone of the server, convert them
+	// before anything is written in UTC
+	if err := convertTimestampsToUTC(db, viper); err != nil {
+		log.Fatal(err)
+	}
+
+	// expired_at used to be a string, keep its values aside until the timestamp
+	// column has been created
+	hasLegacyExpiredAt, err := renameLegacyExpiredAt(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// migrate the schema
-	err := db.AutoMigrate(&entity.Role{}, &entity.User{}, &entity.UserToken{}, &entity.UserRole{}, &entity.Gift{}, &entity.Redemption{}, &entity.Rating{})
+	err = db.AutoMigrate(&entity.Role{}, &entity.Permission{}, &entity.RolePermission{}, &entity.User{}, &entity.UserToken{}, &entity.LoginAttempt{}, &entity.UserRole{}, &entity.RefreshToken{}, &entity.RevokedToken{}, &entity.UserRecoveryCode{}, &entity.APIKey{}, &entity.OAuthClient{}, &entity.OAuthAuthorizationCode{}, &entity.PointWallet{}, &entity.PointLedger{}, &entity.PointBatch{}, &entity.PointBatchRow{}, &entity.Gift{}, &entity.GiftCode{}, &entity.Redemption{}, &entity.RedemptionEvent{}, &entity.Rating{}, &entity.IdempotencyKey{}, &entity.SchedulerLease{}, &entity.SchedulerRun{})
 	if err != nil {
 		log.Fatal(err)
 	} else {
 		log.Info("Migration success")
 	}
 
-	hashedPasswordBytes, err := bcrypt.GenerateFromPassword([]byte("changeme"), bcrypt.DefaultCost)
+	// stock used to be a column, it is now counted from the available gift codes
+	if db.Migrator().HasColumn("gifts", "stock") {
+		if err := backfillGiftCodes(db, viper); err != nil {
+			log.Fatal(err)
+		}
 
-	// seed superadmin role data
-	superAdminRole := entity.Role{
-		Name:      "superadmin",
-		GuardName: "api",
-		Status:    entity.ROLE_ACTIVE,
+		if err := db.Migrator().DropColumn("gifts", "stock"); err != nil {
+			log.Fatal(err)
+		}
 	}
 
-	err = db.Create(&superAdminRole).Error
+	if hasLegacyExpiredAt {
+		if err := migrateLegacyExpiredAt(db, viper); err != nil {
+			log.Fatal(err)
+		}
+	}
 
+	// seed roles and permissions, looked up by name so an existing database keeps
+	// its roles and they are granted the permissions they miss
+	superAdminRole, err := seedRole(db, entity.Role{
+		Name:             "superadmin",
+		GuardName:        "api",
+		Status:           entity.ROLE_ACTIVE,
+		RequireTwoFactor: true,
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// seed user role data
-	userRole := entity.Role{
+	userRole, err := seedRole(db, entity.Role{
 		Name:      "user",
 		GuardName: "api",
 		Status:    entity.ROLE_ACTIVE,
+	})
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	err = db.Create(&userRole).Error
+	// seed permissions data, superadmin is granted every permission
+	permissions := []entity.Permission{
+		{
+			Name:        entity.PERMISSION_GIFTS_MANAGE,
+			GuardName:   "api",
+			Description: "Create, update and delete gifts",
+		},
+		{
+			Name:        entity.PERMISSION_GIFTS_REDEEM,
+			GuardName:   "api",
+			Description: "Redeem gifts, also grantable to partner apps as an OAuth2 scope",
+		},
+		{
+			Name:        entity.PERMISSION_REDEMPTIONS_MANAGE,
+			GuardName:   "api",
+			Description: "Fulfill, ship, cancel and refund redemptions",
+		},
+		{
+			Name:        entity.PERMISSION_REDEMPTIONS_VIEW_ALL,
+			GuardName:   "api",
+			Description: "View redemptions of every user",
+		},
+		{
+			Name:        entity.PERMISSION_ROLES_MANAGE,
+			GuardName:   "api",
+			Description: "Manage roles and their assignment to users",
+		},
+		{
+			Name:        entity.PERMISSION_USERS_MANAGE,
+			GuardName:   "api",
+			Description: "Manage user accounts",
+		},
+		{
+			Name:        entity.PERMISSION_POINTS_MANAGE,
+			GuardName:   "api",
+			Description: "Grant and deduct points of users, also in CSV batches",
+		},
+	}
 
-	if err != nil {
+	for _, permission := range permissions {
+		err = db.Where("name = ? AND guard_name = ?", permission.Name, permission.GuardName).FirstOrCreate(&permission).Error
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err := grantPermission(db, superAdminRole.ID, permission.ID); err != nil {
+			log.Fatal(err)
+		}
+
+		// regular users may only redeem gifts
+		if permission.Name == entity.PERMISSION_GIFTS_REDEEM {
+			if err := grantPermission(db, userRole.ID, permission.ID); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+	// demo users, points and gifts only go into a new database
+	var userCount int64
+	if err := db.Model(&entity.User{}).Count(&userCount).Error; err != nil {
 		log.Fatal(err)
 	}
 
+	if userCount == 0 {
+		if err := seedDemoData(db, viper, superAdminRole, userRole); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	log.Info("Seed success")
+}
+
+// seedRole returns the role with the name and guard of the given role, and
+// creates it when there is none.
+func seedRole(db *gorm.DB, role entity.Role) (*entity.Role, error) {
+	err := db.Where("name = ? AND guard_name = ?", role.Name, role.GuardName).
+		Attrs(entity.Role{Status: role.Status, RequireTwoFactor: role.RequireTwoFactor}).
+		FirstOrCreate(&role).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+// grantPermission attaches a permission to a role unless it already is.
+func grantPermission(db *gorm.DB, roleID uuid.UUID, permissionID uuid.UUID) error {
+	return db.Where(entity.RolePermission{RoleID: roleID, PermissionID: permissionID}).
+		FirstOrCreate(&entity.RolePermission{}).Error
+}
+
+// seedDemoData seeds a superadmin, a user with points and a few gifts in stock.
+func seedDemoData(db *gorm.DB, viper *viper.Viper, superAdminRole *entity.Role, userRole *entity.Role) error {
+	hashedPasswordBytes, err := bcrypt.GenerateFromPassword([]byte("changeme"), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
 	// seed superadmin user data
 	superAdminUser := entity.User{
 		Name:            "Super Admin",
@@ -60,19 +217,15 @@ func main() {
 		Status:          entity.USER_ACTIVE,
 	}
 
-	err = db.Create(&superAdminUser).Error
-
-	if err != nil {
-		log.Fatal(err)
+	if err := db.Create(&superAdminUser).Error; err != nil {
+		return err
 	}
 
-	err = db.Create(&entity.UserRole{
+	if err := db.Create(&entity.UserRole{
 		UserID: superAdminUser.ID,
 		RoleID: superAdminRole.ID,
-	}).Error
-
-	if err != nil {
-		log.Fatal(err)
+	}).Error; err != nil {
+		return err
 	}
 
 	// seed user data
@@ -86,19 +239,41 @@ func main() {
 		Status:          entity.USER_ACTIVE,
 	}
 
-	err = db.Create(&user).Error
-
-	if err != nil {
-		log.Fatal(err)
+	if err := db.Create(&user).Error; err != nil {
+		return err
 	}
 
-	err = db.Create(&entity.UserRole{
+	if err := db.Create(&entity.UserRole{
 		UserID: user.ID,
 		RoleID: userRole.ID,
-	}).Error
+	}).Error; err != nil {
+		return err
+	}
 
+	// seed points so the user can redeem the seeded gifts
+	userWallet := entity.PointWallet{
+		UserID:  user.ID,
+		Balance: 100000,
+	}
+
+	if err := db.Create(&userWallet).Error; err != nil {
+		return err
+	}
+
+	if err := db.Create(&entity.PointLedger{
+		WalletID:     userWallet.ID,
+		UserID:       user.ID,
+		Type:         entity.POINT_CREDIT,
+		Amount:       userWallet.Balance,
+		BalanceAfter: userWallet.Balance,
+		Reason:       "Initial points",
+	}).Error; err != nil {
+		return err
+	}
+
+	codeCipher, err := utils.LoadCodeCipher(viper)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	// seed gifts data
@@ -108,30 +283,293 @@ func main() {
 			RedeemCode:  "REDEMPTION2",
 			Description: "Kalo main wajib pake kuda",
 			Price:       10000,
-			Stock:       10,
 		},
 		{
 			Name:        "The Witcher 3 Gift Card",
 			RedeemCode:  "WITCHER3",
 			Description: "Kalo main wajib pake Geralt",
 			Price:       20000,
-			Stock:       20,
 		},
 		{
 			Name:        "God of War 2018 Gift Card",
 			RedeemCode:  "GODOFWAR",
 			Description: "Kalo main wajib pake Kratos",
 			Price:       30000,
-			Stock:       30,
+			MaxPerUser:  2,
 		},
 	}
 
-	for _, gift := range gifts {
-		err = db.Create(&gift).Error
+	for i, gift := range gifts {
+		if err := db.Create(&gift).Error; err != nil {
+			return err
+		}
+
+		// seed 10, 20 and 30 codes so every gift is in stock
+		codes := []entity.GiftCode{}
+		for n := 1; n <= (i+1)*10; n++ {
+			code := fmt.Sprintf("%s-%04d", gift.RedeemCode, n)
+
+			encrypted, err := codeCipher.Encrypt(code)
+			if err != nil {
+				return err
+			}
+
+			codes = append(codes, entity.GiftCode{
+				GiftID:        gift.ID,
+				CodeHash:      codeCipher.Hash(code),
+				CodeHint:      utils.CodeHint(code),
+				EncryptedCode: encrypted.Ciphertext,
+				EncryptedKey:  encrypted.WrappedKey,
+				KeyID:         encrypted.KeyID,
+				Status:        entity.GIFT_CODE_AVAILABLE,
+			})
+		}
+
+		if err := db.Create(&codes).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// backfillGiftCodes turns the old gifts.stock counter into as many available
+// codes. Before gift codes every redeemer got the redeem code of the gift, so
+// the codes carry that code. They have no hash, as the code is shared and not
+// unique. Each gift is backfilled in a transaction that also zeroes its stock,
+// so an interrupted run can be resumed.
+func backfillGiftCodes(db *gorm.DB, viper *viper.Viper) error {
+	codeCipher, err := utils.LoadCodeCipher(viper)
+	if err != nil {
+		return err
+	}
+
+	var rows []struct {
+		ID         uuid.UUID
+		RedeemCode string
+		Stock      int
+	}
+	if err := db.Table("gifts").Select("id, redeem_code, stock").Where("stock > 0").Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		codes := make([]entity.GiftCode, 0, row.Stock)
+		for n := 0; n < row.Stock; n++ {
+			encrypted, err := codeCipher.Encrypt(row.RedeemCode)
+			if err != nil {
+				return err
+			}
+
+			codes = append(codes, entity.GiftCode{
+				GiftID:        row.ID,
+				CodeHint:      utils.CodeHint(row.RedeemCode),
+				EncryptedCode: encrypted.Ciphertext,
+				EncryptedKey:  encrypted.WrappedKey,
+				KeyID:         encrypted.KeyID,
+				Status:        entity.GIFT_CODE_AVAILABLE,
+			})
+		}
+
+		tx := db.Begin()
+		if tx.Error != nil {
+			return tx.Error
+		}
+
+		if err := tx.CreateInBatches(&codes, 500).Error; err != nil {
+			tx.Rollback()
+			return fmt.Errorf("gift %s: %w", row.ID, err)
+		}
+
+		if err := tx.Table("gifts").Where("id = ?", row.ID).Update("stock", 0).Error; err != nil {
+			tx.Rollback()
+			return fmt.Errorf("gift %s: %w", row.ID, err)
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			return fmt.Errorf("gift %s: %w", row.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// convertTimestampsToUTC converts the DATETIME columns of a MySQL database from
+// database.legacy_timezone, the time zone the server ran in while the
+// connection used loc=Local, to UTC. Postgres is skipped, its timestamps carry
+// their offset. The gift availability window is left alone, it has been
+// written in UTC from the start.
+func convertTimestampsToUTC(db *gorm.DB, viper *viper.Viper) error {
+	legacyTimezone := viper.GetString("database.legacy_timezone")
+	if legacyTimezone == "" || db.Dialector.Name() != "mysql" {
+		return nil
+	}
+
+	if err := db.AutoMigrate(&dataMigration{}); err != nil {
+		return err
+	}
+
+	var done int64
+	if err := db.Model(&dataMigration{}).Where("name = ?", timestampsToUTC).Count(&done).Error; err != nil {
+		return err
+	}
+
+	if done > 0 {
+		return nil
+	}
+
+	zone, err := mysqlTimezone(db, legacyTimezone)
+	if err != nil {
+		return err
+	}
+
+	var columns []struct {
+		TableName  string
+		ColumnName string
+	}
+	if err := db.Raw(`SELECT table_name AS table_name, column_name AS column_name FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND data_type IN ('datetime', 'timestamp') AND table_name <> ?
+		ORDER BY table_name, ordinal_position`, dataMigration{}.TableName()).Scan(&columns).Error; err != nil {
+		return err
+	}
+
+	tables := make(map[string][]string)
+	var tableNames []string
+	for _, column := range columns {
+		if column.TableName == "gifts" && (column.ColumnName == "expired_at" || column.ColumnName == "available_from") {
+			continue
+		}
+
+		if _, ok := tables[column.TableName]; !ok {
+			tableNames = append(tableNames, column.TableName)
+		}
+		tables[column.TableName] = append(tables[column.TableName], column.ColumnName)
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	for _, tableName := range tableNames {
+		var assignments []string
+		var args []interface{}
+		for _, columnName := range tables[tableName] {
+			// zero dates, such as an unverified email_verified_at, stay as they are
+			assignments = append(assignments, fmt.Sprintf("`%[1]s` = IF(`%[1]s` >= '1000-01-02', CONVERT_TZ(`%[1]s`, ?, '+00:00'), `%[1]s`)", columnName))
+			args = append(args, zone)
+		}
+
+		if err := tx.Exec(fmt.Sprintf("UPDATE `%s` SET %s", tableName, strings.Join(assignments, ", ")), args...).Error; err != nil {
+			tx.Rollback()
+			return fmt.Errorf("table %s: %w", tableName, err)
+		}
+	}
+
+	if err := tx.Create(&dataMigration{Name: timestampsToUTC}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// mysqlTimezone returns the name MySQL knows the time zone by. Named zones need
+// the time zone tables of MySQL, without them a zone that never changes its
+// offset is passed as the offset instead.
+func mysqlTimezone(db *gorm.DB, name string) (string, error) {
+	var converted sql.NullString
+	if err := db.Raw("SELECT CONVERT_TZ('2000-01-01 00:00:00', ?, '+00:00')", name).Row().Scan(&converted); err != nil {
+		return "", err
+	}
+
+	if converted.Valid {
+		return name, nil
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return "", fmt.Errorf("invalid database.legacy_timezone %q: %w", name, err)
+	}
+
+	// compare the offsets over the last years to find zones without DST
+	_, offset := time.Date(2000, 1, 1, 0, 0, 0, 0, location).Zone()
+	for year := 2000; year <= time.Now().Year(); year++ {
+		for _, month := range []time.Month{time.January, time.July} {
+			if _, other := time.Date(year, month, 1, 0, 0, 0, 0, location).Zone(); other != offset {
+				return "", fmt.Errorf("time zone %q changes its offset, load the MySQL time zone tables to convert it", name)
+			}
+		}
+	}
+
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	return fmt.Sprintf("%s%02d:%02d", sign, offset/3600, offset%3600/60), nil
+}
+
+// renameLegacyExpiredAt moves a string gifts.expired_at column out of the way
+// and reports whether there is one to migrate, also when an earlier run was
+// interrupted after renaming it.
+func renameLegacyExpiredAt(db *gorm.DB) (bool, error) {
+	if !db.Migrator().HasTable("gifts") {
+		return false, nil
+	}
+
+	if db.Migrator().HasColumn(&legacyGift{}, "legacy_expired_at") {
+		return true, nil
+	}
+
+	columnTypes, err := db.Migrator().ColumnTypes("gifts")
+	if err != nil {
+		return false, err
+	}
+
+	for _, columnType := range columnTypes {
+		if columnType.Name() != "expired_at" {
+			continue
+		}
+
+		typeName := strings.ToLower(columnType.DatabaseTypeName())
+		if !strings.Contains(typeName, "char") && !strings.Contains(typeName, "text") {
+			return false, nil
+		}
+
+		return true, db.Migrator().RenameColumn(&legacyGift{}, "expired_at", "legacy_expired_at")
+	}
+
+	return false, nil
+}
+
+// migrateLegacyExpiredAt copies the old string expiry dates into the timestamp
+// column and drops the old column. The strings carried no time zone, they are
+// read in app.timezone and stored in UTC.
+func migrateLegacyExpiredAt(db *gorm.DB, viper *viper.Viper) error {
+	location, err := utils.LoadTimezone(viper)
+	if err != nil {
+		return err
+	}
+
+	var rows []struct {
+		ID              uuid.UUID
+		LegacyExpiredAt string
+	}
+	if err := db.Table("gifts").Select("id, legacy_expired_at").Where("legacy_expired_at <> ''").Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		expiredAt, err := time.ParseInLocation(legacyExpiredAtLayout, row.LegacyExpiredAt, location)
 		if err != nil {
-			log.Fatal(err)
+			return fmt.Errorf("gift %s has an invalid expired_at %q: %w", row.ID, row.LegacyExpiredAt, err)
+		}
+
+		if err := db.Table("gifts").Where("id = ?", row.ID).Update("expired_at", expiredAt.UTC()).Error; err != nil {
+			return err
 		}
 	}
 
-	log.Info("Seed success")
+	return db.Migrator().DropColumn(&legacyGift{}, "legacy_expired_at")
 }
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/repository"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/google/uuid"
+)
+
+// batchSize is how many gift codes are re-encrypted per round trip.
+const batchSize = 500
+
+// legacyGiftCode is a row from before gift codes were encrypted.
+type legacyGiftCode struct {
+	ID   uuid.UUID
+	Code string
+}
+
+// reencrypt wraps the data key of every gift code with the active key of
+// gift_codes.encryption, and encrypts codes still stored in plain text. Run it
+// after changing active_kid; the previous key can be removed from the config
+// once it finished.
+func main() {
+	viper := config.NewViper()
+	log := config.NewLogrus(viper)
+	db := config.NewDatabase()
+
+	codeCipher, err := utils.LoadCodeCipher(viper)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// encrypt the codes stored before encryption at rest was introduced
+	if db.Migrator().HasColumn("gift_codes", "code") {
+		encrypted := 0
+		for {
+			var codes []legacyGiftCode
+			if err := db.Table("gift_codes").Select("id, code").
+				Where("encrypted_code IS NULL").Limit(batchSize).Scan(&codes).Error; err != nil {
+				log.Fatal(err)
+			}
+
+			if len(codes) == 0 {
+				break
+			}
+
+			for _, code := range codes {
+				sealed, err := codeCipher.Encrypt(code.Code)
+				if err != nil {
+					log.Fatal(err)
+				}
+
+				if err := db.Model(&entity.GiftCode{}).Where("id = ?", code.ID).Updates(map[string]interface{}{
+					"code_hash":      codeCipher.Hash(code.Code),
+					"code_hint":      utils.CodeHint(code.Code),
+					"encrypted_code": sealed.Ciphertext,
+					"encrypted_key":  sealed.WrappedKey,
+					"key_id":         sealed.KeyID,
+				}).Error; err != nil {
+					log.Fatal(err)
+				}
+			}
+
+			encrypted += len(codes)
+		}
+
+		if err := db.Migrator().DropColumn("gift_codes", "code"); err != nil {
+			log.Fatal(err)
+		}
+
+		log.Infof("Encrypted %d plain text gift codes", encrypted)
+	}
+
+	giftCodeRepository := repository.GiftCodeRepositoryFactory(log)
+
+	rewrapped := 0
+	for {
+		codes, err := giftCodeRepository.FindAllNotUnderKey(codeCipher.ActiveKID, batchSize)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if len(*codes) == 0 {
+			break
+		}
+
+		for _, code := range *codes {
+			sealed, err := codeCipher.Rewrap(&utils.EncryptedCode{
+				KeyID:      code.KeyID,
+				WrappedKey: code.EncryptedKey,
+				Ciphertext: code.EncryptedCode,
+			})
+			if err != nil {
+				log.Fatalf("gift code %s: %v", code.ID, err)
+			}
+
+			code.EncryptedCode = sealed.Ciphertext
+			code.EncryptedKey = sealed.WrappedKey
+			code.KeyID = sealed.KeyID
+
+			if err := giftCodeRepository.UpdateEncryption(&code); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		rewrapped += len(*codes)
+	}
+
+	log.Infof("Re-encrypted %d gift codes under key %s", rewrapped, codeCipher.ActiveKID)
+}
@@ -52,13 +52,20 @@ func NewDatabase() *gorm.DB {
 		var dsn string
 		var db *gorm.DB
 
+		// every timestamp is stored in UTC, app.timezone is only used for display
+		gormConfig := &gorm.Config{
+			NowFunc: func() time.Time {
+				return time.Now().UTC()
+			},
+		}
+
 		switch driver {
 		case "mysql":
-			dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local", username, password, host, port, database)
-			db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+			dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=UTC", username, password, host, port, database)
+			db, err = gorm.Open(mysql.Open(dsn), gormConfig)
 		case "postgres":
-			dsn = fmt.Sprintf("host=%s port=%d user=%s dbname=%s password=%s sslmode=disable", host, port, username, database, password)
-			db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+			dsn = fmt.Sprintf("host=%s port=%d user=%s dbname=%s password=%s sslmode=disable TimeZone=UTC", host, port, username, database, password)
+			db, err = gorm.Open(postgres.Open(dsn), gormConfig)
 		default:
 			log.Fatalf("unsupported database driver: %s", driver)
 		}
@@ -10,5 +10,6 @@ func NewValidator(viper *viper.Viper) *validator.Validate {
 	validate := validator.New()
 	validate.RegisterValidation("UserStatusValidation", request.UserStatusValidation)
 	validate.RegisterValidation("UserGenderValidation", request.UserGenderValidation)
+	validate.RegisterValidation("RoleStatusValidation", request.RoleStatusValidation)
 	return validate
 }
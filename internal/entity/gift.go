@@ -1,10 +1,25 @@
 package entity
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// GiftLimitCode names a redemption limit of a gift.
+type GiftLimitCode string
+
+const (
+	GIFT_LIMIT_PER_USER         GiftLimitCode = "PER_USER_LIMIT"
+	GIFT_LIMIT_PER_USER_MONTHLY GiftLimitCode = "PER_USER_MONTHLY_LIMIT"
+	GIFT_LIMIT_PER_USER_WEEKLY  GiftLimitCode = "PER_USER_WEEKLY_LIMIT"
+	GIFT_LIMIT_PER_USER_DAILY   GiftLimitCode = "PER_USER_DAILY_LIMIT"
+	GIFT_LIMIT_DAILY            GiftLimitCode = "DAILY_LIMIT"
+)
+
+// Gift is an item of the catalog. RedeemCode identifies the gift itself, the
+// codes handed out to redeemers are its GiftCodes.
 type Gift struct {
 	gorm.Model  `json:"-"`
 	ID          uuid.UUID `json:"id" gorm:"type:char(36);primaryKey"`
@@ -12,8 +27,26 @@ type Gift struct {
 	Name        string    `json:"name" gorm:"not null"`
 	Description string    `json:"description" gorm:"type:text;default:null"`
 	Price       int       `json:"price" gorm:"default:0"`
-	Stock       int       `json:"stock" gorm:"default:0"`
-	ExpiredAt   string    `json:"expired_at" gorm:"not null"`
+
+	// the gift can be redeemed from AvailableFrom until ExpiredAt, both in UTC
+	// and open ended when empty
+	AvailableFrom *time.Time `json:"available_from" gorm:"default:null;index"`
+	ExpiredAt     *time.Time `json:"expired_at" gorm:"default:null;index"`
+
+	// redemption limits, 0 means unlimited; the periods are calendar days,
+	// weeks starting on Monday and months in app.timezone
+	MaxPerUser        int `json:"max_per_user" gorm:"not null;default:0"`
+	MaxPerUserDaily   int `json:"max_per_user_daily" gorm:"not null;default:0"`
+	MaxPerUserWeekly  int `json:"max_per_user_weekly" gorm:"not null;default:0"`
+	MaxPerUserMonthly int `json:"max_per_user_monthly" gorm:"not null;default:0"`
+	MaxDaily          int `json:"max_daily" gorm:"not null;default:0"`
+
+	// aggregated from ratings at query time, never persisted
+	AvgRating   float64 `json:"avg_rating" gorm:"->;-:migration"`
+	RatingCount int64   `json:"rating_count" gorm:"->;-:migration"`
+
+	// Stock is the number of available gift codes, counted at query time
+	Stock int `json:"stock" gorm:"column:available_stock;->;-:migration"`
 
 	RedeemedUsers []Redemption `json:"redeemed_users" gorm:"many2many:redemptions;constraint:onDelete:CASCADE;"`
 }
@@ -41,6 +74,23 @@ func (gift *Gift) BeforeDelete(tx *gorm.DB) (err error) {
 	return nil
 }
 
+// HasStarted reports whether the availability window of the gift has opened at
+// the given time. Gifts without a start date are available right away.
+func (gift *Gift) HasStarted(now time.Time) bool {
+	return gift.AvailableFrom == nil || !now.Before(*gift.AvailableFrom)
+}
+
+// IsExpired reports whether the gift can no longer be redeemed at the given
+// time. Gifts without an expiry date never expire.
+func (gift *Gift) IsExpired(now time.Time) bool {
+	return gift.ExpiredAt != nil && !now.Before(*gift.ExpiredAt)
+}
+
+// IsAvailable reports whether the gift can be redeemed at the given time.
+func (gift *Gift) IsAvailable(now time.Time) bool {
+	return gift.HasStarted(now) && !gift.IsExpired(now)
+}
+
 func (Gift) TableName() string {
 	return "gifts"
 }
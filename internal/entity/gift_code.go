@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type GiftCodeStatus string
+
+const (
+	// GIFT_CODE_AVAILABLE codes count towards the stock of their gift
+	GIFT_CODE_AVAILABLE GiftCodeStatus = "AVAILABLE"
+	// GIFT_CODE_RESERVED codes are held for a redemption that is not fulfilled yet
+	GIFT_CODE_RESERVED GiftCodeStatus = "RESERVED"
+	// GIFT_CODE_ISSUED codes were handed out to a redeemer
+	GIFT_CODE_ISSUED GiftCodeStatus = "ISSUED"
+	// GIFT_CODE_VOID codes were withdrawn and are never handed out
+	GIFT_CODE_VOID GiftCodeStatus = "VOID"
+)
+
+// GiftCode is a single unit of a gift, typically a code bought from a supplier.
+// Every redemption is assigned exactly one code. The code is only stored
+// encrypted, see utils.CodeCipher; CodeHash identifies it and CodeHint helps
+// admins tell codes apart.
+type GiftCode struct {
+	ID            uuid.UUID      `json:"id" gorm:"type:char(36);primaryKey"`
+	GiftID        uuid.UUID      `json:"gift_id" gorm:"type:char(36);not null;index:idx_gift_codes_gift_status"`
+	CodeHash      string         `json:"-" gorm:"type:char(64);unique;default:null"`
+	CodeHint      string         `json:"code_hint" gorm:"type:varchar(8)"`
+	EncryptedCode string         `json:"-" gorm:"type:text;default:null"`
+	EncryptedKey  string         `json:"-" gorm:"type:varchar(255);default:null"`
+	KeyID         string         `json:"-" gorm:"type:varchar(64);default:null;index"`
+	Status        GiftCodeStatus `json:"status" gorm:"type:varchar(20);not null;default:AVAILABLE;index:idx_gift_codes_gift_status"`
+	RedemptionID  *uuid.UUID     `json:"redemption_id" gorm:"type:char(36);unique;default:null"`
+	IssuedAt      *time.Time     `json:"issued_at" gorm:"default:null"`
+	VoidedAt      *time.Time     `json:"voided_at" gorm:"default:null"`
+	CreatedAt     time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+
+	Gift Gift `json:"-" gorm:"foreignKey:GiftID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (code *GiftCode) BeforeCreate(tx *gorm.DB) (err error) {
+	if code.ID == uuid.Nil {
+		code.ID = uuid.New()
+	}
+	return nil
+}
+
+func (GiftCode) TableName() string {
+	return "gift_codes"
+}
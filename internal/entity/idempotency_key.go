@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type IdempotencyKeyStatus string
+
+const (
+	IDEMPOTENCY_KEY_PROCESSING IdempotencyKeyStatus = "PROCESSING"
+	IDEMPOTENCY_KEY_COMPLETED  IdempotencyKeyStatus = "COMPLETED"
+)
+
+// IdempotencyKey remembers the response to a request sent with an
+// Idempotency-Key header, so a retry of the request is answered with the same
+// response instead of being executed again. Fingerprint is the SHA-256 of the
+// method, path and body of the request.
+type IdempotencyKey struct {
+	ID           uuid.UUID            `json:"id" gorm:"type:char(36);primaryKey"`
+	UserID       uuid.UUID            `json:"user_id" gorm:"type:char(36);not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	Key          string               `json:"key" gorm:"column:idempotency_key;type:varchar(255);not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	Fingerprint  string               `json:"-" gorm:"type:char(64);not null"`
+	Status       IdempotencyKeyStatus `json:"status" gorm:"type:varchar(20);not null;default:PROCESSING"`
+	ResponseCode int                  `json:"response_code" gorm:"default:null"`
+	ResponseBody string               `json:"-" gorm:"type:text;default:null"`
+	ExpiresAt    time.Time            `json:"expires_at" gorm:"not null;index"`
+	CreatedAt    time.Time            `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time            `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (idempotencyKey *IdempotencyKey) BeforeCreate(tx *gorm.DB) (err error) {
+	if idempotencyKey.ID == uuid.Nil {
+		idempotencyKey.ID = uuid.New()
+	}
+	return nil
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type LoginAttemptScope string
+
+const (
+	LOGIN_ATTEMPT_EMAIL      LoginAttemptScope = "EMAIL"
+	LOGIN_ATTEMPT_IP         LoginAttemptScope = "IP"
+	LOGIN_ATTEMPT_TWO_FACTOR LoginAttemptScope = "TWO_FACTOR"
+)
+
+// LoginAttempt counts the consecutive failed logins of an email address or a
+// client IP, and the wrong second factor codes entered for a user ID. Emails
+// are tracked whether or not they belong to a user, so the throttling does not
+// reveal which accounts exist.
+type LoginAttempt struct {
+	ID           uuid.UUID         `json:"id" gorm:"type:char(36);primaryKey"`
+	Scope        LoginAttemptScope `json:"scope" gorm:"type:varchar(10);not null;uniqueIndex:idx_login_attempts_scope_identifier"`
+	Identifier   string            `json:"identifier" gorm:"type:varchar(255);not null;uniqueIndex:idx_login_attempts_scope_identifier"`
+	Failures     int               `json:"failures" gorm:"not null;default:0"`
+	LastFailedAt time.Time         `json:"last_failed_at"`
+	LockedUntil  *time.Time        `json:"locked_until" gorm:"default:null"`
+	CreatedAt    time.Time         `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time         `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (loginAttempt *LoginAttempt) BeforeCreate(tx *gorm.DB) (err error) {
+	if loginAttempt.ID == uuid.Nil {
+		loginAttempt.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsLocked reports whether the lockout is still running at the given time.
+func (loginAttempt *LoginAttempt) IsLocked(now time.Time) bool {
+	return loginAttempt.LockedUntil != nil && loginAttempt.LockedUntil.After(now)
+}
+
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
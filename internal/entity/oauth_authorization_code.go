@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OAuthAuthorizationCode is the short lived, single-use code handed to a client
+// after the user approved its request. Only the hash of the code is stored.
+type OAuthAuthorizationCode struct {
+	ID                  uuid.UUID  `json:"id" gorm:"type:char(36);primaryKey"`
+	CodeHash            string     `json:"-" gorm:"type:char(64);unique;not null"`
+	OAuthClientID       uuid.UUID  `json:"oauth_client_id" gorm:"type:char(36);not null;index"`
+	UserID              uuid.UUID  `json:"user_id" gorm:"type:char(36);not null;index"`
+	RedirectURI         string     `json:"redirect_uri" gorm:"type:text;not null"`
+	Scopes              string     `json:"scopes" gorm:"type:text"`
+	CodeChallenge       string     `json:"-" gorm:"type:varchar(128);not null"`
+	CodeChallengeMethod string     `json:"code_challenge_method" gorm:"type:varchar(10);not null"`
+	ExpiredAt           time.Time  `json:"expired_at" gorm:"not null"`
+	UsedAt              *time.Time `json:"used_at" gorm:"default:null"`
+	AccessTokenJTI      string     `json:"-" gorm:"type:char(36);default:null"`
+	CreatedAt           time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt           time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	OAuthClient OAuthClient `json:"-" gorm:"foreignKey:OAuthClientID;references:ID;constraint:OnDelete:CASCADE"`
+	User        User        `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (code *OAuthAuthorizationCode) BeforeCreate(tx *gorm.DB) (err error) {
+	if code.ID == uuid.Nil {
+		code.ID = uuid.New()
+	}
+	return nil
+}
+
+func (OAuthAuthorizationCode) TableName() string {
+	return "oauth_authorization_codes"
+}
+
+func (code *OAuthAuthorizationCode) ScopeList() []string {
+	return strings.Fields(code.Scopes)
+}
@@ -0,0 +1,81 @@
+package entity
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	OAUTH_GRANT_AUTHORIZATION_CODE = "authorization_code"
+	OAUTH_GRANT_CLIENT_CREDENTIALS = "client_credentials"
+)
+
+// OAuthClient is a partner application allowed to request tokens. Scopes,
+// redirect URIs and grant types are stored space separated, the same way
+// OAuth2 transmits scopes.
+type OAuthClient struct {
+	gorm.Model   `json:"-"`
+	ID           uuid.UUID `json:"id" gorm:"type:char(36);primaryKey"`
+	ClientID     string    `json:"client_id" gorm:"type:varchar(64);unique;not null"`
+	SecretHash   string    `json:"-" gorm:"type:char(64);default:null"`
+	Name         string    `json:"name" gorm:"not null"`
+	RedirectURIs string    `json:"redirect_uris" gorm:"type:text"`
+	Scopes       string    `json:"scopes" gorm:"type:text"`
+	GrantTypes   string    `json:"grant_types" gorm:"type:varchar(255);not null"`
+	Confidential bool      `json:"confidential" gorm:"not null;default:false"`
+}
+
+func (client *OAuthClient) BeforeCreate(tx *gorm.DB) (err error) {
+	client.ID = uuid.New()
+	return nil
+}
+
+func (client *OAuthClient) BeforeDelete(tx *gorm.DB) (err error) {
+	if client.DeletedAt.Valid {
+		return nil
+	}
+
+	randomString := uuid.New().String()
+
+	client.ClientID = client.ClientID + "_deleted_" + randomString
+	tx.Model(&OAuthClient{}).Where("id = ?", client.ID).Updates(map[string]interface{}{
+		"client_id": client.ClientID,
+	})
+	return nil
+}
+
+func (OAuthClient) TableName() string {
+	return "oauth_clients"
+}
+
+func (client *OAuthClient) ScopeList() []string {
+	return strings.Fields(client.Scopes)
+}
+
+func (client *OAuthClient) RedirectURIList() []string {
+	return strings.Fields(client.RedirectURIs)
+}
+
+func (client *OAuthClient) GrantTypeList() []string {
+	return strings.Fields(client.GrantTypes)
+}
+
+func (client *OAuthClient) HasRedirectURI(uri string) bool {
+	for _, redirectURI := range client.RedirectURIList() {
+		if redirectURI == uri {
+			return true
+		}
+	}
+	return false
+}
+
+func (client *OAuthClient) AllowsGrantType(grantType string) bool {
+	for _, allowed := range client.GrantTypeList() {
+		if allowed == grantType {
+			return true
+		}
+	}
+	return false
+}
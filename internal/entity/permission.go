@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	PERMISSION_GIFTS_MANAGE         = "gifts.manage"
+	PERMISSION_GIFTS_REDEEM         = "gifts.redeem"
+	PERMISSION_POINTS_MANAGE        = "points.manage"
+	PERMISSION_REDEMPTIONS_MANAGE   = "redemptions.manage"
+	PERMISSION_REDEMPTIONS_VIEW_ALL = "redemptions.view_all"
+	PERMISSION_ROLES_MANAGE         = "roles.manage"
+	PERMISSION_USERS_MANAGE         = "users.manage"
+)
+
+type Permission struct {
+	gorm.Model
+	ID          uuid.UUID `json:"id" gorm:"type:char(36);primaryKey"`
+	Name        string    `json:"name" gorm:"unique;not null"`
+	GuardName   string    `json:"guard_name" gorm:"default:web"`
+	Description string    `json:"description" gorm:"type:text;default:null"`
+	Roles       []Role    `json:"roles" gorm:"many2many:role_permissions;"`
+}
+
+func (permission *Permission) BeforeCreate(tx *gorm.DB) (err error) {
+	permission.ID = uuid.New()
+	return nil
+}
+
+func (Permission) TableName() string {
+	return "permissions"
+}
@@ -0,0 +1,77 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PointBatchStatus string
+type PointBatchRowStatus string
+
+const (
+	POINT_BATCH_PROCESSING PointBatchStatus = "PROCESSING"
+	POINT_BATCH_COMPLETED  PointBatchStatus = "COMPLETED"
+)
+
+const (
+	POINT_BATCH_ROW_SUCCESS PointBatchRowStatus = "SUCCESS"
+	POINT_BATCH_ROW_FAILED  PointBatchRowStatus = "FAILED"
+)
+
+// PointBatch is a CSV upload crediting points to many users. BatchKey is chosen
+// by the uploader and makes the upload idempotent: uploading the same file with
+// the same key again returns the existing report, or finishes the rows an
+// interrupted upload did not get to.
+type PointBatch struct {
+	ID            uuid.UUID        `json:"id" gorm:"type:char(36);primaryKey"`
+	BatchKey      string           `json:"batch_key" gorm:"type:varchar(100);unique;not null"`
+	FileName      string           `json:"file_name" gorm:"type:varchar(255)"`
+	FileHash      string           `json:"-" gorm:"type:char(64);not null"`
+	Reason        string           `json:"reason" gorm:"type:varchar(255)"`
+	Status        PointBatchStatus `json:"status" gorm:"type:varchar(20);not null;default:PROCESSING"`
+	TotalRows     int              `json:"total_rows" gorm:"not null;default:0"`
+	SucceededRows int              `json:"succeeded_rows" gorm:"not null;default:0"`
+	FailedRows    int              `json:"failed_rows" gorm:"not null;default:0"`
+	ActorID       uuid.UUID        `json:"actor_id" gorm:"type:char(36);not null;index"`
+	CreatedAt     time.Time        `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time        `json:"updated_at" gorm:"autoUpdateTime"`
+
+	Rows []PointBatchRow `json:"rows" gorm:"foreignKey:BatchID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (batch *PointBatch) BeforeCreate(tx *gorm.DB) (err error) {
+	if batch.ID == uuid.Nil {
+		batch.ID = uuid.New()
+	}
+	return nil
+}
+
+func (PointBatch) TableName() string {
+	return "point_batches"
+}
+
+// PointBatchRow is the outcome of a single CSV row of a PointBatch.
+type PointBatchRow struct {
+	ID        uuid.UUID           `json:"id" gorm:"type:char(36);primaryKey"`
+	BatchID   uuid.UUID           `json:"batch_id" gorm:"type:char(36);not null;uniqueIndex:idx_point_batch_rows_batch_row"`
+	RowNumber int                 `json:"row_number" gorm:"not null;uniqueIndex:idx_point_batch_rows_batch_row"`
+	Email     string              `json:"email" gorm:"type:varchar(255)"`
+	Amount    int64               `json:"amount" gorm:"not null;default:0"`
+	Status    PointBatchRowStatus `json:"status" gorm:"type:varchar(20);not null"`
+	Error     string              `json:"error" gorm:"type:varchar(255);default:null"`
+	LedgerID  *uuid.UUID          `json:"ledger_id" gorm:"type:char(36);default:null"`
+	CreatedAt time.Time           `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (row *PointBatchRow) BeforeCreate(tx *gorm.DB) (err error) {
+	if row.ID == uuid.Nil {
+		row.ID = uuid.New()
+	}
+	return nil
+}
+
+func (PointBatchRow) TableName() string {
+	return "point_batch_rows"
+}
@@ -0,0 +1,69 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PointEntryType string
+
+const (
+	POINT_CREDIT   PointEntryType = "CREDIT"
+	POINT_DEBIT    PointEntryType = "DEBIT"
+	POINT_REVERSAL PointEntryType = "REVERSAL"
+)
+
+// What the ReferenceID of a ledger entry points to.
+const (
+	POINT_REFERENCE_REDEMPTION  = "REDEMPTION"
+	POINT_REFERENCE_POINT_BATCH = "POINT_BATCH"
+)
+
+// ErrPointLedgerAppendOnly is returned when a ledger entry is about to be
+// changed or deleted. Mistakes are corrected with a reversal entry instead.
+var ErrPointLedgerAppendOnly = errors.New("point ledger entries cannot be changed or deleted")
+
+// PointLedger is an append-only record of every change to a point wallet.
+// Amount is signed: positive for credits, negative for debits, and the opposite
+// of the reversed entry for reversals.
+type PointLedger struct {
+	ID            uuid.UUID      `json:"id" gorm:"type:char(36);primaryKey"`
+	WalletID      uuid.UUID      `json:"wallet_id" gorm:"type:char(36);not null;index"`
+	UserID        uuid.UUID      `json:"user_id" gorm:"type:char(36);not null;index"`
+	Type          PointEntryType `json:"type" gorm:"type:varchar(20);not null"`
+	Amount        int64          `json:"amount" gorm:"not null"`
+	BalanceAfter  int64          `json:"balance_after" gorm:"not null"`
+	Reason        string         `json:"reason" gorm:"type:varchar(255);not null"`
+	ReferenceType string         `json:"reference_type" gorm:"type:varchar(50);default:null;index:idx_point_ledgers_reference"`
+	ReferenceID   *uuid.UUID     `json:"reference_id" gorm:"type:char(36);default:null;index:idx_point_ledgers_reference"`
+	ReversalOfID  *uuid.UUID     `json:"reversal_of_id" gorm:"type:char(36);unique;default:null"`
+	CreatedAt     time.Time      `json:"created_at" gorm:"autoCreateTime"`
+
+	// ActorID is the admin who granted or deducted the points, empty for
+	// entries caused by the user themselves
+	ActorID *uuid.UUID `json:"actor_id" gorm:"type:char(36);default:null;index"`
+
+	Wallet PointWallet `json:"-" gorm:"foreignKey:WalletID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (entry *PointLedger) BeforeCreate(tx *gorm.DB) (err error) {
+	if entry.ID == uuid.Nil {
+		entry.ID = uuid.New()
+	}
+	return nil
+}
+
+func (entry *PointLedger) BeforeUpdate(tx *gorm.DB) (err error) {
+	return ErrPointLedgerAppendOnly
+}
+
+func (entry *PointLedger) BeforeDelete(tx *gorm.DB) (err error) {
+	return ErrPointLedgerAppendOnly
+}
+
+func (PointLedger) TableName() string {
+	return "point_ledgers"
+}
@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PointWallet holds the points balance of a user. The balance is only changed
+// together with a PointLedger entry, which is the source of truth for it.
+type PointWallet struct {
+	ID        uuid.UUID `json:"id" gorm:"type:char(36);primaryKey"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:char(36);unique;not null"`
+	Balance   int64     `json:"balance" gorm:"not null;default:0"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	User User `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (wallet *PointWallet) BeforeCreate(tx *gorm.DB) (err error) {
+	if wallet.ID == uuid.Nil {
+		wallet.ID = uuid.New()
+	}
+	return nil
+}
+
+func (PointWallet) TableName() string {
+	return "point_wallets"
+}
@@ -7,6 +7,35 @@ import (
 	"gorm.io/gorm"
 )
 
+type RedemptionStatus string
+
+const (
+	REDEMPTION_PENDING   RedemptionStatus = "PENDING"
+	REDEMPTION_FULFILLED RedemptionStatus = "FULFILLED"
+	REDEMPTION_SHIPPED   RedemptionStatus = "SHIPPED"
+	REDEMPTION_CANCELLED RedemptionStatus = "CANCELLED"
+	REDEMPTION_REFUNDED  RedemptionStatus = "REFUNDED"
+)
+
+// redemptionTransitions lists the statuses a redemption may move to from each
+// status. Cancelled and refunded redemptions are final.
+var redemptionTransitions = map[RedemptionStatus][]RedemptionStatus{
+	REDEMPTION_PENDING:   {REDEMPTION_FULFILLED, REDEMPTION_CANCELLED},
+	REDEMPTION_FULFILLED: {REDEMPTION_SHIPPED, REDEMPTION_REFUNDED},
+	REDEMPTION_SHIPPED:   {REDEMPTION_REFUNDED},
+}
+
+// CanTransitionTo reports whether a redemption in this status may move to the
+// given status.
+func (status RedemptionStatus) CanTransitionTo(to RedemptionStatus) bool {
+	for _, allowed := range redemptionTransitions[status] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
 type Redemption struct {
 	gorm.Model `json:"-"`
 	ID         uuid.UUID `json:"id" gorm:"type:char(36);primaryKey"`
@@ -14,10 +43,24 @@ type Redemption struct {
 	GiftID     uuid.UUID `json:"gift_id" gorm:"type:char(36);not null"`
 	RedeemedAt time.Time `json:"redeemed_at" gorm:"default:CURRENT_TIMESTAMP"`
 
+	// PointsSpent is the gift price at the time of redemption
+	PointsSpent int64 `json:"points_spent" gorm:"not null;default:0"`
+
+	// GiftCodeID is the unit of the gift handed out for this redemption
+	GiftCodeID *uuid.UUID `json:"gift_code_id" gorm:"type:char(36);unique;default:null"`
+
+	// Status defaults to fulfilled for redemptions made before statuses existed,
+	// those were handed their gift code right away
+	Status RedemptionStatus `json:"status" gorm:"type:varchar(20);not null;default:FULFILLED;index"`
+
 	User User `json:"user" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
 	Gift Gift `json:"gift" gorm:"foreignKey:GiftID;references:ID;constraint:OnDelete:CASCADE"`
 
+	GiftCode *GiftCode `json:"gift_code" gorm:"foreignKey:GiftCodeID;references:ID"`
+
 	Rating *Rating `json:"rating" gorm:"foreignKey:RedemptionID;references:ID;constraint:OnDelete:CASCADE"`
+
+	Events []RedemptionEvent `json:"events" gorm:"foreignKey:RedemptionID;references:ID;constraint:OnDelete:CASCADE"`
 }
 
 func (redemption *Redemption) BeforeCreate(tx *gorm.DB) (err error) {
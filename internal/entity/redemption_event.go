@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RedemptionEvent records a status change of a redemption. FromStatus is empty
+// for the event that created the redemption.
+type RedemptionEvent struct {
+	ID           uuid.UUID        `json:"id" gorm:"type:char(36);primaryKey"`
+	RedemptionID uuid.UUID        `json:"redemption_id" gorm:"type:char(36);not null;index"`
+	FromStatus   RedemptionStatus `json:"from_status" gorm:"type:varchar(20);default:null"`
+	ToStatus     RedemptionStatus `json:"to_status" gorm:"type:varchar(20);not null"`
+	ActorID      *uuid.UUID       `json:"actor_id" gorm:"type:char(36);default:null"`
+	Note         string           `json:"note" gorm:"type:varchar(255);default:null"`
+	CreatedAt    time.Time        `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (event *RedemptionEvent) BeforeCreate(tx *gorm.DB) (err error) {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	return nil
+}
+
+func (RedemptionEvent) TableName() string {
+	return "redemption_events"
+}
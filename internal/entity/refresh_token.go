@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefreshToken is a single-use token exchanged for a new access token. Every
+// rotation creates a new row in the same family, so a reused token can revoke
+// the whole chain it belongs to.
+type RefreshToken struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:char(36);primaryKey"`
+	UserID       uuid.UUID  `json:"user_id" gorm:"type:char(36);not null;index"`
+	FamilyID     uuid.UUID  `json:"family_id" gorm:"type:char(36);not null;index"`
+	TokenHash    string     `json:"-" gorm:"type:char(64);unique;not null"`
+	ExpiredAt    time.Time  `json:"expired_at" gorm:"not null"`
+	RevokedAt    *time.Time `json:"revoked_at" gorm:"default:null"`
+	ReplacedByID *uuid.UUID `json:"replaced_by_id" gorm:"type:char(36);default:null"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// TwoFactorVerified carries over to every rotation of the family, so the
+	// session keeps the second factor it was started with.
+	TwoFactorVerified bool `json:"two_factor_verified" gorm:"not null;default:false"`
+
+	User User `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (refreshToken *RefreshToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if refreshToken.ID == uuid.Nil {
+		refreshToken.ID = uuid.New()
+	}
+	return nil
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
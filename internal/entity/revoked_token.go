@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RevokedToken is the denylist of access tokens, keyed by their jti claim,
+// kept until the token would have expired anyway.
+type RevokedToken struct {
+	JTI       string    `json:"jti" gorm:"type:char(36);primaryKey"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:char(36);not null;index"`
+	ExpiredAt time.Time `json:"expired_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (RevokedToken) TableName() string {
+	return "revoked_tokens"
+}
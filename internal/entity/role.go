@@ -1,8 +1,6 @@
 package entity
 
 import (
-	"time"
-
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -16,11 +14,16 @@ const (
 
 type Role struct {
 	gorm.Model
-	ID        uuid.UUID  `json:"id" gorm:"type:char(36);primaryKey"`
-	Name      string     `json:"name" gorm:"not null"`
-	GuardName string     `json:"guard_name" gorm:"default:web"`
-	Status    RoleStatus `json:"status" gorm:"default:ACTIVE"`
-	Users     []User     `json:"users" gorm:"many2many:user_roles;"` // many to many relationship
+	ID          uuid.UUID    `json:"id" gorm:"type:char(36);primaryKey"`
+	Name        string       `json:"name" gorm:"not null"`
+	GuardName   string       `json:"guard_name" gorm:"default:web"`
+	Status      RoleStatus   `json:"status" gorm:"default:ACTIVE"`
+	Users       []User       `json:"users" gorm:"many2many:user_roles;"` // many to many relationship
+	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions;"`
+
+	// RequireTwoFactor limits members of the role to enrolling in 2FA until
+	// they sign in with a second factor.
+	RequireTwoFactor bool `json:"require_two_factor" gorm:"not null;default:false"`
 	// CreatedAt     time.Time    `gorm:"autoCreateTime"`
 	// UpdatedAt     time.Time    `gorm:"autoUpdateTime"`
 	// DeletedAt     gorm.DeletedAt
@@ -31,11 +34,6 @@ func (role *Role) BeforeCreate(tx *gorm.DB) (err error) {
 	return nil
 }
 
-func (role *Role) BeforeUpdate(tx *gorm.DB) (err error) {
-	role.UpdatedAt = time.Now().Add(time.Hour * 7) // ganti ini misal mau pake timezone lain
-	return nil
-}
-
 func (Role) TableName() string {
 	return "roles"
 }
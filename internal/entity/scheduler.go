@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SchedulerRunStatus string
+
+const (
+	SCHEDULER_RUN_RUNNING   SchedulerRunStatus = "RUNNING"
+	SCHEDULER_RUN_SUCCEEDED SchedulerRunStatus = "SUCCEEDED"
+	SCHEDULER_RUN_FAILED    SchedulerRunStatus = "FAILED"
+)
+
+// SchedulerLease makes sure a scheduled job runs on one instance only. An
+// instance holds the lease of a job until LockedUntil, and LastScheduledAt is
+// the last tick of the schedule that was run, so no tick runs twice even when
+// the clocks of the instances drift apart.
+type SchedulerLease struct {
+	JobName         string     `json:"job_name" gorm:"type:varchar(100);primaryKey"`
+	Owner           string     `json:"owner" gorm:"type:varchar(100);default:null"`
+	LockedUntil     *time.Time `json:"locked_until" gorm:"default:null"`
+	LastScheduledAt *time.Time `json:"last_scheduled_at" gorm:"default:null"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (SchedulerLease) TableName() string {
+	return "scheduler_leases"
+}
+
+// SchedulerRun is the history of the scheduled jobs, one row per run.
+type SchedulerRun struct {
+	ID          uuid.UUID          `json:"id" gorm:"type:char(36);primaryKey"`
+	JobName     string             `json:"job_name" gorm:"type:varchar(100);not null;index"`
+	Owner       string             `json:"owner" gorm:"type:varchar(100);not null"`
+	ScheduledAt time.Time          `json:"scheduled_at" gorm:"not null"`
+	StartedAt   time.Time          `json:"started_at" gorm:"not null;index"`
+	FinishedAt  *time.Time         `json:"finished_at" gorm:"default:null"`
+	Status      SchedulerRunStatus `json:"status" gorm:"type:varchar(20);not null;default:RUNNING"`
+	Message     string             `json:"message" gorm:"type:text;default:null"`
+}
+
+func (schedulerRun *SchedulerRun) BeforeCreate(tx *gorm.DB) (err error) {
+	if schedulerRun.ID == uuid.Nil {
+		schedulerRun.ID = uuid.New()
+	}
+	return nil
+}
+
+func (SchedulerRun) TableName() string {
+	return "scheduler_runs"
+}
@@ -33,6 +33,13 @@ type User struct {
 	Status          UserStatus `json:"status" gorm:"default:PENDING"`
 	Roles           []Role     `json:"roles" gorm:"many2many:user_roles;"`
 
+	// TwoFactorSecret is set by the setup step and only trusted once
+	// TwoFactorEnabledAt is set. TwoFactorLastStep is the last accepted TOTP
+	// time step, so a code cannot be replayed.
+	TwoFactorSecret    string     `json:"-" gorm:"type:varchar(64);default:null"`
+	TwoFactorEnabledAt *time.Time `json:"two_factor_enabled_at" gorm:"default:null"`
+	TwoFactorLastStep  int64      `json:"-" gorm:"not null;default:0"`
+
 	RedeemedGifts []Redemption `json:"redeemed_gifts" gorm:"many2many:redemptions;constraint:onDelete:CASCADE;"`
 }
 
@@ -59,6 +66,10 @@ func (user *User) BeforeDelete(tx *gorm.DB) (err error) {
 	return nil
 }
 
+func (user *User) TwoFactorEnabled() bool {
+	return user.TwoFactorEnabledAt != nil
+}
+
 func (User) TableName() string {
 	return "users"
 }
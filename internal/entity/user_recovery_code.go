@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserRecoveryCode is a single-use code that replaces a TOTP code when the
+// authenticator is lost. Only the hash of the code is stored.
+type UserRecoveryCode struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:char(36);primaryKey"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:char(36);not null;index"`
+	CodeHash  string     `json:"-" gorm:"type:char(64);not null"`
+	UsedAt    *time.Time `json:"used_at" gorm:"default:null"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+
+	User User `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (code *UserRecoveryCode) BeforeCreate(tx *gorm.DB) (err error) {
+	code.ID = uuid.New()
+	return nil
+}
+
+func (UserRecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
@@ -7,6 +7,7 @@ type UserTokenType string
 const (
 	UserTokenVerification  UserTokenType = "VERIFICATION"
 	UserTokenResetPassword UserTokenType = "RESET_PASSWORD"
+	UserTokenUnlockAccount UserTokenType = "UNLOCK_ACCOUNT"
 )
 
 type UserToken struct {
@@ -14,8 +15,11 @@ type UserToken struct {
 	Token     int           `json:"token"`
 	TokenType UserTokenType `json:"token_type"`
 	ExpiredAt time.Time     `json:"expired_at"`
-	CreatedAt time.Time     `gorm:"autoCreateTime"`
-	UpdatedAt time.Time     `gorm:"autoUpdateTime"`
+	// Attempts counts the wrong codes tried for the email since the token was
+	// issued.
+	Attempts  int       `json:"attempts" gorm:"not null;default:0"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
 }
 
 func (UserToken) TableName() string {
@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
+	"github.com/sirupsen/logrus"
+)
+
+type IAPIKeyDTO interface {
+	ConvertEntityToAPIKeyResponse(payload *entity.APIKey) *response.APIKeyResponse
+	ConvertEntitiesToAPIKeyResponses(payload *[]entity.APIKey) *[]response.APIKeyResponse
+}
+
+type APIKeyDTO struct {
+	Log *logrus.Logger
+}
+
+func NewAPIKeyDTO(log *logrus.Logger) IAPIKeyDTO {
+	return &APIKeyDTO{
+		Log: log,
+	}
+}
+
+func APIKeyDTOFactory(log *logrus.Logger) IAPIKeyDTO {
+	return NewAPIKeyDTO(log)
+}
+
+func (a *APIKeyDTO) ConvertEntityToAPIKeyResponse(payload *entity.APIKey) *response.APIKeyResponse {
+	return &response.APIKeyResponse{
+		ID:         payload.ID,
+		Name:       payload.Name,
+		Prefix:     payload.Prefix,
+		Scopes:     payload.ScopeList(),
+		ExpiredAt:  payload.ExpiredAt,
+		LastUsedAt: payload.LastUsedAt,
+		RevokedAt:  payload.RevokedAt,
+		CreatedAt:  payload.CreatedAt,
+	}
+}
+
+func (a *APIKeyDTO) ConvertEntitiesToAPIKeyResponses(payload *[]entity.APIKey) *[]response.APIKeyResponse {
+	apiKeys := []response.APIKeyResponse{}
+	for _, apiKey := range *payload {
+		apiKeys = append(apiKeys, *a.ConvertEntityToAPIKeyResponse(&apiKey))
+	}
+	return &apiKeys
+}
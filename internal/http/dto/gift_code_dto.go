@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
+	"github.com/sirupsen/logrus"
+)
+
+type IGiftCodeDTO interface {
+	ConvertEntityToGiftCodeResponse(payload *entity.GiftCode) *response.GiftCodeResponse
+	ConvertEntitiesToGiftCodeResponses(payload *[]entity.GiftCode) *[]response.GiftCodeResponse
+}
+
+type GiftCodeDTO struct {
+	Log *logrus.Logger
+}
+
+func NewGiftCodeDTO(log *logrus.Logger) IGiftCodeDTO {
+	return &GiftCodeDTO{
+		Log: log,
+	}
+}
+
+func GiftCodeDTOFactory(log *logrus.Logger) IGiftCodeDTO {
+	return NewGiftCodeDTO(log)
+}
+
+func (g *GiftCodeDTO) ConvertEntityToGiftCodeResponse(payload *entity.GiftCode) *response.GiftCodeResponse {
+	return &response.GiftCodeResponse{
+		ID:           payload.ID,
+		GiftID:       payload.GiftID,
+		CodeHint:     payload.CodeHint,
+		Status:       payload.Status,
+		RedemptionID: payload.RedemptionID,
+		IssuedAt:     payload.IssuedAt,
+		VoidedAt:     payload.VoidedAt,
+		CreatedAt:    payload.CreatedAt,
+		UpdatedAt:    payload.UpdatedAt,
+	}
+}
+
+func (g *GiftCodeDTO) ConvertEntitiesToGiftCodeResponses(payload *[]entity.GiftCode) *[]response.GiftCodeResponse {
+	codes := []response.GiftCodeResponse{}
+	for _, code := range *payload {
+		codes = append(codes, *g.ConvertEntityToGiftCodeResponse(&code))
+	}
+	return &codes
+}
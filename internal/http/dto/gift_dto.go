@@ -0,0 +1,78 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+type IGiftDTO interface {
+	ConvertEntityToGiftResponse(payload *entity.Gift) *response.GiftResponse
+	ConvertEntitiesToGiftResponses(payload *[]entity.Gift) *[]response.GiftResponse
+}
+
+// GiftDTO shows the timestamps of a gift in Location, the app.timezone.
+type GiftDTO struct {
+	Log      *logrus.Logger
+	Location *time.Location
+}
+
+func NewGiftDTO(log *logrus.Logger, location *time.Location) IGiftDTO {
+	return &GiftDTO{
+		Log:      log,
+		Location: location,
+	}
+}
+
+func GiftDTOFactory(log *logrus.Logger, viper *viper.Viper) IGiftDTO {
+	location, err := utils.LoadTimezone(viper)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return NewGiftDTO(log, location)
+}
+
+func (g *GiftDTO) ConvertEntityToGiftResponse(payload *entity.Gift) *response.GiftResponse {
+	return &response.GiftResponse{
+		ID:            payload.ID,
+		RedeemCode:    payload.RedeemCode,
+		Name:          payload.Name,
+		Description:   payload.Description,
+		Price:         payload.Price,
+		Stock:         payload.Stock,
+		AvailableFrom: inLocation(payload.AvailableFrom, g.Location),
+		ExpiredAt:     inLocation(payload.ExpiredAt, g.Location),
+		Limits: response.GiftLimitsResponse{
+			MaxPerUser:        payload.MaxPerUser,
+			MaxPerUserDaily:   payload.MaxPerUserDaily,
+			MaxPerUserWeekly:  payload.MaxPerUserWeekly,
+			MaxPerUserMonthly: payload.MaxPerUserMonthly,
+			MaxDaily:          payload.MaxDaily,
+		},
+		AvgRating:   payload.AvgRating,
+		RatingCount: payload.RatingCount,
+		CreatedAt:   payload.CreatedAt.In(g.Location),
+		UpdatedAt:   payload.UpdatedAt.In(g.Location),
+	}
+}
+
+func (g *GiftDTO) ConvertEntitiesToGiftResponses(payload *[]entity.Gift) *[]response.GiftResponse {
+	gifts := []response.GiftResponse{}
+	for _, gift := range *payload {
+		gifts = append(gifts, *g.ConvertEntityToGiftResponse(&gift))
+	}
+	return &gifts
+}
+
+// inLocation converts an optional timestamp for display.
+func inLocation(t *time.Time, location *time.Location) *time.Time {
+	if t == nil {
+		return nil
+	}
+	converted := t.In(location)
+	return &converted
+}
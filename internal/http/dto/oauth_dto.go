@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
+	"github.com/sirupsen/logrus"
+)
+
+type IOAuthDTO interface {
+	ConvertEntityToOAuthClientResponse(payload *entity.OAuthClient) *response.OAuthClientResponse
+	ConvertEntitiesToOAuthClientResponses(payload *[]entity.OAuthClient) *[]response.OAuthClientResponse
+}
+
+type OAuthDTO struct {
+	Log *logrus.Logger
+}
+
+func NewOAuthDTO(log *logrus.Logger) IOAuthDTO {
+	return &OAuthDTO{
+		Log: log,
+	}
+}
+
+func OAuthDTOFactory(log *logrus.Logger) IOAuthDTO {
+	return NewOAuthDTO(log)
+}
+
+func (o *OAuthDTO) ConvertEntityToOAuthClientResponse(payload *entity.OAuthClient) *response.OAuthClientResponse {
+	return &response.OAuthClientResponse{
+		ID:           payload.ID,
+		ClientID:     payload.ClientID,
+		Name:         payload.Name,
+		RedirectURIs: payload.RedirectURIList(),
+		Scopes:       payload.ScopeList(),
+		GrantTypes:   payload.GrantTypeList(),
+		Confidential: payload.Confidential,
+		CreatedAt:    payload.CreatedAt,
+		UpdatedAt:    payload.UpdatedAt,
+	}
+}
+
+func (o *OAuthDTO) ConvertEntitiesToOAuthClientResponses(payload *[]entity.OAuthClient) *[]response.OAuthClientResponse {
+	clients := []response.OAuthClientResponse{}
+	for _, client := range *payload {
+		clients = append(clients, *o.ConvertEntityToOAuthClientResponse(&client))
+	}
+	return &clients
+}
@@ -0,0 +1,45 @@
+package dto
+
+import (
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
+	"github.com/sirupsen/logrus"
+)
+
+type IPermissionDTO interface {
+	ConvertEntityToPermissionResponse(payload *entity.Permission) *response.PermissionResponse
+	ConvertEntitiesToPermissionResponses(payload *[]entity.Permission) *[]response.PermissionResponse
+}
+
+type PermissionDTO struct {
+	Log *logrus.Logger
+}
+
+func NewPermissionDTO(log *logrus.Logger) IPermissionDTO {
+	return &PermissionDTO{
+		Log: log,
+	}
+}
+
+func PermissionDTOFactory(log *logrus.Logger) IPermissionDTO {
+	return NewPermissionDTO(log)
+}
+
+func (p *PermissionDTO) ConvertEntityToPermissionResponse(payload *entity.Permission) *response.PermissionResponse {
+	return &response.PermissionResponse{
+		ID:          payload.ID,
+		Name:        payload.Name,
+		GuardName:   payload.GuardName,
+		Description: payload.Description,
+		CreatedAt:   payload.CreatedAt,
+		UpdatedAt:   payload.UpdatedAt,
+	}
+}
+
+func (p *PermissionDTO) ConvertEntitiesToPermissionResponses(payload *[]entity.Permission) *[]response.PermissionResponse {
+	permissions := []response.PermissionResponse{}
+	for _, permission := range *payload {
+		permissions = append(permissions, *p.ConvertEntityToPermissionResponse(&permission))
+	}
+	return &permissions
+}
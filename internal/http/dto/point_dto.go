@@ -0,0 +1,111 @@
+package dto
+
+import (
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type IPointDTO interface {
+	ConvertEntityToPointBalanceResponse(userID uuid.UUID, payload *entity.PointWallet) *response.PointBalanceResponse
+	ConvertEntityToPointLedgerResponse(payload *entity.PointLedger) *response.PointLedgerResponse
+	ConvertEntitiesToPointLedgerResponses(payload *[]entity.PointLedger) *[]response.PointLedgerResponse
+	ConvertEntityToPointBatchResponse(payload *entity.PointBatch) *response.PointBatchResponse
+	ConvertEntitiesToPointBatchResponses(payload *[]entity.PointBatch) *[]response.PointBatchResponse
+}
+
+type PointDTO struct {
+	Log *logrus.Logger
+}
+
+func NewPointDTO(log *logrus.Logger) IPointDTO {
+	return &PointDTO{
+		Log: log,
+	}
+}
+
+func PointDTOFactory(log *logrus.Logger) IPointDTO {
+	return NewPointDTO(log)
+}
+
+// ConvertEntityToPointBalanceResponse reports a zero balance for users without
+// a wallet yet.
+func (p *PointDTO) ConvertEntityToPointBalanceResponse(userID uuid.UUID, payload *entity.PointWallet) *response.PointBalanceResponse {
+	if payload == nil {
+		return &response.PointBalanceResponse{
+			UserID: userID,
+		}
+	}
+
+	return &response.PointBalanceResponse{
+		UserID:    payload.UserID,
+		Balance:   payload.Balance,
+		UpdatedAt: &payload.UpdatedAt,
+	}
+}
+
+func (p *PointDTO) ConvertEntityToPointLedgerResponse(payload *entity.PointLedger) *response.PointLedgerResponse {
+	return &response.PointLedgerResponse{
+		ID:            payload.ID,
+		Type:          payload.Type,
+		Amount:        payload.Amount,
+		BalanceAfter:  payload.BalanceAfter,
+		Reason:        payload.Reason,
+		ReferenceType: payload.ReferenceType,
+		ReferenceID:   payload.ReferenceID,
+		ReversalOfID:  payload.ReversalOfID,
+		ActorID:       payload.ActorID,
+		CreatedAt:     payload.CreatedAt,
+	}
+}
+
+func (p *PointDTO) ConvertEntitiesToPointLedgerResponses(payload *[]entity.PointLedger) *[]response.PointLedgerResponse {
+	entries := []response.PointLedgerResponse{}
+	for _, entry := range *payload {
+		entries = append(entries, *p.ConvertEntityToPointLedgerResponse(&entry))
+	}
+	return &entries
+}
+
+// ConvertEntityToPointBatchResponse includes the rows only when they were loaded.
+func (p *PointDTO) ConvertEntityToPointBatchResponse(payload *entity.PointBatch) *response.PointBatchResponse {
+	var rows *[]response.PointBatchRowResponse
+	if payload.Rows != nil {
+		converted := []response.PointBatchRowResponse{}
+		for _, row := range payload.Rows {
+			converted = append(converted, response.PointBatchRowResponse{
+				RowNumber: row.RowNumber,
+				Email:     row.Email,
+				Amount:    row.Amount,
+				Status:    row.Status,
+				Error:     row.Error,
+				LedgerID:  row.LedgerID,
+			})
+		}
+		rows = &converted
+	}
+
+	return &response.PointBatchResponse{
+		ID:            payload.ID,
+		BatchKey:      payload.BatchKey,
+		FileName:      payload.FileName,
+		Reason:        payload.Reason,
+		Status:        payload.Status,
+		TotalRows:     payload.TotalRows,
+		SucceededRows: payload.SucceededRows,
+		FailedRows:    payload.FailedRows,
+		ActorID:       payload.ActorID,
+		CreatedAt:     payload.CreatedAt,
+		UpdatedAt:     payload.UpdatedAt,
+		Rows:          rows,
+	}
+}
+
+func (p *PointDTO) ConvertEntitiesToPointBatchResponses(payload *[]entity.PointBatch) *[]response.PointBatchResponse {
+	batches := []response.PointBatchResponse{}
+	for _, batch := range *payload {
+		batches = append(batches, *p.ConvertEntityToPointBatchResponse(&batch))
+	}
+	return &batches
+}
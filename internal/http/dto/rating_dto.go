@@ -0,0 +1,36 @@
+package dto
+
+import (
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
+	"github.com/sirupsen/logrus"
+)
+
+type IRatingDTO interface {
+	ConvertEntityToRatingResponse(payload *entity.Rating) *response.RatingResponse
+}
+
+type RatingDTO struct {
+	Log *logrus.Logger
+}
+
+func NewRatingDTO(log *logrus.Logger) IRatingDTO {
+	return &RatingDTO{
+		Log: log,
+	}
+}
+
+func RatingDTOFactory(log *logrus.Logger) IRatingDTO {
+	return NewRatingDTO(log)
+}
+
+func (r *RatingDTO) ConvertEntityToRatingResponse(payload *entity.Rating) *response.RatingResponse {
+	return &response.RatingResponse{
+		ID:           payload.ID,
+		RedemptionID: payload.RedemptionID,
+		Rating:       payload.Rating,
+		Comment:      payload.Comment,
+		CreatedAt:    payload.CreatedAt,
+		UpdatedAt:    payload.UpdatedAt,
+	}
+}
@@ -0,0 +1,94 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+type IRedemptionDTO interface {
+	ConvertEntityToRedemptionResponse(payload *entity.Redemption) *response.RedemptionResponse
+	ConvertEntitiesToRedemptionResponses(payload *[]entity.Redemption) *[]response.RedemptionResponse
+}
+
+// RedemptionDTO shows the timestamps of a redemption in Location, the
+// app.timezone.
+type RedemptionDTO struct {
+	Log       *logrus.Logger
+	Location  *time.Location
+	GiftDTO   IGiftDTO
+	RatingDTO IRatingDTO
+}
+
+func NewRedemptionDTO(log *logrus.Logger, location *time.Location, giftDTO IGiftDTO, ratingDTO IRatingDTO) IRedemptionDTO {
+	return &RedemptionDTO{
+		Log:       log,
+		Location:  location,
+		GiftDTO:   giftDTO,
+		RatingDTO: ratingDTO,
+	}
+}
+
+func RedemptionDTOFactory(log *logrus.Logger, viper *viper.Viper) IRedemptionDTO {
+	location, err := utils.LoadTimezone(viper)
+	if err != nil {
+		log.Fatal(err)
+	}
+	giftDTO := GiftDTOFactory(log, viper)
+	ratingDTO := RatingDTOFactory(log)
+	return NewRedemptionDTO(log, location, giftDTO, ratingDTO)
+}
+
+func (r *RedemptionDTO) ConvertEntityToRedemptionResponse(payload *entity.Redemption) *response.RedemptionResponse {
+	return &response.RedemptionResponse{
+		ID:          payload.ID,
+		UserID:      payload.UserID,
+		GiftID:      payload.GiftID,
+		RedeemedAt:  payload.RedeemedAt.In(r.Location),
+		Status:      payload.Status,
+		PointsSpent: payload.PointsSpent,
+		CreatedAt:   payload.CreatedAt.In(r.Location),
+		UpdatedAt:   payload.UpdatedAt.In(r.Location),
+		Gift: func() *response.GiftResponse {
+			if payload.Gift.ID == uuid.Nil {
+				return nil
+			}
+			return r.GiftDTO.ConvertEntityToGiftResponse(&payload.Gift)
+		}(),
+		Rating: func() *response.RatingResponse {
+			if payload.Rating == nil {
+				return nil
+			}
+			return r.RatingDTO.ConvertEntityToRatingResponse(payload.Rating)
+		}(),
+		Events: func() *[]response.RedemptionEventResponse {
+			if payload.Events == nil {
+				return nil
+			}
+			events := []response.RedemptionEventResponse{}
+			for _, event := range payload.Events {
+				events = append(events, response.RedemptionEventResponse{
+					FromStatus: event.FromStatus,
+					ToStatus:   event.ToStatus,
+					ActorID:    event.ActorID,
+					Note:       event.Note,
+					CreatedAt:  event.CreatedAt.In(r.Location),
+				})
+			}
+			return &events
+		}(),
+	}
+}
+
+func (r *RedemptionDTO) ConvertEntitiesToRedemptionResponses(payload *[]entity.Redemption) *[]response.RedemptionResponse {
+	redemptions := []response.RedemptionResponse{}
+	for _, redemption := range *payload {
+		redemptions = append(redemptions, *r.ConvertEntityToRedemptionResponse(&redemption))
+	}
+	return &redemptions
+}
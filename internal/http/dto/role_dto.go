@@ -12,17 +12,20 @@ type IRoleDTO interface {
 }
 
 type RoleDTO struct {
-	Log *logrus.Logger
+	Log           *logrus.Logger
+	PermissionDTO IPermissionDTO
 }
 
-func NewRoleDTO(log *logrus.Logger) IRoleDTO {
+func NewRoleDTO(log *logrus.Logger, permissionDTO IPermissionDTO) IRoleDTO {
 	return &RoleDTO{
-		Log: log,
+		Log:           log,
+		PermissionDTO: permissionDTO,
 	}
 }
 
 func RoleDTOFactory(log *logrus.Logger) IRoleDTO {
-	return NewRoleDTO(log)
+	permissionDTO := PermissionDTOFactory(log)
+	return NewRoleDTO(log, permissionDTO)
 }
 
 func (r *RoleDTO) ConvertEntityToRoleResponse(payload *entity.Role) *response.RoleResponse {
@@ -33,11 +36,18 @@ func (r *RoleDTO) ConvertEntityToRoleResponse(payload *entity.Role) *response.Ro
 		Status:    payload.Status,
 		CreatedAt: payload.CreatedAt,
 		UpdatedAt: payload.UpdatedAt,
+		Permissions: func() *[]response.PermissionResponse {
+			if len(payload.Permissions) == 0 {
+				return nil
+			}
+			return r.PermissionDTO.ConvertEntitiesToPermissionResponses(&payload.Permissions)
+		}(),
+		RequireTwoFactor: payload.RequireTwoFactor,
 	}
 }
 
 func (r *RoleDTO) ConvertEntitiesToRoleResponses(payload *[]entity.Role) *[]response.RoleResponse {
-	var roles []response.RoleResponse
+	roles := []response.RoleResponse{}
 	for _, role := range *payload {
 		roles = append(roles, *r.ConvertEntityToRoleResponse(&role))
 	}
@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+type ISchedulerDTO interface {
+	ConvertEntityToSchedulerRunResponse(payload *entity.SchedulerRun) *response.SchedulerRunResponse
+	ConvertEntitiesToSchedulerRunResponses(payload *[]entity.SchedulerRun) *[]response.SchedulerRunResponse
+}
+
+type SchedulerDTO struct {
+	Log      *logrus.Logger
+	Location *time.Location
+}
+
+func NewSchedulerDTO(log *logrus.Logger, location *time.Location) ISchedulerDTO {
+	return &SchedulerDTO{
+		Log:      log,
+		Location: location,
+	}
+}
+
+func SchedulerDTOFactory(log *logrus.Logger, viper *viper.Viper) ISchedulerDTO {
+	location, err := utils.LoadTimezone(viper)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return NewSchedulerDTO(log, location)
+}
+
+func (s *SchedulerDTO) ConvertEntityToSchedulerRunResponse(payload *entity.SchedulerRun) *response.SchedulerRunResponse {
+	return &response.SchedulerRunResponse{
+		ID:          payload.ID,
+		JobName:     payload.JobName,
+		Owner:       payload.Owner,
+		ScheduledAt: payload.ScheduledAt.In(s.Location),
+		StartedAt:   payload.StartedAt.In(s.Location),
+		FinishedAt:  inLocation(payload.FinishedAt, s.Location),
+		Status:      payload.Status,
+		Message:     payload.Message,
+	}
+}
+
+func (s *SchedulerDTO) ConvertEntitiesToSchedulerRunResponses(payload *[]entity.SchedulerRun) *[]response.SchedulerRunResponse {
+	runs := []response.SchedulerRunResponse{}
+	for _, run := range *payload {
+		runs = append(runs, *s.ConvertEntityToSchedulerRunResponse(&run))
+	}
+	return &runs
+}
@@ -18,7 +18,7 @@ type UserDTO struct {
 
 func NewUserDTO(log *logrus.Logger, roleDTO IRoleDTO) IUserDTO {
 	return &UserDTO{
-		Log: log,
+		Log:     log,
 		RoleDTO: roleDTO,
 	}
 }
@@ -49,11 +49,44 @@ func (u *UserDTO) ConvertEntityToUserResponse(payload *entity.User) *response.Us
 			}
 			return &roles
 		}(),
+		Permissions:       u.effectivePermissions(payload),
+		TwoFactorEnabled:  payload.TwoFactorEnabled(),
+		TwoFactorRequired: u.twoFactorRequired(payload),
 	}
 }
 
+// twoFactorRequired reports whether any active role of the user enforces 2FA.
+func (u *UserDTO) twoFactorRequired(payload *entity.User) bool {
+	for _, role := range payload.Roles {
+		if role.Status != entity.ROLE_INACTIVE && role.RequireTwoFactor {
+			return true
+		}
+	}
+	return false
+}
+
+// effectivePermissions collects the distinct permission names granted by the
+// user's active roles.
+func (u *UserDTO) effectivePermissions(payload *entity.User) []string {
+	permissions := []string{}
+	seen := make(map[string]struct{})
+	for _, role := range payload.Roles {
+		if role.Status == entity.ROLE_INACTIVE {
+			continue
+		}
+		for _, permission := range role.Permissions {
+			if _, ok := seen[permission.Name]; ok {
+				continue
+			}
+			seen[permission.Name] = struct{}{}
+			permissions = append(permissions, permission.Name)
+		}
+	}
+	return permissions
+}
+
 func (u *UserDTO) ConvertEntitiesToUserResponses(payload *[]entity.User) *[]response.UserResponse {
-	var users []response.UserResponse
+	users := []response.UserResponse{}
 	for _, user := range *payload {
 		users = append(users, *u.ConvertEntityToUserResponse(&user))
 	}
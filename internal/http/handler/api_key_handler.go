@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/middleware"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/request"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/usecase"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+type IAPIKeyHandler interface {
+	FindAllPaginated(ctx *gin.Context)
+	CreateAPIKey(ctx *gin.Context)
+	RevokeAPIKey(ctx *gin.Context)
+}
+
+type APIKeyHandler struct {
+	Log      *logrus.Logger
+	Viper    *viper.Viper
+	Validate *validator.Validate
+	UseCase  usecase.IAPIKeyUseCase
+}
+
+func NewAPIKeyHandler(
+	log *logrus.Logger,
+	viper *viper.Viper,
+	validate *validator.Validate,
+	useCase usecase.IAPIKeyUseCase,
+) IAPIKeyHandler {
+	return &APIKeyHandler{
+		Log:      log,
+		Viper:    viper,
+		Validate: validate,
+		UseCase:  useCase,
+	}
+}
+
+func APIKeyHandlerFactory(
+	log *logrus.Logger,
+	viper *viper.Viper,
+) IAPIKeyHandler {
+	useCase := usecase.APIKeyUseCaseFactory(log, viper)
+	validate := config.NewValidator(viper)
+	return NewAPIKeyHandler(log, viper, validate, useCase)
+}
+
+func (a *APIKeyHandler) FindAllPaginated(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		a.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	page, pageSize, search := getPagination(ctx)
+
+	apiKeys, total, err := a.UseCase.FindAllPaginated(userID, page, pageSize, search)
+	if err != nil {
+		a.Log.Error("[APIKeyHandler.FindAllPaginated] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.PaginatedResponse(ctx, http.StatusOK, "success", apiKeys, utils.NewPagination(page, pageSize, total))
+}
+
+func (a *APIKeyHandler) CreateAPIKey(ctx *gin.Context) {
+	var payload = new(request.APIKeyRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		a.Log.Error("[APIKeyHandler.CreateAPIKey] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	if err := a.Validate.Struct(payload); err != nil {
+		a.Log.Errorf("Error when validating request: %v", err)
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	claims, err := middleware.GetUser(ctx)
+	if err != nil {
+		a.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		a.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	// the key inherits the second factor of the session it was created from
+	twoFactorVerified, _ := claims["mfa"].(bool)
+
+	apiKey, err := a.UseCase.CreateAPIKey(userID, twoFactorVerified, payload)
+	if err != nil {
+		a.Log.Error("[APIKeyHandler.CreateAPIKey] " + err.Error())
+		switch {
+		case errors.Is(err, usecase.ErrAPIKeyScopeNotGranted), errors.Is(err, usecase.ErrAPIKeyExpiryInPast):
+			utils.BadRequestResponse(ctx, "bad request", err.Error())
+		case errors.Is(err, usecase.ErrUserNotFound):
+			utils.ErrorResponse(ctx, http.StatusNotFound, "error", err.Error())
+		default:
+			utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusCreated, "store the key somewhere safe, it will not be shown again", apiKey)
+}
+
+func (a *APIKeyHandler) RevokeAPIKey(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid API key id", err.Error())
+		return
+	}
+
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		a.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	revoked, err := a.UseCase.RevokeAPIKey(userID, id)
+	if err != nil {
+		a.Log.Error("[APIKeyHandler.RevokeAPIKey] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	if !revoked {
+		utils.ErrorResponse(ctx, http.StatusNotFound, "error", "API key not found")
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", nil)
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/usecase"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/repository"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+type IGiftCodeHandler interface {
+	FindAllPaginated(ctx *gin.Context)
+	ImportCodes(ctx *gin.Context)
+	VoidCode(ctx *gin.Context)
+}
+
+type GiftCodeHandler struct {
+	Log      *logrus.Logger
+	Viper    *viper.Viper
+	Validate *validator.Validate
+	UseCase  usecase.IGiftCodeUseCase
+}
+
+func NewGiftCodeHandler(
+	log *logrus.Logger,
+	viper *viper.Viper,
+	validate *validator.Validate,
+	useCase usecase.IGiftCodeUseCase,
+) IGiftCodeHandler {
+	return &GiftCodeHandler{
+		Log:      log,
+		Viper:    viper,
+		Validate: validate,
+		UseCase:  useCase,
+	}
+}
+
+func GiftCodeHandlerFactory(
+	log *logrus.Logger,
+	viper *viper.Viper,
+) IGiftCodeHandler {
+	useCase := usecase.GiftCodeUseCaseFactory(log, viper)
+	validate := config.NewValidator(viper)
+	return NewGiftCodeHandler(log, viper, validate, useCase)
+}
+
+func (g *GiftCodeHandler) FindAllPaginated(ctx *gin.Context) {
+	giftID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid gift id", err.Error())
+		return
+	}
+
+	status := ctx.Query("status")
+	switch entity.GiftCodeStatus(status) {
+	case "", entity.GIFT_CODE_AVAILABLE, entity.GIFT_CODE_RESERVED, entity.GIFT_CODE_ISSUED, entity.GIFT_CODE_VOID:
+	default:
+		utils.BadRequestResponse(ctx, "invalid status", status)
+		return
+	}
+
+	page, pageSize, search := getPagination(ctx)
+
+	codes, total, err := g.UseCase.FindAllPaginated(giftID, status, page, pageSize, search)
+	if err != nil {
+		g.Log.Error("[GiftCodeHandler.FindAllPaginated] " + err.Error())
+		g.errorResponse(ctx, err)
+		return
+	}
+
+	utils.PaginatedResponse(ctx, http.StatusOK, "success", codes, utils.NewPagination(page, pageSize, total))
+}
+
+// ImportCodes takes a multipart form with a CSV in the field file.
+func (g *GiftCodeHandler) ImportCodes(ctx *gin.Context) {
+	giftID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid gift id", err.Error())
+		return
+	}
+
+	_, content, ok := readUploadedFile(ctx, "file", int64(g.Viper.GetInt("gift_codes.import.max_file_size")))
+	if !ok {
+		return
+	}
+
+	result, err := g.UseCase.ImportCodes(giftID, content)
+	if err != nil {
+		g.Log.Error("[GiftCodeHandler.ImportCodes] " + err.Error())
+		g.errorResponse(ctx, err)
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", result)
+}
+
+func (g *GiftCodeHandler) VoidCode(ctx *gin.Context) {
+	giftID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid gift id", err.Error())
+		return
+	}
+
+	id, err := uuid.Parse(ctx.Param("code_id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid gift code id", err.Error())
+		return
+	}
+
+	code, err := g.UseCase.VoidCode(giftID, id)
+	if err != nil {
+		g.Log.Error("[GiftCodeHandler.VoidCode] " + err.Error())
+		g.errorResponse(ctx, err)
+		return
+	}
+
+	if code == nil {
+		utils.ErrorResponse(ctx, http.StatusNotFound, "error", "Gift code not found")
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", code)
+}
+
+func (g *GiftCodeHandler) errorResponse(ctx *gin.Context, err error) {
+	var fileErr *usecase.CSVFileError
+	switch {
+	case errors.As(err, &fileErr):
+		utils.BadRequestResponse(ctx, "invalid file", fileErr.Error())
+	case errors.Is(err, repository.ErrGiftNotFound):
+		utils.ErrorResponse(ctx, http.StatusNotFound, "error", err.Error())
+	case errors.Is(err, usecase.ErrGiftCodeNotAvailable):
+		utils.ErrorResponse(ctx, http.StatusConflict, "error", err.Error())
+	default:
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+	}
+}
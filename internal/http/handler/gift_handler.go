@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/middleware"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/request"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/usecase"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+type IGiftHandler interface {
+	FindAllPaginated(ctx *gin.Context)
+	FindByID(ctx *gin.Context)
+	CreateGift(ctx *gin.Context)
+	UpdateGift(ctx *gin.Context)
+	DeleteGift(ctx *gin.Context)
+}
+
+type GiftHandler struct {
+	Log      *logrus.Logger
+	Viper    *viper.Viper
+	Validate *validator.Validate
+	UseCase  usecase.IGiftUseCase
+}
+
+func NewGiftHandler(
+	log *logrus.Logger,
+	viper *viper.Viper,
+	validate *validator.Validate,
+	useCase usecase.IGiftUseCase,
+) IGiftHandler {
+	return &GiftHandler{
+		Log:      log,
+		Viper:    viper,
+		Validate: validate,
+		UseCase:  useCase,
+	}
+}
+
+func GiftHandlerFactory(
+	log *logrus.Logger,
+	viper *viper.Viper,
+) IGiftHandler {
+	useCase := usecase.GiftUseCaseFactory(log, viper)
+	validate := config.NewValidator(viper)
+	return NewGiftHandler(log, viper, validate, useCase)
+}
+
+func (g *GiftHandler) FindAllPaginated(ctx *gin.Context) {
+	page, pageSize, search := getPagination(ctx)
+
+	// gift managers also see gifts that are not available yet or have expired
+	onlyAvailable := !middleware.HasPermission(ctx, entity.PERMISSION_GIFTS_MANAGE)
+
+	gifts, total, err := g.UseCase.FindAllPaginated(page, pageSize, search, ctx.Query("sort"), onlyAvailable)
+	if err != nil {
+		g.Log.Error("[GiftHandler.FindAllPaginated] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.PaginatedResponse(ctx, http.StatusOK, "success", gifts, utils.NewPagination(page, pageSize, total))
+}
+
+func (g *GiftHandler) FindByID(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid gift id", err.Error())
+		return
+	}
+
+	gift, err := g.UseCase.FindByID(id, !middleware.HasPermission(ctx, entity.PERMISSION_GIFTS_MANAGE))
+	if err != nil {
+		g.Log.Error("[GiftHandler.FindByID] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	if gift == nil {
+		utils.ErrorResponse(ctx, http.StatusNotFound, "error", "Gift not found")
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", gift)
+}
+
+func (g *GiftHandler) CreateGift(ctx *gin.Context) {
+	var payload = new(request.GiftRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		g.Log.Error("[GiftHandler.CreateGift] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	if err := g.Validate.Struct(payload); err != nil {
+		g.Log.Errorf("Error when validating request: %v", err)
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	gift, err := g.UseCase.CreateGift(payload)
+	if err != nil {
+		g.Log.Error("[GiftHandler.CreateGift] " + err.Error())
+		if errors.Is(err, usecase.ErrGiftRedeemCodeTaken) {
+			utils.ErrorResponse(ctx, http.StatusConflict, "error", err.Error())
+			return
+		}
+		if errors.Is(err, usecase.ErrGiftInvalidWindow) {
+			utils.BadRequestResponse(ctx, "bad request", err.Error())
+			return
+		}
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusCreated, "success", gift)
+}
+
+func (g *GiftHandler) UpdateGift(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid gift id", err.Error())
+		return
+	}
+
+	var payload = new(request.GiftRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		g.Log.Error("[GiftHandler.UpdateGift] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	if err := g.Validate.Struct(payload); err != nil {
+		g.Log.Errorf("Error when validating request: %v", err)
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	gift, err := g.UseCase.UpdateGift(id, payload)
+	if err != nil {
+		g.Log.Error("[GiftHandler.UpdateGift] " + err.Error())
+		if errors.Is(err, usecase.ErrGiftRedeemCodeTaken) {
+			utils.ErrorResponse(ctx, http.StatusConflict, "error", err.Error())
+			return
+		}
+		if errors.Is(err, usecase.ErrGiftInvalidWindow) {
+			utils.BadRequestResponse(ctx, "bad request", err.Error())
+			return
+		}
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	if gift == nil {
+		utils.ErrorResponse(ctx, http.StatusNotFound, "error", "Gift not found")
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", gift)
+}
+
+func (g *GiftHandler) DeleteGift(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid gift id", err.Error())
+		return
+	}
+
+	deleted, err := g.UseCase.DeleteGift(id)
+	if err != nil {
+		g.Log.Error("[GiftHandler.DeleteGift] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	if !deleted {
+		utils.ErrorResponse(ctx, http.StatusNotFound, "error", "Gift not found")
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", nil)
+}
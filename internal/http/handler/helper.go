@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/usecase"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPage     = 1
+	defaultPageSize = 10
+	maxPageSize     = 100
+)
+
+// defaultMaxUploadSize caps an uploaded file when no limit is configured, in
+// kilobytes.
+const defaultMaxUploadSize = 1024
+
+// getPagination reads the page, page_size and search query parameters, falling
+// back to sane defaults when they are missing or invalid.
+func getPagination(ctx *gin.Context) (int, int, string) {
+	page, err := strconv.Atoi(ctx.Query("page"))
+	if err != nil || page < 1 {
+		page = defaultPage
+	}
+
+	pageSize, err := strconv.Atoi(ctx.Query("page_size"))
+	if err != nil || pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	return page, pageSize, ctx.Query("search")
+}
+
+// tooManyRequestsResponse writes a 429 with a Retry-After header in seconds.
+func tooManyRequestsResponse(ctx *gin.Context, err *usecase.TooManyRequestsError) {
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+	utils.ErrorResponse(ctx, http.StatusTooManyRequests, "error", err.Error())
+}
+
+// readUploadedFile reads the multipart file in the given form field, rejecting
+// files larger than maxSize kilobytes. It writes the error response itself and
+// returns false when the file cannot be used.
+func readUploadedFile(ctx *gin.Context, field string, maxSize int64) (string, []byte, bool) {
+	if maxSize <= 0 {
+		maxSize = defaultMaxUploadSize
+	}
+
+	fileHeader, err := ctx.FormFile(field)
+	if err != nil {
+		utils.BadRequestResponse(ctx, field+" is required", err.Error())
+		return "", nil, false
+	}
+
+	if fileHeader.Size > maxSize*1024 {
+		utils.ErrorResponse(ctx, http.StatusRequestEntityTooLarge, "error", field+" is too large")
+		return "", nil, false
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return "", nil, false
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return "", nil, false
+	}
+
+	return fileHeader.Filename, content, true
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+type IJWKSHandler interface {
+	JWKS(ctx *gin.Context)
+}
+
+type JWKSHandler struct {
+	Log   *logrus.Logger
+	Viper *viper.Viper
+}
+
+func NewJWKSHandler(
+	log *logrus.Logger,
+	viper *viper.Viper,
+) IJWKSHandler {
+	return &JWKSHandler{
+		Log:   log,
+		Viper: viper,
+	}
+}
+
+func JWKSHandlerFactory(
+	log *logrus.Logger,
+	viper *viper.Viper,
+) IJWKSHandler {
+	return NewJWKSHandler(log, viper)
+}
+
+// JWKS publishes the public verification keys. The document follows RFC 7517
+// instead of the usual response envelope so standard JWT libraries can use it.
+func (j *JWKSHandler) JWKS(ctx *gin.Context) {
+	keys, err := utils.LoadKeySet(j.Viper)
+	if err != nil {
+		j.Log.Error("[JWKSHandler.JWKS] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, gin.H{
+		"keys": keys.JWKS(),
+	})
+}
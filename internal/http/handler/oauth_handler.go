@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/middleware"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/request"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/usecase"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+type IOAuthHandler interface {
+	Authorize(ctx *gin.Context)
+	Token(ctx *gin.Context)
+	Introspect(ctx *gin.Context)
+	FindAllClientsPaginated(ctx *gin.Context)
+	FindClientByID(ctx *gin.Context)
+	CreateClient(ctx *gin.Context)
+	DeleteClient(ctx *gin.Context)
+}
+
+type OAuthHandler struct {
+	Log      *logrus.Logger
+	Viper    *viper.Viper
+	Validate *validator.Validate
+	UseCase  usecase.IOAuthUseCase
+}
+
+func NewOAuthHandler(
+	log *logrus.Logger,
+	viper *viper.Viper,
+	validate *validator.Validate,
+	useCase usecase.IOAuthUseCase,
+) IOAuthHandler {
+	return &OAuthHandler{
+		Log:      log,
+		Viper:    viper,
+		Validate: validate,
+		UseCase:  useCase,
+	}
+}
+
+func OAuthHandlerFactory(
+	log *logrus.Logger,
+	viper *viper.Viper,
+) IOAuthHandler {
+	useCase := usecase.OAuthUseCaseFactory(log, viper)
+	validate := config.NewValidator(viper)
+	return NewOAuthHandler(log, viper, validate, useCase)
+}
+
+// Authorize is called by our frontend once the signed in user approved the
+// client's request, and returns where the user has to be redirected to.
+func (o *OAuthHandler) Authorize(ctx *gin.Context) {
+	var payload = new(request.OAuthAuthorizeRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		o.Log.Error("[OAuthHandler.Authorize] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	if err := o.Validate.Struct(payload); err != nil {
+		o.Log.Errorf("Error when validating request: %v", err)
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		o.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	result, err := o.UseCase.Authorize(userID, payload)
+	if err != nil {
+		o.Log.Error("[OAuthHandler.Authorize] " + err.Error())
+		var oauthErr *usecase.OAuthError
+		if errors.As(err, &oauthErr) {
+			utils.BadRequestResponse(ctx, oauthErr.Code, oauthErr.Description)
+			return
+		}
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", result)
+}
+
+// Token implements the token endpoint of RFC 6749. Clients authenticate either
+// with HTTP Basic or with client_id and client_secret in the body.
+func (o *OAuthHandler) Token(ctx *gin.Context) {
+	var payload = new(request.OAuthTokenRequest)
+	if err := ctx.ShouldBind(payload); err != nil {
+		o.Log.Error("[OAuthHandler.Token] " + err.Error())
+		oauthErrorResponse(ctx, &usecase.OAuthError{Code: "invalid_request", Description: err.Error()})
+		return
+	}
+
+	if clientID, clientSecret, ok := ctx.Request.BasicAuth(); ok {
+		payload.ClientID, payload.ClientSecret = clientID, clientSecret
+	}
+
+	result, err := o.UseCase.Token(payload)
+	if err != nil {
+		o.Log.Error("[OAuthHandler.Token] " + err.Error())
+		oauthErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, result)
+}
+
+// Introspect implements RFC 7662 for resource servers of our partners.
+func (o *OAuthHandler) Introspect(ctx *gin.Context) {
+	var payload = new(request.OAuthIntrospectRequest)
+	if err := ctx.ShouldBind(payload); err != nil {
+		o.Log.Error("[OAuthHandler.Introspect] " + err.Error())
+		oauthErrorResponse(ctx, &usecase.OAuthError{Code: "invalid_request", Description: err.Error()})
+		return
+	}
+
+	if clientID, clientSecret, ok := ctx.Request.BasicAuth(); ok {
+		payload.ClientID, payload.ClientSecret = clientID, clientSecret
+	}
+
+	result, err := o.UseCase.Introspect(payload)
+	if err != nil {
+		o.Log.Error("[OAuthHandler.Introspect] " + err.Error())
+		oauthErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (o *OAuthHandler) FindAllClientsPaginated(ctx *gin.Context) {
+	page, pageSize, search := getPagination(ctx)
+
+	clients, total, err := o.UseCase.FindAllClientsPaginated(page, pageSize, search)
+	if err != nil {
+		o.Log.Error("[OAuthHandler.FindAllClientsPaginated] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.PaginatedResponse(ctx, http.StatusOK, "success", clients, utils.NewPagination(page, pageSize, total))
+}
+
+func (o *OAuthHandler) FindClientByID(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid client id", err.Error())
+		return
+	}
+
+	client, err := o.UseCase.FindClientByID(id)
+	if err != nil {
+		o.Log.Error("[OAuthHandler.FindClientByID] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	if client == nil {
+		utils.ErrorResponse(ctx, http.StatusNotFound, "error", "OAuth client not found")
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", client)
+}
+
+func (o *OAuthHandler) CreateClient(ctx *gin.Context) {
+	var payload = new(request.OAuthClientRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		o.Log.Error("[OAuthHandler.CreateClient] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	if err := o.Validate.Struct(payload); err != nil {
+		o.Log.Errorf("Error when validating request: %v", err)
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	client, err := o.UseCase.CreateClient(payload)
+	if err != nil {
+		o.Log.Error("[OAuthHandler.CreateClient] " + err.Error())
+		if errors.Is(err, usecase.ErrOAuthUnknownScope) ||
+			errors.Is(err, usecase.ErrOAuthRedirectURIRequired) ||
+			errors.Is(err, usecase.ErrOAuthClientMustBeConfident) {
+			utils.BadRequestResponse(ctx, "bad request", err.Error())
+			return
+		}
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusCreated, "success", client)
+}
+
+func (o *OAuthHandler) DeleteClient(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid client id", err.Error())
+		return
+	}
+
+	deleted, err := o.UseCase.DeleteClient(id)
+	if err != nil {
+		o.Log.Error("[OAuthHandler.DeleteClient] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	if !deleted {
+		utils.ErrorResponse(ctx, http.StatusNotFound, "error", "OAuth client not found")
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", nil)
+}
+
+// oauthErrorResponse writes errors in the format of RFC 6749 section 5.2
+// instead of the usual response envelope.
+func oauthErrorResponse(ctx *gin.Context, err error) {
+	var oauthErr *usecase.OAuthError
+	if !errors.As(err, &oauthErr) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": err.Error(),
+		})
+		return
+	}
+
+	status := http.StatusBadRequest
+	if oauthErr.Code == "invalid_client" {
+		status = http.StatusUnauthorized
+		ctx.Header("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(status, gin.H{
+		"error":             oauthErr.Code,
+		"error_description": oauthErr.Description,
+	})
+}
@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/middleware"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/request"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/usecase"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/repository"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+type IPointHandler interface {
+	MyBalance(ctx *gin.Context)
+	MyHistory(ctx *gin.Context)
+	UserBalance(ctx *gin.Context)
+	UserHistory(ctx *gin.Context)
+	AdjustPoints(ctx *gin.Context)
+	FindAllBatchesPaginated(ctx *gin.Context)
+	FindBatchByID(ctx *gin.Context)
+	UploadBatch(ctx *gin.Context)
+}
+
+type PointHandler struct {
+	Log      *logrus.Logger
+	Viper    *viper.Viper
+	Validate *validator.Validate
+	UseCase  usecase.IPointUseCase
+}
+
+func NewPointHandler(
+	log *logrus.Logger,
+	viper *viper.Viper,
+	validate *validator.Validate,
+	useCase usecase.IPointUseCase,
+) IPointHandler {
+	return &PointHandler{
+		Log:      log,
+		Viper:    viper,
+		Validate: validate,
+		UseCase:  useCase,
+	}
+}
+
+func PointHandlerFactory(
+	log *logrus.Logger,
+	viper *viper.Viper,
+) IPointHandler {
+	useCase := usecase.PointUseCaseFactory(log, viper)
+	validate := config.NewValidator(viper)
+	return NewPointHandler(log, viper, validate, useCase)
+}
+
+func (p *PointHandler) MyBalance(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		p.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	balance, err := p.UseCase.FindBalance(userID)
+	if err != nil {
+		p.Log.Error("[PointHandler.MyBalance] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", balance)
+}
+
+func (p *PointHandler) MyHistory(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		p.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	page, pageSize, _ := getPagination(ctx)
+
+	entries, total, err := p.UseCase.FindHistoryPaginated(userID, page, pageSize)
+	if err != nil {
+		p.Log.Error("[PointHandler.MyHistory] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.PaginatedResponse(ctx, http.StatusOK, "success", entries, utils.NewPagination(page, pageSize, total))
+}
+
+func (p *PointHandler) UserBalance(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid user id", err.Error())
+		return
+	}
+
+	balance, err := p.UseCase.FindUserBalance(userID)
+	if err != nil {
+		p.Log.Error("[PointHandler.UserBalance] " + err.Error())
+		p.errorResponse(ctx, err)
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", balance)
+}
+
+func (p *PointHandler) UserHistory(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid user id", err.Error())
+		return
+	}
+
+	page, pageSize, _ := getPagination(ctx)
+
+	entries, total, err := p.UseCase.FindUserHistoryPaginated(userID, page, pageSize)
+	if err != nil {
+		p.Log.Error("[PointHandler.UserHistory] " + err.Error())
+		p.errorResponse(ctx, err)
+		return
+	}
+
+	utils.PaginatedResponse(ctx, http.StatusOK, "success", entries, utils.NewPagination(page, pageSize, total))
+}
+
+func (p *PointHandler) AdjustPoints(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid user id", err.Error())
+		return
+	}
+
+	var payload = new(request.PointAdjustmentRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		p.Log.Error("[PointHandler.AdjustPoints] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	if err := p.Validate.Struct(payload); err != nil {
+		p.Log.Errorf("Error when validating request: %v", err)
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	actorID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		p.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	entry, err := p.UseCase.AdjustPoints(actorID, userID, payload)
+	if err != nil {
+		p.Log.Error("[PointHandler.AdjustPoints] " + err.Error())
+		p.errorResponse(ctx, err)
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusCreated, "success", entry)
+}
+
+func (p *PointHandler) FindAllBatchesPaginated(ctx *gin.Context) {
+	page, pageSize, search := getPagination(ctx)
+
+	batches, total, err := p.UseCase.FindAllBatchesPaginated(page, pageSize, search)
+	if err != nil {
+		p.Log.Error("[PointHandler.FindAllBatchesPaginated] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.PaginatedResponse(ctx, http.StatusOK, "success", batches, utils.NewPagination(page, pageSize, total))
+}
+
+func (p *PointHandler) FindBatchByID(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid batch id", err.Error())
+		return
+	}
+
+	batch, err := p.UseCase.FindBatchByID(id)
+	if err != nil {
+		p.Log.Error("[PointHandler.FindBatchByID] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	if batch == nil {
+		utils.ErrorResponse(ctx, http.StatusNotFound, "error", "Point batch not found")
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", batch)
+}
+
+// UploadBatch takes a multipart form with the CSV in the field file. Retrying
+// with the same batch_key and file answers 200 with the existing report.
+func (p *PointHandler) UploadBatch(ctx *gin.Context) {
+	var payload = new(request.PointBatchRequest)
+	if err := ctx.ShouldBind(payload); err != nil {
+		p.Log.Error("[PointHandler.UploadBatch] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	if err := p.Validate.Struct(payload); err != nil {
+		p.Log.Errorf("Error when validating request: %v", err)
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	fileName, content, ok := readUploadedFile(ctx, "file", int64(p.Viper.GetInt("points.batch.max_file_size")))
+	if !ok {
+		return
+	}
+
+	actorID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		p.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	batch, created, err := p.UseCase.UploadBatch(actorID, payload, fileName, content)
+	if err != nil {
+		p.Log.Error("[PointHandler.UploadBatch] " + err.Error())
+		p.errorResponse(ctx, err)
+		return
+	}
+
+	if !created {
+		utils.SuccessResponse(ctx, http.StatusOK, "batch was already uploaded", batch)
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusCreated, "success", batch)
+}
+
+func (p *PointHandler) errorResponse(ctx *gin.Context, err error) {
+	var fileErr *usecase.CSVFileError
+	switch {
+	case errors.As(err, &fileErr):
+		utils.BadRequestResponse(ctx, "invalid file", fileErr.Error())
+	case errors.Is(err, usecase.ErrPointReasonRequired):
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+	case errors.Is(err, usecase.ErrUserNotFound):
+		utils.ErrorResponse(ctx, http.StatusNotFound, "error", err.Error())
+	case errors.Is(err, repository.ErrInsufficientPoints), errors.Is(err, usecase.ErrPointBatchKeyReused),
+		errors.Is(err, usecase.ErrCannotAdjustOwnPoints):
+		utils.ErrorResponse(ctx, http.StatusConflict, "error", err.Error())
+	default:
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+	}
+}
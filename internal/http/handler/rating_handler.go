@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/middleware"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/request"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/usecase"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+type IRatingHandler interface {
+	RateRedemption(ctx *gin.Context)
+}
+
+type RatingHandler struct {
+	Log      *logrus.Logger
+	Viper    *viper.Viper
+	Validate *validator.Validate
+	UseCase  usecase.IRatingUseCase
+}
+
+func NewRatingHandler(
+	log *logrus.Logger,
+	viper *viper.Viper,
+	validate *validator.Validate,
+	useCase usecase.IRatingUseCase,
+) IRatingHandler {
+	return &RatingHandler{
+		Log:      log,
+		Viper:    viper,
+		Validate: validate,
+		UseCase:  useCase,
+	}
+}
+
+func RatingHandlerFactory(
+	log *logrus.Logger,
+	viper *viper.Viper,
+) IRatingHandler {
+	useCase := usecase.RatingUseCaseFactory(log)
+	validate := config.NewValidator(viper)
+	return NewRatingHandler(log, viper, validate, useCase)
+}
+
+func (r *RatingHandler) RateRedemption(ctx *gin.Context) {
+	redemptionID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid redemption id", err.Error())
+		return
+	}
+
+	var payload = new(request.RatingRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		r.Log.Error("[RatingHandler.RateRedemption] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	if err := r.Validate.Struct(payload); err != nil {
+		r.Log.Errorf("Error when validating request: %v", err)
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		r.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	rating, err := r.UseCase.RateRedemption(userID, redemptionID, payload)
+	if err != nil {
+		r.Log.Error("[RatingHandler.RateRedemption] " + err.Error())
+		switch {
+		case errors.Is(err, usecase.ErrRedemptionNotFound):
+			utils.ErrorResponse(ctx, http.StatusNotFound, "error", err.Error())
+		case errors.Is(err, usecase.ErrRedemptionNotOwned):
+			utils.ErrorResponse(ctx, http.StatusForbidden, "error", err.Error())
+		case errors.Is(err, usecase.ErrRedemptionAlreadyRated):
+			utils.ErrorResponse(ctx, http.StatusConflict, "error", err.Error())
+		default:
+			utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusCreated, "success", rating)
+}
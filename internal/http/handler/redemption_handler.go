@@ -0,0 +1,299 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/middleware"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/request"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/usecase"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/repository"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+type IRedemptionHandler interface {
+	Redeem(ctx *gin.Context)
+	MyRedemption(ctx *gin.Context)
+	MyRedemptions(ctx *gin.Context)
+	FindAllPaginated(ctx *gin.Context)
+	FindByID(ctx *gin.Context)
+	UpdateStatus(ctx *gin.Context)
+	Cancel(ctx *gin.Context)
+}
+
+type RedemptionHandler struct {
+	Log      *logrus.Logger
+	Viper    *viper.Viper
+	Validate *validator.Validate
+	UseCase  usecase.IRedemptionUseCase
+}
+
+func NewRedemptionHandler(
+	log *logrus.Logger,
+	viper *viper.Viper,
+	validate *validator.Validate,
+	useCase usecase.IRedemptionUseCase,
+) IRedemptionHandler {
+	return &RedemptionHandler{
+		Log:      log,
+		Viper:    viper,
+		Validate: validate,
+		UseCase:  useCase,
+	}
+}
+
+func RedemptionHandlerFactory(
+	log *logrus.Logger,
+	viper *viper.Viper,
+) IRedemptionHandler {
+	useCase := usecase.RedemptionUseCaseFactory(log, viper)
+	validate := config.NewValidator(viper)
+	return NewRedemptionHandler(log, viper, validate, useCase)
+}
+
+func (r *RedemptionHandler) Redeem(ctx *gin.Context) {
+	giftID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid gift id", err.Error())
+		return
+	}
+
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		r.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	redemption, err := r.UseCase.Redeem(userID, giftID)
+	if err != nil {
+		r.Log.Error("[RedemptionHandler.Redeem] " + err.Error())
+		var limitErr *repository.RedemptionLimitError
+		switch {
+		case errors.As(err, &limitErr):
+			utils.FormatResponse(ctx, http.StatusConflict, "error", limitErr.Error(), response.RedemptionLimitResponse{
+				Limit:    limitErr.Code,
+				Max:      limitErr.Max,
+				ResetsAt: limitErr.ResetsAt,
+			})
+		case errors.Is(err, repository.ErrGiftNotFound):
+			utils.ErrorResponse(ctx, http.StatusNotFound, "error", err.Error())
+		case errors.Is(err, repository.ErrGiftOutOfStock), errors.Is(err, repository.ErrGiftExpired),
+			errors.Is(err, repository.ErrGiftNotAvailable), errors.Is(err, repository.ErrInsufficientPoints):
+			utils.ErrorResponse(ctx, http.StatusConflict, "error", err.Error())
+		default:
+			utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusCreated, "success", redemption)
+}
+
+func (r *RedemptionHandler) MyRedemption(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid redemption id", err.Error())
+		return
+	}
+
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		r.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	redemption, err := r.UseCase.FindMine(userID, id)
+	if err != nil {
+		r.Log.Error("[RedemptionHandler.MyRedemption] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	if redemption == nil {
+		utils.ErrorResponse(ctx, http.StatusNotFound, "error", "Redemption not found")
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", redemption)
+}
+
+func (r *RedemptionHandler) MyRedemptions(ctx *gin.Context) {
+	status, ok := getRedemptionStatus(ctx)
+	if !ok {
+		return
+	}
+
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		r.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	page, pageSize, _ := getPagination(ctx)
+
+	redemptions, total, err := r.UseCase.FindAllMinePaginated(userID, status, page, pageSize)
+	if err != nil {
+		r.Log.Error("[RedemptionHandler.MyRedemptions] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.PaginatedResponse(ctx, http.StatusOK, "success", redemptions, utils.NewPagination(page, pageSize, total))
+}
+
+func (r *RedemptionHandler) FindAllPaginated(ctx *gin.Context) {
+	status, ok := getRedemptionStatus(ctx)
+	if !ok {
+		return
+	}
+
+	page, pageSize, _ := getPagination(ctx)
+
+	redemptions, total, err := r.UseCase.FindAllPaginated(status, page, pageSize)
+	if err != nil {
+		r.Log.Error("[RedemptionHandler.FindAllPaginated] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.PaginatedResponse(ctx, http.StatusOK, "success", redemptions, utils.NewPagination(page, pageSize, total))
+}
+
+func (r *RedemptionHandler) FindByID(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid redemption id", err.Error())
+		return
+	}
+
+	redemption, err := r.UseCase.FindByID(id)
+	if err != nil {
+		r.Log.Error("[RedemptionHandler.FindByID] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	if redemption == nil {
+		utils.ErrorResponse(ctx, http.StatusNotFound, "error", "Redemption not found")
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", redemption)
+}
+
+func (r *RedemptionHandler) UpdateStatus(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid redemption id", err.Error())
+		return
+	}
+
+	var payload = new(request.RedemptionStatusRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		r.Log.Error("[RedemptionHandler.UpdateStatus] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	if err := r.Validate.Struct(payload); err != nil {
+		r.Log.Errorf("Error when validating request: %v", err)
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	actorID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		r.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	redemption, err := r.UseCase.UpdateStatus(actorID, id, payload)
+	if err != nil {
+		r.Log.Error("[RedemptionHandler.UpdateStatus] " + err.Error())
+		switch {
+		case errors.Is(err, repository.ErrRedemptionNotFound):
+			utils.ErrorResponse(ctx, http.StatusNotFound, "error", err.Error())
+		case errors.Is(err, repository.ErrInvalidRedemptionTransition):
+			utils.ErrorResponse(ctx, http.StatusConflict, "error", err.Error())
+		default:
+			utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", redemption)
+}
+
+// Cancel lets the owner of a redemption, or an admin with redemptions.manage,
+// cancel it while it is still pending.
+func (r *RedemptionHandler) Cancel(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid redemption id", err.Error())
+		return
+	}
+
+	var payload = new(request.RedemptionCancelRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		r.Log.Error("[RedemptionHandler.Cancel] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	if err := r.Validate.Struct(payload); err != nil {
+		r.Log.Errorf("Error when validating request: %v", err)
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	actorID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		r.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	canManage := middleware.HasPermission(ctx, entity.PERMISSION_REDEMPTIONS_MANAGE)
+
+	redemption, err := r.UseCase.Cancel(actorID, canManage, id, payload)
+	if err != nil {
+		r.Log.Error("[RedemptionHandler.Cancel] " + err.Error())
+		switch {
+		case errors.Is(err, repository.ErrRedemptionNotFound):
+			utils.ErrorResponse(ctx, http.StatusNotFound, "error", err.Error())
+		case errors.Is(err, repository.ErrInvalidRedemptionTransition):
+			utils.ErrorResponse(ctx, http.StatusConflict, "error", "Only pending redemptions can be cancelled")
+		default:
+			utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "Redemption cancelled successfully", redemption)
+}
+
+// getRedemptionStatus reads the optional status filter, answering 400 when it
+// is not a known status.
+func getRedemptionStatus(ctx *gin.Context) (string, bool) {
+	status := ctx.Query("status")
+	switch entity.RedemptionStatus(status) {
+	case "", entity.REDEMPTION_PENDING, entity.REDEMPTION_FULFILLED, entity.REDEMPTION_SHIPPED,
+		entity.REDEMPTION_CANCELLED, entity.REDEMPTION_REFUNDED:
+		return status, true
+	default:
+		utils.BadRequestResponse(ctx, "invalid status", status)
+		return "", false
+	}
+}
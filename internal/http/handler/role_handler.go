@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/request"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/usecase"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+type IRoleHandler interface {
+	FindAllPaginated(ctx *gin.Context)
+	FindByID(ctx *gin.Context)
+	StoreRole(ctx *gin.Context)
+	UpdateRole(ctx *gin.Context)
+	DeleteRole(ctx *gin.Context)
+	FindAllPermissions(ctx *gin.Context)
+	FindUserRoles(ctx *gin.Context)
+	AssignRolesToUser(ctx *gin.Context)
+	RevokeRoleFromUser(ctx *gin.Context)
+}
+
+type RoleHandler struct {
+	Log      *logrus.Logger
+	Viper    *viper.Viper
+	Validate *validator.Validate
+	UseCase  usecase.IRoleUseCase
+}
+
+func NewRoleHandler(
+	log *logrus.Logger,
+	viper *viper.Viper,
+	validate *validator.Validate,
+	useCase usecase.IRoleUseCase,
+) IRoleHandler {
+	return &RoleHandler{
+		Log:      log,
+		Viper:    viper,
+		Validate: validate,
+		UseCase:  useCase,
+	}
+}
+
+func RoleHandlerFactory(
+	log *logrus.Logger,
+	viper *viper.Viper,
+) IRoleHandler {
+	useCase := usecase.RoleUseCaseFactory(log)
+	validate := config.NewValidator(viper)
+	return NewRoleHandler(log, viper, validate, useCase)
+}
+
+func (r *RoleHandler) FindAllPaginated(ctx *gin.Context) {
+	page, pageSize, search := getPagination(ctx)
+
+	roles, total, err := r.UseCase.FindAllPaginated(page, pageSize, search)
+	if err != nil {
+		r.Log.Error("[RoleHandler.FindAllPaginated] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.PaginatedResponse(ctx, http.StatusOK, "success", roles, utils.NewPagination(page, pageSize, total))
+}
+
+func (r *RoleHandler) FindByID(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid role id", err.Error())
+		return
+	}
+
+	role, err := r.UseCase.FindByID(id)
+	if err != nil {
+		r.Log.Error("[RoleHandler.FindByID] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	if role == nil {
+		utils.ErrorResponse(ctx, http.StatusNotFound, "error", "Role not found")
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", role)
+}
+
+func (r *RoleHandler) StoreRole(ctx *gin.Context) {
+	var payload = new(request.RoleRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		r.Log.Error("[RoleHandler.StoreRole] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	if err := r.Validate.Struct(payload); err != nil {
+		r.Log.Errorf("Error when validating request: %v", err)
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	role, err := r.UseCase.StoreRole(payload)
+	if err != nil {
+		r.Log.Error("[RoleHandler.StoreRole] " + err.Error())
+		if errors.Is(err, usecase.ErrRoleNameTaken) {
+			utils.ErrorResponse(ctx, http.StatusConflict, "error", err.Error())
+			return
+		}
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusCreated, "success", role)
+}
+
+func (r *RoleHandler) UpdateRole(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid role id", err.Error())
+		return
+	}
+
+	var payload = new(request.RoleRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		r.Log.Error("[RoleHandler.UpdateRole] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	if err := r.Validate.Struct(payload); err != nil {
+		r.Log.Errorf("Error when validating request: %v", err)
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	role, err := r.UseCase.UpdateRole(id, payload)
+	if err != nil {
+		r.Log.Error("[RoleHandler.UpdateRole] " + err.Error())
+		if errors.Is(err, usecase.ErrRoleNameTaken) {
+			utils.ErrorResponse(ctx, http.StatusConflict, "error", err.Error())
+			return
+		}
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	if role == nil {
+		utils.ErrorResponse(ctx, http.StatusNotFound, "error", "Role not found")
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", role)
+}
+
+func (r *RoleHandler) DeleteRole(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid role id", err.Error())
+		return
+	}
+
+	deleted, err := r.UseCase.DeleteRole(id)
+	if err != nil {
+		r.Log.Error("[RoleHandler.DeleteRole] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	if !deleted {
+		utils.ErrorResponse(ctx, http.StatusNotFound, "error", "Role not found")
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", nil)
+}
+
+func (r *RoleHandler) FindAllPermissions(ctx *gin.Context) {
+	permissions, err := r.UseCase.FindAllPermissions()
+	if err != nil {
+		r.Log.Error("[RoleHandler.FindAllPermissions] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", permissions)
+}
+
+func (r *RoleHandler) FindUserRoles(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid user id", err.Error())
+		return
+	}
+
+	userRoles, err := r.UseCase.FindUserRoles(userID)
+	if err != nil {
+		r.Log.Error("[RoleHandler.FindUserRoles] " + err.Error())
+		r.userRolesErrorResponse(ctx, err)
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", userRoles)
+}
+
+func (r *RoleHandler) AssignRolesToUser(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid user id", err.Error())
+		return
+	}
+
+	var payload = new(request.AssignRolesRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		r.Log.Error("[RoleHandler.AssignRolesToUser] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	if err := r.Validate.Struct(payload); err != nil {
+		r.Log.Errorf("Error when validating request: %v", err)
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	userRoles, err := r.UseCase.AssignRolesToUser(userID, payload)
+	if err != nil {
+		r.Log.Error("[RoleHandler.AssignRolesToUser] " + err.Error())
+		r.userRolesErrorResponse(ctx, err)
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", userRoles)
+}
+
+func (r *RoleHandler) RevokeRoleFromUser(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid user id", err.Error())
+		return
+	}
+
+	roleID, err := uuid.Parse(ctx.Param("role_id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid role id", err.Error())
+		return
+	}
+
+	userRoles, err := r.UseCase.RevokeRoleFromUser(userID, roleID)
+	if err != nil {
+		r.Log.Error("[RoleHandler.RevokeRoleFromUser] " + err.Error())
+		r.userRolesErrorResponse(ctx, err)
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", userRoles)
+}
+
+func (r *RoleHandler) userRolesErrorResponse(ctx *gin.Context, err error) {
+	if errors.Is(err, usecase.ErrUserNotFound) {
+		utils.ErrorResponse(ctx, http.StatusNotFound, "error", "User not found")
+		return
+	}
+	utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+}
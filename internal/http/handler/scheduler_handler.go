@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/usecase"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+type ISchedulerHandler interface {
+	FindAllRunsPaginated(ctx *gin.Context)
+}
+
+type SchedulerHandler struct {
+	Log     *logrus.Logger
+	Viper   *viper.Viper
+	UseCase usecase.ISchedulerUseCase
+}
+
+func NewSchedulerHandler(
+	log *logrus.Logger,
+	viper *viper.Viper,
+	useCase usecase.ISchedulerUseCase,
+) ISchedulerHandler {
+	return &SchedulerHandler{
+		Log:     log,
+		Viper:   viper,
+		UseCase: useCase,
+	}
+}
+
+func SchedulerHandlerFactory(
+	log *logrus.Logger,
+	viper *viper.Viper,
+) ISchedulerHandler {
+	useCase := usecase.SchedulerUseCaseFactory(log, viper)
+	return NewSchedulerHandler(log, viper, useCase)
+}
+
+// FindAllRunsPaginated lists the run history of the scheduled jobs, newest
+// first, optionally filtered by ?job and ?status.
+func (s *SchedulerHandler) FindAllRunsPaginated(ctx *gin.Context) {
+	status := ctx.Query("status")
+	switch entity.SchedulerRunStatus(status) {
+	case "", entity.SCHEDULER_RUN_RUNNING, entity.SCHEDULER_RUN_SUCCEEDED, entity.SCHEDULER_RUN_FAILED:
+	default:
+		utils.BadRequestResponse(ctx, "invalid status", status)
+		return
+	}
+
+	page, pageSize, _ := getPagination(ctx)
+
+	runs, total, err := s.UseCase.FindAllRunsPaginated(ctx.Query("job"), status, page, pageSize)
+	if err != nil {
+		s.Log.Error("[SchedulerHandler.FindAllRunsPaginated] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.PaginatedResponse(ctx, http.StatusOK, "success", runs, utils.NewPagination(page, pageSize, total))
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/middleware"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/request"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/usecase"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/repository"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+type ITokenHandler interface {
+	Refresh(ctx *gin.Context)
+	Logout(ctx *gin.Context)
+	LogoutAll(ctx *gin.Context)
+}
+
+type TokenHandler struct {
+	Log      *logrus.Logger
+	Viper    *viper.Viper
+	Validate *validator.Validate
+	UseCase  usecase.ITokenUseCase
+}
+
+func NewTokenHandler(
+	log *logrus.Logger,
+	viper *viper.Viper,
+	validate *validator.Validate,
+	useCase usecase.ITokenUseCase,
+) ITokenHandler {
+	return &TokenHandler{
+		Log:      log,
+		Viper:    viper,
+		Validate: validate,
+		UseCase:  useCase,
+	}
+}
+
+func TokenHandlerFactory(
+	log *logrus.Logger,
+	viper *viper.Viper,
+) ITokenHandler {
+	useCase := usecase.TokenUseCaseFactory(log, viper)
+	validate := config.NewValidator(viper)
+	return NewTokenHandler(log, viper, validate, useCase)
+}
+
+func (t *TokenHandler) Refresh(ctx *gin.Context) {
+	var payload = new(request.RefreshTokenRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		t.Log.Error("[TokenHandler.Refresh] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	err := t.Validate.Struct(payload)
+	if err != nil {
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		t.Log.Errorf("Error when validating request: %v", err)
+		return
+	}
+
+	tokens, err := t.UseCase.Refresh(payload)
+	if err != nil {
+		t.Log.Error("[TokenHandler.Refresh] " + err.Error())
+		if errors.Is(err, repository.ErrRefreshTokenInvalid) || errors.Is(err, repository.ErrRefreshTokenReused) {
+			utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+			return
+		}
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", tokens)
+}
+
+func (t *TokenHandler) Logout(ctx *gin.Context) {
+	// the refresh token is optional, so an empty body is accepted
+	var payload = new(request.LogoutRequest)
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			t.Log.Error("[TokenHandler.Logout] " + err.Error())
+			utils.BadRequestResponse(ctx, "bad request", err.Error())
+			return
+		}
+	}
+
+	claims, err := middleware.GetUser(ctx)
+	if err != nil {
+		t.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		t.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	if err := t.UseCase.Logout(userID, claims, payload); err != nil {
+		t.Log.Error("[TokenHandler.Logout] " + err.Error())
+		if errors.Is(err, repository.ErrRefreshTokenInvalid) {
+			utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+			return
+		}
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", nil)
+}
+
+func (t *TokenHandler) LogoutAll(ctx *gin.Context) {
+	claims, err := middleware.GetUser(ctx)
+	if err != nil {
+		t.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		t.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	if err := t.UseCase.LogoutAll(userID, claims); err != nil {
+		t.Log.Error("[TokenHandler.LogoutAll] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", nil)
+}
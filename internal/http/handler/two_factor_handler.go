@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/middleware"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/request"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/usecase"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+type ITwoFactorHandler interface {
+	Setup(ctx *gin.Context)
+	Confirm(ctx *gin.Context)
+	Disable(ctx *gin.Context)
+	ResetUser(ctx *gin.Context)
+}
+
+type TwoFactorHandler struct {
+	Log      *logrus.Logger
+	Viper    *viper.Viper
+	Validate *validator.Validate
+	UseCase  usecase.ITwoFactorUseCase
+}
+
+func NewTwoFactorHandler(
+	log *logrus.Logger,
+	viper *viper.Viper,
+	validate *validator.Validate,
+	useCase usecase.ITwoFactorUseCase,
+) ITwoFactorHandler {
+	return &TwoFactorHandler{
+		Log:      log,
+		Viper:    viper,
+		Validate: validate,
+		UseCase:  useCase,
+	}
+}
+
+func TwoFactorHandlerFactory(
+	log *logrus.Logger,
+	viper *viper.Viper,
+) ITwoFactorHandler {
+	useCase := usecase.TwoFactorUseCaseFactory(log, viper)
+	validate := config.NewValidator(viper)
+	return NewTwoFactorHandler(log, viper, validate, useCase)
+}
+
+func (t *TwoFactorHandler) Setup(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		t.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	setup, err := t.UseCase.Setup(userID)
+	if err != nil {
+		t.Log.Error("[TwoFactorHandler.Setup] " + err.Error())
+		t.errorResponse(ctx, err)
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "scan the uri with your authenticator app and confirm a code", setup)
+}
+
+func (t *TwoFactorHandler) Confirm(ctx *gin.Context) {
+	var payload = new(request.TwoFactorConfirmRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		t.Log.Error("[TwoFactorHandler.Confirm] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	err := t.Validate.Struct(payload)
+	if err != nil {
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		t.Log.Errorf("Error when validating request: %v", err)
+		return
+	}
+
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		t.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	codes, err := t.UseCase.Confirm(userID, payload)
+	if err != nil {
+		t.Log.Error("[TwoFactorHandler.Confirm] " + err.Error())
+		t.errorResponse(ctx, err)
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "two-factor authentication enabled, store the recovery codes somewhere safe", codes)
+}
+
+func (t *TwoFactorHandler) Disable(ctx *gin.Context) {
+	var payload = new(request.TwoFactorDisableRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		t.Log.Error("[TwoFactorHandler.Disable] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	err := t.Validate.Struct(payload)
+	if err != nil {
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		t.Log.Errorf("Error when validating request: %v", err)
+		return
+	}
+
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		t.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	if err := t.UseCase.Disable(userID, payload); err != nil {
+		t.Log.Error("[TwoFactorHandler.Disable] " + err.Error())
+		t.errorResponse(ctx, err)
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "two-factor authentication disabled", nil)
+}
+
+func (t *TwoFactorHandler) ResetUser(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid user id", err.Error())
+		return
+	}
+
+	actorID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		t.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	reset, err := t.UseCase.ResetForUser(actorID, id)
+	if err != nil {
+		t.Log.Error("[TwoFactorHandler.ResetUser] " + err.Error())
+		if errors.Is(err, usecase.ErrCannotModifySelf) {
+			utils.ErrorResponse(ctx, http.StatusConflict, "error", err.Error())
+			return
+		}
+		if errors.Is(err, usecase.ErrUserOutranksActor) {
+			utils.ErrorResponse(ctx, http.StatusForbidden, "error", err.Error())
+			return
+		}
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	if !reset {
+		utils.ErrorResponse(ctx, http.StatusNotFound, "error", "User not found")
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", nil)
+}
+
+func (t *TwoFactorHandler) errorResponse(ctx *gin.Context, err error) {
+	var throttled *usecase.TooManyRequestsError
+	switch {
+	case errors.As(err, &throttled):
+		tooManyRequestsResponse(ctx, throttled)
+	case errors.Is(err, usecase.ErrUserNotFound):
+		utils.ErrorResponse(ctx, http.StatusNotFound, "error", err.Error())
+	case errors.Is(err, usecase.ErrTwoFactorAlreadyEnabled),
+		errors.Is(err, usecase.ErrTwoFactorNotSetUp),
+		errors.Is(err, usecase.ErrTwoFactorNotEnabled),
+		errors.Is(err, usecase.ErrTwoFactorRequiredByRole):
+		utils.ErrorResponse(ctx, http.StatusConflict, "error", err.Error())
+	case errors.Is(err, usecase.ErrInvalidTwoFactorCode):
+		utils.ErrorResponse(ctx, http.StatusUnprocessableEntity, "error", err.Error())
+	case errors.Is(err, usecase.ErrIncorrectPassword):
+		utils.ErrorResponse(ctx, http.StatusForbidden, "error", err.Error())
+	default:
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+	}
+}
@@ -1,9 +1,11 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
 	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/middleware"
 	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/request"
 	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/usecase"
@@ -17,14 +19,29 @@ import (
 
 type IUserHandler interface {
 	Login(ctx *gin.Context)
+	LoginTwoFactor(ctx *gin.Context)
+	UnlockAccount(ctx *gin.Context)
+	Register(ctx *gin.Context)
+	VerifyEmail(ctx *gin.Context)
+	ResendVerificationEmail(ctx *gin.Context)
+	ForgotPassword(ctx *gin.Context)
+	ResetPassword(ctx *gin.Context)
 	UserMe(ctx *gin.Context)
+	FindAllPaginated(ctx *gin.Context)
+	FindByID(ctx *gin.Context)
+	UpdateUser(ctx *gin.Context)
+	DeleteUser(ctx *gin.Context)
+	ActivateUser(ctx *gin.Context)
+	DeactivateUser(ctx *gin.Context)
 }
 
 type UserHandler struct {
-	Log      *logrus.Logger
-	Viper    *viper.Viper
-	Validate *validator.Validate
-	UseCase  usecase.IUserUseCase
+	Log              *logrus.Logger
+	Viper            *viper.Viper
+	Validate         *validator.Validate
+	UseCase          usecase.IUserUseCase
+	TokenUseCase     usecase.ITokenUseCase
+	TwoFactorUseCase usecase.ITwoFactorUseCase
 }
 
 func NewUserHandler(
@@ -32,12 +49,16 @@ func NewUserHandler(
 	viper *viper.Viper,
 	validate *validator.Validate,
 	useCase usecase.IUserUseCase,
+	tokenUseCase usecase.ITokenUseCase,
+	twoFactorUseCase usecase.ITwoFactorUseCase,
 ) IUserHandler {
 	return &UserHandler{
-		Log:      log,
-		Viper:    viper,
-		Validate: validate,
-		UseCase:  useCase,
+		Log:              log,
+		Viper:            viper,
+		Validate:         validate,
+		UseCase:          useCase,
+		TokenUseCase:     tokenUseCase,
+		TwoFactorUseCase: twoFactorUseCase,
 	}
 }
 
@@ -45,9 +66,11 @@ func UserHandlerFactory(
 	log *logrus.Logger,
 	viper *viper.Viper,
 ) IUserHandler {
-	useCase := usecase.UserUseCaseFactory(log)
+	useCase := usecase.UserUseCaseFactory(log, viper)
+	tokenUseCase := usecase.TokenUseCaseFactory(log, viper)
+	twoFactorUseCase := usecase.TwoFactorUseCaseFactory(log, viper)
 	validate := config.NewValidator(viper)
-	return NewUserHandler(log, viper, validate, useCase)
+	return NewUserHandler(log, viper, validate, useCase, tokenUseCase, twoFactorUseCase)
 }
 
 func (u *UserHandler) Login(ctx *gin.Context) {
@@ -65,32 +88,249 @@ func (u *UserHandler) Login(ctx *gin.Context) {
 		return
 	}
 
-	user, err := u.UseCase.Login(payload)
+	user, err := u.UseCase.Login(payload, ctx.ClientIP())
 	if err != nil {
 		u.Log.Error("[UserHandler.Login] " + err.Error())
-		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		var throttled *usecase.TooManyRequestsError
+		switch {
+		case errors.As(err, &throttled):
+			tooManyRequestsResponse(ctx, throttled)
+		case errors.Is(err, usecase.ErrInvalidCredentials):
+			utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		case errors.Is(err, usecase.ErrUserInactive), errors.Is(err, usecase.ErrEmailNotVerified):
+			utils.ErrorResponse(ctx, http.StatusForbidden, "error", err.Error())
+		default:
+			utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		}
 		return
 	}
 
-	if user == nil {
-		utils.ErrorResponse(ctx, http.StatusNotFound, "error", "User not found")
+	if user.TwoFactorEnabled {
+		challenge, err := u.TwoFactorUseCase.CreateChallenge(user)
+		if err != nil {
+			u.Log.Error("[UserHandler.Login] " + err.Error())
+			utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+			return
+		}
+
+		utils.SuccessResponse(ctx, http.StatusOK, "two-factor code required", challenge)
 		return
 	}
 
-	token, err := utils.GenerateToken(user)
+	tokens, err := u.TokenUseCase.IssueTokens(user, false)
 	if err != nil {
 		u.Log.Error("[UserHandler.Login] " + err.Error())
 		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
 		return
 	}
 
-	var data = map[string]interface{}{
-		"token":      token,
-		"token_type": "Bearer",
-		"user":       user,
+	utils.SuccessResponse(ctx, http.StatusOK, "success", tokens)
+}
+
+// LoginTwoFactor completes a login of a user with two-factor authentication by
+// exchanging the challenge token and a TOTP or recovery code for tokens.
+func (u *UserHandler) LoginTwoFactor(ctx *gin.Context) {
+	var payload = new(request.TwoFactorLoginRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		u.Log.Error("[UserHandler.LoginTwoFactor] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	err := u.Validate.Struct(payload)
+	if err != nil {
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		u.Log.Errorf("Error when validating request: %v", err)
+		return
+	}
+
+	user, err := u.TwoFactorUseCase.VerifyChallenge(payload)
+	if err != nil {
+		u.Log.Error("[UserHandler.LoginTwoFactor] " + err.Error())
+		var throttled *usecase.TooManyRequestsError
+		if errors.As(err, &throttled) {
+			tooManyRequestsResponse(ctx, throttled)
+			return
+		}
+		if errors.Is(err, usecase.ErrInvalidTwoFactorChallenge) || errors.Is(err, usecase.ErrInvalidTwoFactorCode) {
+			utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+			return
+		}
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	tokens, err := u.TokenUseCase.IssueTokens(user, true)
+	if err != nil {
+		u.Log.Error("[UserHandler.LoginTwoFactor] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", tokens)
+}
+
+func (u *UserHandler) Register(ctx *gin.Context) {
+	var payload = new(request.UserRegisterRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		u.Log.Error("[UserHandler.Register] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	err := u.Validate.Struct(payload)
+	if err != nil {
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		u.Log.Errorf("Error when validating request: %v", err)
+		return
+	}
+
+	user, err := u.UseCase.Register(payload)
+	if err != nil {
+		u.Log.Error("[UserHandler.Register] " + err.Error())
+		if errors.Is(err, usecase.ErrUserAlreadyRegistered) {
+			utils.ErrorResponse(ctx, http.StatusConflict, "error", err.Error())
+			return
+		}
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusCreated, "verification code has been sent to your email", user)
+}
+
+func (u *UserHandler) VerifyEmail(ctx *gin.Context) {
+	var payload = new(request.VerifyEmailRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		u.Log.Error("[UserHandler.VerifyEmail] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	err := u.Validate.Struct(payload)
+	if err != nil {
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		u.Log.Errorf("Error when validating request: %v", err)
+		return
+	}
+
+	user, err := u.UseCase.VerifyEmail(payload)
+	if err != nil {
+		u.Log.Error("[UserHandler.VerifyEmail] " + err.Error())
+		switch {
+		case errors.Is(err, usecase.ErrInvalidUserToken):
+			utils.BadRequestResponse(ctx, err.Error(), nil)
+		default:
+			utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "email verified", user)
+}
+
+func (u *UserHandler) ResendVerificationEmail(ctx *gin.Context) {
+	var payload = new(request.ResendVerificationEmailRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		u.Log.Error("[UserHandler.ResendVerificationEmail] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	err := u.Validate.Struct(payload)
+	if err != nil {
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		u.Log.Errorf("Error when validating request: %v", err)
+		return
+	}
+
+	if err := u.UseCase.ResendVerificationEmail(payload); err != nil {
+		u.Log.Error("[UserHandler.ResendVerificationEmail] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "if the email is registered, a new verification code has been sent", nil)
+}
+
+func (u *UserHandler) ForgotPassword(ctx *gin.Context) {
+	var payload = new(request.ForgotPasswordRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		u.Log.Error("[UserHandler.ForgotPassword] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	err := u.Validate.Struct(payload)
+	if err != nil {
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		u.Log.Errorf("Error when validating request: %v", err)
+		return
+	}
+
+	if err := u.UseCase.ForgotPassword(payload); err != nil {
+		u.Log.Error("[UserHandler.ForgotPassword] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "if the email is registered, a password reset code has been sent", nil)
+}
+
+func (u *UserHandler) ResetPassword(ctx *gin.Context) {
+	var payload = new(request.ResetPasswordRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		u.Log.Error("[UserHandler.ResetPassword] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	err := u.Validate.Struct(payload)
+	if err != nil {
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		u.Log.Errorf("Error when validating request: %v", err)
+		return
+	}
+
+	if err := u.UseCase.ResetPassword(payload); err != nil {
+		u.Log.Error("[UserHandler.ResetPassword] " + err.Error())
+		if errors.Is(err, usecase.ErrInvalidUserToken) {
+			utils.BadRequestResponse(ctx, err.Error(), nil)
+			return
+		}
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "password has been reset", nil)
+}
+
+func (u *UserHandler) UnlockAccount(ctx *gin.Context) {
+	var payload = new(request.UnlockAccountRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		u.Log.Error("[UserHandler.UnlockAccount] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	err := u.Validate.Struct(payload)
+	if err != nil {
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		u.Log.Errorf("Error when validating request: %v", err)
+		return
+	}
+
+	if err := u.UseCase.UnlockAccount(payload); err != nil {
+		u.Log.Error("[UserHandler.UnlockAccount] " + err.Error())
+		if errors.Is(err, usecase.ErrInvalidUserToken) {
+			utils.BadRequestResponse(ctx, err.Error(), nil)
+			return
+		}
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
 	}
 
-	utils.SuccessResponse(ctx, http.StatusOK, "success", data)
+	utils.SuccessResponse(ctx, http.StatusOK, "account has been unlocked", nil)
 }
 
 func (u *UserHandler) UserMe(ctx *gin.Context) {
@@ -121,3 +361,171 @@ func (u *UserHandler) UserMe(ctx *gin.Context) {
 
 	utils.SuccessResponse(ctx, http.StatusOK, "success", me)
 }
+
+func (u *UserHandler) FindAllPaginated(ctx *gin.Context) {
+	page, pageSize, search := getPagination(ctx)
+
+	users, total, err := u.UseCase.FindAllPaginated(page, pageSize, search)
+	if err != nil {
+		u.Log.Error("[UserHandler.FindAllPaginated] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	utils.PaginatedResponse(ctx, http.StatusOK, "success", users, utils.NewPagination(page, pageSize, total))
+}
+
+func (u *UserHandler) FindByID(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid user id", err.Error())
+		return
+	}
+
+	user, err := u.UseCase.FindByID(id)
+	if err != nil {
+		u.Log.Error("[UserHandler.FindByID] " + err.Error())
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	if user == nil {
+		utils.ErrorResponse(ctx, http.StatusNotFound, "error", "User not found")
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", user)
+}
+
+func (u *UserHandler) UpdateUser(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid user id", err.Error())
+		return
+	}
+
+	var payload = new(request.UserRequest)
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		u.Log.Error("[UserHandler.UpdateUser] " + err.Error())
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		return
+	}
+
+	err = u.Validate.Struct(payload)
+	if err != nil {
+		utils.BadRequestResponse(ctx, "bad request", err.Error())
+		u.Log.Errorf("Error when validating request: %v", err)
+		return
+	}
+
+	actorID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		u.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	user, err := u.UseCase.UpdateUser(actorID, id, payload)
+	if err != nil {
+		u.Log.Error("[UserHandler.UpdateUser] " + err.Error())
+		if errors.Is(err, usecase.ErrUserEmailTaken) || errors.Is(err, usecase.ErrCannotModifySelf) {
+			utils.ErrorResponse(ctx, http.StatusConflict, "error", err.Error())
+			return
+		}
+		if errors.Is(err, usecase.ErrUserOutranksActor) {
+			utils.ErrorResponse(ctx, http.StatusForbidden, "error", err.Error())
+			return
+		}
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	if user == nil {
+		utils.ErrorResponse(ctx, http.StatusNotFound, "error", "User not found")
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", user)
+}
+
+func (u *UserHandler) DeleteUser(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid user id", err.Error())
+		return
+	}
+
+	actorID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		u.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	deleted, err := u.UseCase.DeleteUser(actorID, id)
+	if err != nil {
+		u.Log.Error("[UserHandler.DeleteUser] " + err.Error())
+		if errors.Is(err, usecase.ErrCannotModifySelf) {
+			utils.ErrorResponse(ctx, http.StatusConflict, "error", err.Error())
+			return
+		}
+		if errors.Is(err, usecase.ErrUserOutranksActor) {
+			utils.ErrorResponse(ctx, http.StatusForbidden, "error", err.Error())
+			return
+		}
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	if !deleted {
+		utils.ErrorResponse(ctx, http.StatusNotFound, "error", "User not found")
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", nil)
+}
+
+func (u *UserHandler) ActivateUser(ctx *gin.Context) {
+	u.updateUserStatus(ctx, entity.USER_ACTIVE)
+}
+
+func (u *UserHandler) DeactivateUser(ctx *gin.Context) {
+	u.updateUserStatus(ctx, entity.USER_INACTIVE)
+}
+
+func (u *UserHandler) updateUserStatus(ctx *gin.Context, status entity.UserStatus) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(ctx, "invalid user id", err.Error())
+		return
+	}
+
+	actorID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		u.Log.Errorf("Error when getting user: %v", err)
+		utils.ErrorResponse(ctx, http.StatusUnauthorized, "error", err.Error())
+		return
+	}
+
+	user, err := u.UseCase.UpdateUserStatus(actorID, id, status)
+	if err != nil {
+		u.Log.Error("[UserHandler.updateUserStatus] " + err.Error())
+		if errors.Is(err, usecase.ErrCannotModifySelf) {
+			utils.ErrorResponse(ctx, http.StatusConflict, "error", err.Error())
+			return
+		}
+		if errors.Is(err, usecase.ErrUserOutranksActor) {
+			utils.ErrorResponse(ctx, http.StatusForbidden, "error", err.Error())
+			return
+		}
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	if user == nil {
+		utils.ErrorResponse(ctx, http.StatusNotFound, "error", "User not found")
+		return
+	}
+
+	utils.SuccessResponse(ctx, http.StatusOK, "success", user)
+}
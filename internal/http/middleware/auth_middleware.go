@@ -2,18 +2,29 @@ package middleware
 
 import (
 	"errors"
-	"fmt"
 	"net/http"
 	"strings"
 
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/usecase"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/repository"
 	"github.com/IlhamSetiaji/gift-redeem-be/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/spf13/viper"
 )
 
-func NewAuth(viper *viper.Viper) gin.HandlerFunc {
+// NewAuth validates the bearer token of the request and rejects access tokens
+// whose jti has been revoked by a logout. API keys are accepted as well, either
+// in the X-API-Key header or as the bearer token.
+func NewAuth(viper *viper.Viper, tokenRepository repository.ITokenRepository, apiKeyUseCase usecase.IAPIKeyUseCase) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+			authenticateAPIKey(c, apiKeyUseCase, apiKey)
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			utils.ErrorResponse(c, http.StatusUnauthorized, "error", "No Authorization header provided")
@@ -28,12 +39,19 @@ func NewAuth(viper *viper.Viper) gin.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.Parse(bearerToken[1], func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(viper.GetString("jwt.secret")), nil
-		})
+		if strings.HasPrefix(bearerToken[1], entity.API_KEY_PREFIX) {
+			authenticateAPIKey(c, apiKeyUseCase, bearerToken[1])
+			return
+		}
+
+		keys, err := utils.LoadKeySet(viper)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "error", err.Error())
+			c.Abort()
+			return
+		}
+
+		token, err := jwt.Parse(bearerToken[1], keys.Keyfunc)
 
 		if err != nil {
 			utils.ErrorResponse(c, http.StatusUnauthorized, "error", err.Error())
@@ -41,18 +59,85 @@ func NewAuth(viper *viper.Viper) gin.HandlerFunc {
 			return
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			c.Set("auth", claims)
-		} else {
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok || !token.Valid {
 			utils.ErrorResponse(c, http.StatusUnauthorized, "error", "Invalid token")
 			c.Abort()
 			return
 		}
 
+		// challenge tokens of a pending two-factor login are signed with the same
+		// keys but must not grant access
+		if tokenUse, ok := claims["token_use"]; ok && tokenUse != utils.TokenUseAccess {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "error", "Invalid token")
+			c.Abort()
+			return
+		}
+
+		if jti, ok := claims["jti"].(string); ok && jti != "" {
+			revoked, err := tokenRepository.IsAccessTokenRevoked(jti)
+			if err != nil {
+				utils.ErrorResponse(c, http.StatusInternalServerError, "error", err.Error())
+				c.Abort()
+				return
+			}
+
+			if revoked {
+				utils.ErrorResponse(c, http.StatusUnauthorized, "error", "Token has been revoked")
+				c.Abort()
+				return
+			}
+		}
+
+		c.Set("auth", claims)
+
+		c.Next()
+	}
+}
+
+func authenticateAPIKey(c *gin.Context, apiKeyUseCase usecase.IAPIKeyUseCase, apiKey string) {
+	claims, err := apiKeyUseCase.Authenticate(apiKey)
+	if err != nil {
+		if errors.Is(err, usecase.ErrAPIKeyInvalid) {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "error", err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "error", err.Error())
+		}
+		c.Abort()
+		return
+	}
+
+	c.Set("auth", claims)
+
+	c.Next()
+}
+
+// DenyAPIKeys keeps requests authenticated with an API key away from routes
+// that need a signed in user, such as managing the API keys themselves. It must
+// run after NewAuth.
+func DenyAPIKeys() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsAPIKeyRequest(c) {
+			utils.ErrorResponse(c, http.StatusForbidden, "error", "This resource cannot be accessed with an API key")
+			c.Abort()
+			return
+		}
+
 		c.Next()
 	}
 }
 
+// IsAPIKeyRequest reports whether the request was authenticated with an API key.
+func IsAPIKeyRequest(c *gin.Context) bool {
+	claims, err := GetUser(c)
+	if err != nil {
+		return false
+	}
+
+	_, ok := claims["api_key_id"]
+	return ok
+}
+
 func GetUser(c *gin.Context) (jwt.MapClaims, error) {
 	auth, exists := c.Get("auth")
 	if !exists {
@@ -66,3 +151,17 @@ func GetUser(c *gin.Context) (jwt.MapClaims, error) {
 
 	return claims, nil
 }
+
+func GetUserID(c *gin.Context) (uuid.UUID, error) {
+	claims, err := GetUser(c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	id, ok := claims["id"].(string)
+	if !ok {
+		return uuid.Nil, errors.New("user id not found in auth claims")
+	}
+
+	return uuid.Parse(id)
+}
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/repository"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	defaultIdempotencyKeyTTL = 24
+)
+
+// idempotencyWriter keeps a copy of the response body so it can be stored.
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyWriter) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// NewIdempotency makes a request safe to retry when the client sends an
+// Idempotency-Key header. The first request with a key is executed and its
+// response stored for idempotency.ttl hours; a retry with the same key and the
+// same method, path and body gets the stored response back. Reusing the key
+// for another request fails with 422, and a retry while the first request is
+// still running fails with 409. Responses with a 5xx status are not stored, so
+// the request can be retried. It must run after NewAuth.
+func NewIdempotency(viper *viper.Viper, log *logrus.Logger, idempotencyRepository repository.IIdempotencyRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			utils.BadRequestResponse(c, "Idempotency-Key must not be longer than 255 characters", nil)
+			c.Abort()
+			return
+		}
+
+		userID, err := GetUserID(c)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "error", err.Error())
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			utils.BadRequestResponse(c, "failed to read request body", err.Error())
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := utils.HashToken(c.Request.Method + " " + c.Request.URL.Path + "\n" + string(body))
+
+		ttl := viper.GetInt("idempotency.ttl")
+		if ttl <= 0 {
+			ttl = defaultIdempotencyKeyTTL
+		}
+
+		stored, created, err := idempotencyRepository.Reserve(&entity.IdempotencyKey{
+			UserID:      userID,
+			Key:         key,
+			Fingerprint: fingerprint,
+			Status:      entity.IDEMPOTENCY_KEY_PROCESSING,
+			ExpiresAt:   time.Now().Add(time.Duration(ttl) * time.Hour),
+		})
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "error", err.Error())
+			c.Abort()
+			return
+		}
+
+		if !created {
+			replayIdempotentResponse(c, stored, fingerprint)
+			return
+		}
+
+		writer := &idempotencyWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		// release the key when the handler panics or fails, so a retry runs again
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := idempotencyRepository.Release(stored.ID); err != nil {
+				log.Error("[IdempotencyMiddleware] " + err.Error())
+			}
+		}()
+
+		c.Next()
+
+		if writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		if err := idempotencyRepository.Complete(stored.ID, writer.Status(), writer.body.String()); err != nil {
+			log.Error("[IdempotencyMiddleware] " + err.Error())
+			return
+		}
+		completed = true
+	}
+}
+
+func replayIdempotentResponse(c *gin.Context, stored *entity.IdempotencyKey, fingerprint string) {
+	if stored.Fingerprint != fingerprint {
+		utils.ErrorResponse(c, http.StatusUnprocessableEntity, "error", "Idempotency-Key was already used for a different request")
+		c.Abort()
+		return
+	}
+
+	if stored.Status != entity.IDEMPOTENCY_KEY_COMPLETED {
+		utils.ErrorResponse(c, http.StatusConflict, "error", "A request with this Idempotency-Key is still being processed")
+		c.Abort()
+		return
+	}
+
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Data(stored.ResponseCode, "application/json; charset=utf-8", []byte(stored.ResponseBody))
+	c.Abort()
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission only lets the request through when the authenticated user
+// has been granted at least one of the given permissions. It must run after
+// NewAuth.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userPermissions, err := GetPermissions(c)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "error", err.Error())
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if _, ok := userPermissions[permission]; ok {
+				c.Next()
+				return
+			}
+		}
+
+		utils.ErrorResponse(c, http.StatusForbidden, "error", "You do not have permission to access this resource")
+		c.Abort()
+	}
+}
+
+// GetPermissions returns the permission names in the JWT permissions claim.
+func GetPermissions(c *gin.Context) (map[string]struct{}, error) {
+	claims, err := GetUser(c)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := make(map[string]struct{})
+
+	rawPermissions, ok := claims["permissions"].([]interface{})
+	if !ok {
+		return permissions, nil
+	}
+
+	for _, rawPermission := range rawPermissions {
+		if name, ok := rawPermission.(string); ok {
+			permissions[name] = struct{}{}
+		}
+	}
+
+	return permissions, nil
+}
+
+// HasPermission reports whether the authenticated user holds the permission.
+func HasPermission(c *gin.Context, permission string) bool {
+	permissions, err := GetPermissions(c)
+	if err != nil {
+		return false
+	}
+
+	_, ok := permissions[permission]
+	return ok
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRoles only lets the request through when the authenticated user holds
+// at least one of the given roles. It must run after NewAuth.
+func RequireRoles(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userRoles, err := GetRoles(c)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "error", err.Error())
+			c.Abort()
+			return
+		}
+
+		for _, role := range roles {
+			if _, ok := userRoles[role]; ok {
+				c.Next()
+				return
+			}
+		}
+
+		utils.ErrorResponse(c, http.StatusForbidden, "error", "You do not have permission to access this resource")
+		c.Abort()
+	}
+}
+
+// GetRoles returns the names of the active roles in the JWT roles claim.
+func GetRoles(c *gin.Context) (map[string]struct{}, error) {
+	claims, err := GetUser(c)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make(map[string]struct{})
+
+	rawRoles, ok := claims["roles"].([]interface{})
+	if !ok {
+		return roles, nil
+	}
+
+	for _, rawRole := range rawRoles {
+		role, ok := rawRole.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if status, ok := role["status"].(string); ok && entity.RoleStatus(status) == entity.ROLE_INACTIVE {
+			continue
+		}
+
+		if name, ok := role["name"].(string); ok {
+			roles[name] = struct{}{}
+		}
+	}
+
+	return roles, nil
+}
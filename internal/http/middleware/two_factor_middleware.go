@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireTwoFactor rejects users whose roles require two-factor authentication
+// unless the session was started with a second factor. Users that still have to
+// enroll can only reach the routes registered before this middleware. It must
+// run after NewAuth.
+func RequireTwoFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := GetUser(c)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "error", err.Error())
+			c.Abort()
+			return
+		}
+
+		required, _ := claims["mfa_required"].(bool)
+		verified, _ := claims["mfa"].(bool)
+		if required && !verified {
+			utils.ErrorResponse(c, http.StatusForbidden, "error", "Two-factor authentication is required for your account, enable it and sign in again")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
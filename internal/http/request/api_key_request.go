@@ -0,0 +1,11 @@
+package request
+
+import "time"
+
+// APIKeyRequest creates an API key. Without scopes the key carries every
+// permission of its owner, without expires_at it never expires.
+type APIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=255"`
+	Scopes    []string   `json:"scopes" validate:"omitempty,dive,required"`
+	ExpiresAt *time.Time `json:"expires_at" validate:"omitempty"`
+}
@@ -0,0 +1,21 @@
+package request
+
+import "time"
+
+type GiftRequest struct {
+	RedeemCode  string `json:"redeem_code" validate:"required"`
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description" validate:"omitempty"`
+	Price       int    `json:"price" validate:"gte=0"`
+
+	// RFC 3339 timestamps with an offset, e.g. 2026-12-31T23:59:59+07:00
+	AvailableFrom *time.Time `json:"available_from" validate:"omitempty"`
+	ExpiredAt     *time.Time `json:"expired_at" validate:"omitempty"`
+
+	// redemption limits, 0 means unlimited
+	MaxPerUser        int `json:"max_per_user" validate:"gte=0"`
+	MaxPerUserDaily   int `json:"max_per_user_daily" validate:"gte=0"`
+	MaxPerUserWeekly  int `json:"max_per_user_weekly" validate:"gte=0"`
+	MaxPerUserMonthly int `json:"max_per_user_monthly" validate:"gte=0"`
+	MaxDaily          int `json:"max_daily" validate:"gte=0"`
+}
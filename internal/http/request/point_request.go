@@ -0,0 +1,15 @@
+package request
+
+// PointAdjustmentRequest grants (CREDIT) or deducts (DEBIT) points of a user.
+type PointAdjustmentRequest struct {
+	Type   string `json:"type" validate:"required,oneof=CREDIT DEBIT"`
+	Amount int64  `json:"amount" validate:"required,min=1"`
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+// PointBatchRequest holds the form fields sent along with a CSV upload. The
+// reason applies to every row that does not carry its own.
+type PointBatchRequest struct {
+	BatchKey string `form:"batch_key" validate:"required,max=100"`
+	Reason   string `form:"reason" validate:"required,max=255"`
+}
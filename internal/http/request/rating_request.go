@@ -0,0 +1,6 @@
+package request
+
+type RatingRequest struct {
+	Rating  float64 `json:"rating" validate:"required,gte=1,lte=5"`
+	Comment string  `json:"comment" validate:"omitempty,max=1000"`
+}
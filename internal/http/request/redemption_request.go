@@ -0,0 +1,13 @@
+package request
+
+// RedemptionStatusRequest moves a redemption to another status. The note is
+// shown to the user in the timeline of the redemption.
+type RedemptionStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=FULFILLED SHIPPED CANCELLED REFUNDED"`
+	Note   string `json:"note" validate:"omitempty,max=255"`
+}
+
+// RedemptionCancelRequest cancels a redemption that has not been fulfilled yet.
+type RedemptionCancelRequest struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
@@ -0,0 +1,20 @@
+package request
+
+import (
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/google/uuid"
+)
+
+type RoleRequest struct {
+	Name          string            `json:"name" validate:"required"`
+	GuardName     string            `json:"guard_name" validate:"omitempty"`
+	Status        entity.RoleStatus `json:"status" validate:"omitempty,RoleStatusValidation"`
+	PermissionIDs []uuid.UUID       `json:"permission_ids" validate:"omitempty,dive,uuid"`
+
+	// RequireTwoFactor is left unchanged on update when omitted
+	RequireTwoFactor *bool `json:"require_two_factor" validate:"omitempty"`
+}
+
+type AssignRolesRequest struct {
+	RoleIDs []uuid.UUID `json:"role_ids" validate:"required,min=1,dive,uuid"`
+}
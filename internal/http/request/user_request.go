@@ -21,8 +21,6 @@ type UserRequest struct {
 	PasswordConfirmation string            `json:"password_confirmation" validate:"omitempty,eqfield=Password"`
 	Gender               entity.UserGender `json:"gender" validate:"omitempty,UserGenderValidation"`
 	EmailVerifiedAt      time.Time         `json:"email_verified_at" validate:"omitempty"`
-	Status               entity.UserStatus `json:"status" validate:"omitempty,UserStatusValidation"`
-	RoleIDs              []uuid.UUID       `json:"role_ids" validate:"omitempty,dive,uuid"`
 }
 
 type UserRegisterRequest struct {
@@ -32,5 +30,29 @@ type UserRegisterRequest struct {
 	Password             string            `json:"password" validate:"required"`
 	PasswordConfirmation string            `json:"password_confirmation" validate:"required,eqfield=Password"`
 	Gender               entity.UserGender `json:"gender" validate:"required,UserGenderValidation"`
-	RoleIDs              []uuid.UUID       `json:"role_ids" validate:"required,dive,uuid"`
+}
+
+type VerifyEmailRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Token int    `json:"token" validate:"required"`
+}
+
+type ResendVerificationEmailRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Email                string `json:"email" validate:"required,email"`
+	Token                int    `json:"token" validate:"required"`
+	Password             string `json:"password" validate:"required,min=8"`
+	PasswordConfirmation string `json:"password_confirmation" validate:"required,eqfield=Password"`
+}
+
+type UnlockAccountRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Token int    `json:"token" validate:"required"`
 }
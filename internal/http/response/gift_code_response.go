@@ -0,0 +1,34 @@
+package response
+
+import (
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/google/uuid"
+)
+
+type GiftCodeResponse struct {
+	ID           uuid.UUID             `json:"id"`
+	GiftID       uuid.UUID             `json:"gift_id"`
+	CodeHint     string                `json:"code_hint"`
+	Status       entity.GiftCodeStatus `json:"status"`
+	RedemptionID *uuid.UUID            `json:"redemption_id"`
+	IssuedAt     *time.Time            `json:"issued_at"`
+	VoidedAt     *time.Time            `json:"voided_at"`
+	CreatedAt    time.Time             `json:"created_at"`
+	UpdatedAt    time.Time             `json:"updated_at"`
+}
+
+// GiftCodeImportResponse summarizes a CSV import of gift codes. Rows that were
+// rejected are listed with the reason, duplicates are only counted.
+type GiftCodeImportResponse struct {
+	TotalRows  int                           `json:"total_rows"`
+	Imported   int64                         `json:"imported"`
+	Duplicates int64                         `json:"duplicates"`
+	Rejected   []GiftCodeImportErrorResponse `json:"rejected"`
+}
+
+type GiftCodeImportErrorResponse struct {
+	RowNumber int    `json:"row_number"`
+	Error     string `json:"error"`
+}
@@ -0,0 +1,32 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type GiftResponse struct {
+	ID            uuid.UUID          `json:"id"`
+	RedeemCode    string             `json:"redeem_code"`
+	Name          string             `json:"name"`
+	Description   string             `json:"description"`
+	Price         int                `json:"price"`
+	Stock         int                `json:"stock"`
+	AvailableFrom *time.Time         `json:"available_from"`
+	ExpiredAt     *time.Time         `json:"expired_at"`
+	Limits        GiftLimitsResponse `json:"limits"`
+	AvgRating     float64            `json:"avg_rating"`
+	RatingCount   int64              `json:"rating_count"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+}
+
+// GiftLimitsResponse lists the redemption limits of a gift, 0 means unlimited.
+type GiftLimitsResponse struct {
+	MaxPerUser        int `json:"max_per_user"`
+	MaxPerUserDaily   int `json:"max_per_user_daily"`
+	MaxPerUserWeekly  int `json:"max_per_user_weekly"`
+	MaxPerUserMonthly int `json:"max_per_user_monthly"`
+	MaxDaily          int `json:"max_daily"`
+}
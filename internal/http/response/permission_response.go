@@ -0,0 +1,16 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PermissionResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	GuardName   string    `json:"guard_name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
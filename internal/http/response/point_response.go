@@ -0,0 +1,51 @@
+package response
+
+import (
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/google/uuid"
+)
+
+type PointBalanceResponse struct {
+	UserID    uuid.UUID  `json:"user_id"`
+	Balance   int64      `json:"balance"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+type PointLedgerResponse struct {
+	ID            uuid.UUID             `json:"id"`
+	Type          entity.PointEntryType `json:"type"`
+	Amount        int64                 `json:"amount"`
+	BalanceAfter  int64                 `json:"balance_after"`
+	Reason        string                `json:"reason"`
+	ReferenceType string                `json:"reference_type,omitempty"`
+	ReferenceID   *uuid.UUID            `json:"reference_id,omitempty"`
+	ReversalOfID  *uuid.UUID            `json:"reversal_of_id,omitempty"`
+	ActorID       *uuid.UUID            `json:"actor_id,omitempty"`
+	CreatedAt     time.Time             `json:"created_at"`
+}
+
+type PointBatchResponse struct {
+	ID            uuid.UUID                `json:"id"`
+	BatchKey      string                   `json:"batch_key"`
+	FileName      string                   `json:"file_name"`
+	Reason        string                   `json:"reason"`
+	Status        entity.PointBatchStatus  `json:"status"`
+	TotalRows     int                      `json:"total_rows"`
+	SucceededRows int                      `json:"succeeded_rows"`
+	FailedRows    int                      `json:"failed_rows"`
+	ActorID       uuid.UUID                `json:"actor_id"`
+	CreatedAt     time.Time                `json:"created_at"`
+	UpdatedAt     time.Time                `json:"updated_at"`
+	Rows          *[]PointBatchRowResponse `json:"rows,omitempty"`
+}
+
+type PointBatchRowResponse struct {
+	RowNumber int                        `json:"row_number"`
+	Email     string                     `json:"email"`
+	Amount    int64                      `json:"amount"`
+	Status    entity.PointBatchRowStatus `json:"status"`
+	Error     string                     `json:"error,omitempty"`
+	LedgerID  *uuid.UUID                 `json:"ledger_id,omitempty"`
+}
@@ -0,0 +1,16 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RatingResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	RedemptionID *uuid.UUID `json:"redemption_id"`
+	Rating       float64    `json:"rating"`
+	Comment      string     `json:"comment"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,39 @@
+package response
+
+import (
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/google/uuid"
+)
+
+type RedemptionResponse struct {
+	ID          uuid.UUID                  `json:"id"`
+	UserID      uuid.UUID                  `json:"user_id"`
+	GiftID      uuid.UUID                  `json:"gift_id"`
+	RedeemedAt  time.Time                  `json:"redeemed_at"`
+	Status      entity.RedemptionStatus    `json:"status"`
+	PointsSpent int64                      `json:"points_spent"`
+	Code        string                     `json:"code,omitempty"`
+	CreatedAt   time.Time                  `json:"created_at"`
+	UpdatedAt   time.Time                  `json:"updated_at"`
+	Gift        *GiftResponse              `json:"gift"`
+	Rating      *RatingResponse            `json:"rating"`
+	Events      *[]RedemptionEventResponse `json:"events,omitempty"`
+}
+
+type RedemptionEventResponse struct {
+	FromStatus entity.RedemptionStatus `json:"from_status,omitempty"`
+	ToStatus   entity.RedemptionStatus `json:"to_status"`
+	ActorID    *uuid.UUID              `json:"actor_id"`
+	Note       string                  `json:"note,omitempty"`
+	CreatedAt  time.Time               `json:"created_at"`
+}
+
+// RedemptionLimitResponse tells which limit of a gift was reached and when it
+// resets. ResetsAt is omitted for the lifetime limit.
+type RedemptionLimitResponse struct {
+	Limit    entity.GiftLimitCode `json:"limit"`
+	Max      int                  `json:"max"`
+	ResetsAt *time.Time           `json:"resets_at,omitempty"`
+}
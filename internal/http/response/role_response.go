@@ -8,11 +8,20 @@ import (
 )
 
 type RoleResponse struct {
-	ID        uuid.UUID         `json:"id"`
-	Name      string            `json:"name"`
-	GuardName string            `json:"guard_name"`
-	Status    entity.RoleStatus `json:"status"`
-	CreatedAt time.Time         `json:"created_at"`
-	UpdatedAt time.Time         `json:"updated_at"`
-	Users     *[]UserResponse   `json:"users"`
+	ID          uuid.UUID             `json:"id"`
+	Name        string                `json:"name"`
+	GuardName   string                `json:"guard_name"`
+	Status      entity.RoleStatus     `json:"status"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+	Users       *[]UserResponse       `json:"users"`
+	Permissions *[]PermissionResponse `json:"permissions"`
+
+	RequireTwoFactor bool `json:"require_two_factor"`
+}
+
+type UserRolesResponse struct {
+	UserID    uuid.UUID       `json:"user_id"`
+	Assigned  *[]RoleResponse `json:"assigned"`
+	Available *[]RoleResponse `json:"available"`
 }
@@ -0,0 +1,19 @@
+package response
+
+import (
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/google/uuid"
+)
+
+type SchedulerRunResponse struct {
+	ID          uuid.UUID                 `json:"id"`
+	JobName     string                    `json:"job_name"`
+	Owner       string                    `json:"owner"`
+	ScheduledAt time.Time                 `json:"scheduled_at"`
+	StartedAt   time.Time                 `json:"started_at"`
+	FinishedAt  *time.Time                `json:"finished_at"`
+	Status      entity.SchedulerRunStatus `json:"status"`
+	Message     string                    `json:"message,omitempty"`
+}
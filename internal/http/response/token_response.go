@@ -0,0 +1,10 @@
+package response
+
+type TokenResponse struct {
+	Token                 string        `json:"token"`
+	TokenType             string        `json:"token_type"`
+	ExpiresIn             int64         `json:"expires_in"`
+	RefreshToken          string        `json:"refresh_token"`
+	RefreshTokenExpiresIn int64         `json:"refresh_token_expires_in"`
+	User                  *UserResponse `json:"user,omitempty"`
+}
@@ -18,4 +18,8 @@ type UserResponse struct {
 	CreatedAt       time.Time         `json:"created_at"`
 	UpdatedAt       time.Time         `json:"updated_at"`
 	Roles           *[]RoleResponse   `json:"roles"`
+	Permissions     []string          `json:"permissions"`
+
+	TwoFactorEnabled  bool `json:"two_factor_enabled"`
+	TwoFactorRequired bool `json:"two_factor_required"`
 }
@@ -1,19 +1,35 @@
 package route
 
 import (
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
 	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/handler"
 	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/middleware"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/usecase"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/repository"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
 
 type RouteConfig struct {
-	App            *gin.Engine
-	Log            *logrus.Logger
-	Viper          *viper.Viper
-	UserHandler    handler.IUserHandler
-	AuthMiddleware gin.HandlerFunc
+	App                   *gin.Engine
+	Log                   *logrus.Logger
+	Viper                 *viper.Viper
+	UserHandler           handler.IUserHandler
+	GiftHandler           handler.IGiftHandler
+	GiftCodeHandler       handler.IGiftCodeHandler
+	RedemptionHandler     handler.IRedemptionHandler
+	RatingHandler         handler.IRatingHandler
+	RoleHandler           handler.IRoleHandler
+	TokenHandler          handler.ITokenHandler
+	JWKSHandler           handler.IJWKSHandler
+	OAuthHandler          handler.IOAuthHandler
+	TwoFactorHandler      handler.ITwoFactorHandler
+	APIKeyHandler         handler.IAPIKeyHandler
+	PointHandler          handler.IPointHandler
+	SchedulerHandler      handler.ISchedulerHandler
+	AuthMiddleware        gin.HandlerFunc
+	IdempotencyMiddleware gin.HandlerFunc
 }
 
 func (c *RouteConfig) SetupRoutes() {
@@ -22,6 +38,7 @@ func (c *RouteConfig) SetupRoutes() {
 			"message": "Hello world",
 		})
 	})
+	c.App.GET("/.well-known/jwks.json", c.JWKSHandler.JWKS)
 
 	c.SetupAPIRoutes()
 }
@@ -30,23 +47,159 @@ func (c *RouteConfig) SetupAPIRoutes() {
 	apiRoute := c.App.Group("/api")
 	{
 		apiRoute.POST("/login", c.UserHandler.Login)
+		apiRoute.POST("/login/2fa", c.UserHandler.LoginTwoFactor)
+		apiRoute.POST("/register", c.UserHandler.Register)
+		apiRoute.POST("/verify-email", c.UserHandler.VerifyEmail)
+		apiRoute.POST("/verify-email/resend", c.UserHandler.ResendVerificationEmail)
+		apiRoute.POST("/password/forgot", c.UserHandler.ForgotPassword)
+		apiRoute.POST("/password/reset", c.UserHandler.ResetPassword)
+		apiRoute.POST("/unlock-account", c.UserHandler.UnlockAccount)
+		apiRoute.POST("/token/refresh", c.TokenHandler.Refresh)
+		apiRoute.POST("/oauth/token", c.OAuthHandler.Token)
+		apiRoute.POST("/oauth/introspect", c.OAuthHandler.Introspect)
 		apiRoute.Use(c.AuthMiddleware)
 		{
 			apiRoute.GET("/users/me", c.UserHandler.UserMe)
+			apiRoute.POST("/logout", c.TokenHandler.Logout)
+			apiRoute.POST("/logout-all", c.TokenHandler.LogoutAll)
+
+			// two-factor enrollment stays reachable for users that still have to enable it
+			twoFactorRoute := apiRoute.Group("/2fa", middleware.DenyAPIKeys())
+			{
+				twoFactorRoute.POST("/setup", c.TwoFactorHandler.Setup)
+				twoFactorRoute.POST("/confirm", c.TwoFactorHandler.Confirm)
+				twoFactorRoute.POST("/disable", c.TwoFactorHandler.Disable)
+			}
+
+			apiRoute.Use(middleware.RequireTwoFactor())
+			apiRoute.POST("/oauth/authorize", middleware.DenyAPIKeys(), c.OAuthHandler.Authorize)
+
+			// gifts
+			apiRoute.GET("/gifts", c.GiftHandler.FindAllPaginated)
+			apiRoute.GET("/gifts/:id", c.GiftHandler.FindByID)
+			apiRoute.POST("/gifts/:id/redeem", middleware.RequirePermission(entity.PERMISSION_GIFTS_REDEEM), c.IdempotencyMiddleware, c.RedemptionHandler.Redeem)
+
+			// points
+			apiRoute.GET("/me/points", c.PointHandler.MyBalance)
+			apiRoute.GET("/me/points/history", c.PointHandler.MyHistory)
+
+			// redemptions
+			apiRoute.GET("/me/redemptions", c.RedemptionHandler.MyRedemptions)
+			apiRoute.GET("/me/redemptions/:id", c.RedemptionHandler.MyRedemption)
+			apiRoute.POST("/redemptions/:id/rating", c.RatingHandler.RateRedemption)
+			apiRoute.POST("/redemptions/:id/cancel", c.IdempotencyMiddleware, c.RedemptionHandler.Cancel)
+
+			// api keys, only manageable by a signed in user
+			apiKeyRoute := apiRoute.Group("/api-keys", middleware.DenyAPIKeys())
+			{
+				apiKeyRoute.GET("", c.APIKeyHandler.FindAllPaginated)
+				apiKeyRoute.POST("", c.APIKeyHandler.CreateAPIKey)
+				apiKeyRoute.DELETE("/:id", c.APIKeyHandler.RevokeAPIKey)
+			}
+
+			giftManageRoute := apiRoute.Group("", middleware.RequirePermission(entity.PERMISSION_GIFTS_MANAGE))
+			{
+				giftManageRoute.POST("/gifts", c.GiftHandler.CreateGift)
+				giftManageRoute.PUT("/gifts/:id", c.GiftHandler.UpdateGift)
+				giftManageRoute.DELETE("/gifts/:id", c.GiftHandler.DeleteGift)
+				giftManageRoute.GET("/gifts/:id/codes", c.GiftCodeHandler.FindAllPaginated)
+				giftManageRoute.POST("/gifts/:id/codes/import", c.GiftCodeHandler.ImportCodes)
+				giftManageRoute.POST("/gifts/:id/codes/:code_id/void", c.GiftCodeHandler.VoidCode)
+			}
+
+			userManageRoute := apiRoute.Group("/admin/users", middleware.RequirePermission(entity.PERMISSION_USERS_MANAGE))
+			{
+				userManageRoute.GET("", c.UserHandler.FindAllPaginated)
+				userManageRoute.GET("/:id", c.UserHandler.FindByID)
+				userManageRoute.PUT("/:id", c.UserHandler.UpdateUser)
+				userManageRoute.DELETE("/:id", c.UserHandler.DeleteUser)
+				userManageRoute.POST("/:id/activate", c.UserHandler.ActivateUser)
+				userManageRoute.POST("/:id/deactivate", c.UserHandler.DeactivateUser)
+				userManageRoute.DELETE("/:id/2fa", c.TwoFactorHandler.ResetUser)
+			}
+
+			redemptionManageRoute := apiRoute.Group("/admin/redemptions")
+			{
+				redemptionManageRoute.GET("", middleware.RequirePermission(entity.PERMISSION_REDEMPTIONS_VIEW_ALL, entity.PERMISSION_REDEMPTIONS_MANAGE), c.RedemptionHandler.FindAllPaginated)
+				redemptionManageRoute.GET("/:id", middleware.RequirePermission(entity.PERMISSION_REDEMPTIONS_VIEW_ALL, entity.PERMISSION_REDEMPTIONS_MANAGE), c.RedemptionHandler.FindByID)
+				redemptionManageRoute.POST("/:id/status", middleware.RequirePermission(entity.PERMISSION_REDEMPTIONS_MANAGE), c.IdempotencyMiddleware, c.RedemptionHandler.UpdateStatus)
+			}
+
+			pointManageRoute := apiRoute.Group("/admin", middleware.RequirePermission(entity.PERMISSION_POINTS_MANAGE))
+			{
+				pointManageRoute.GET("/users/:id/points", c.PointHandler.UserBalance)
+				pointManageRoute.GET("/users/:id/points/history", c.PointHandler.UserHistory)
+				pointManageRoute.POST("/users/:id/points", c.IdempotencyMiddleware, c.PointHandler.AdjustPoints)
+				pointManageRoute.GET("/points/batches", c.PointHandler.FindAllBatchesPaginated)
+				pointManageRoute.GET("/points/batches/:id", c.PointHandler.FindBatchByID)
+				pointManageRoute.POST("/points/batches", c.PointHandler.UploadBatch)
+			}
+
+			superAdminRoute := apiRoute.Group("", middleware.DenyAPIKeys(), middleware.RequireRoles("superadmin"))
+			{
+				// roles
+				superAdminRoute.GET("/roles", c.RoleHandler.FindAllPaginated)
+				superAdminRoute.GET("/roles/:id", c.RoleHandler.FindByID)
+				superAdminRoute.POST("/roles", c.RoleHandler.StoreRole)
+				superAdminRoute.PUT("/roles/:id", c.RoleHandler.UpdateRole)
+				superAdminRoute.DELETE("/roles/:id", c.RoleHandler.DeleteRole)
+				superAdminRoute.GET("/permissions", c.RoleHandler.FindAllPermissions)
+
+				// user roles
+				superAdminRoute.GET("/users/:id/roles", c.RoleHandler.FindUserRoles)
+				superAdminRoute.POST("/users/:id/roles", c.RoleHandler.AssignRolesToUser)
+				superAdminRoute.DELETE("/users/:id/roles/:role_id", c.RoleHandler.RevokeRoleFromUser)
+
+				// oauth clients
+				superAdminRoute.GET("/oauth-clients", c.OAuthHandler.FindAllClientsPaginated)
+				superAdminRoute.GET("/oauth-clients/:id", c.OAuthHandler.FindClientByID)
+				superAdminRoute.POST("/oauth-clients", c.OAuthHandler.CreateClient)
+				superAdminRoute.DELETE("/oauth-clients/:id", c.OAuthHandler.DeleteClient)
+
+				// scheduled jobs
+				superAdminRoute.GET("/scheduler/runs", c.SchedulerHandler.FindAllRunsPaginated)
+			}
 		}
 	}
 }
 
 func NewRouteConfig(app *gin.Engine, viper *viper.Viper, log *logrus.Logger) *RouteConfig {
 	// factory handlers
+	userHandler := handler.UserHandlerFactory(log, viper)
+	giftHandler := handler.GiftHandlerFactory(log, viper)
+	giftCodeHandler := handler.GiftCodeHandlerFactory(log, viper)
+	redemptionHandler := handler.RedemptionHandlerFactory(log, viper)
+	ratingHandler := handler.RatingHandlerFactory(log, viper)
+	roleHandler := handler.RoleHandlerFactory(log, viper)
+	tokenHandler := handler.TokenHandlerFactory(log, viper)
+	jwksHandler := handler.JWKSHandlerFactory(log, viper)
+	oauthHandler := handler.OAuthHandlerFactory(log, viper)
+	twoFactorHandler := handler.TwoFactorHandlerFactory(log, viper)
+	apiKeyHandler := handler.APIKeyHandlerFactory(log, viper)
+	pointHandler := handler.PointHandlerFactory(log, viper)
+	schedulerHandler := handler.SchedulerHandlerFactory(log, viper)
 
 	// factory middleware
-	authMiddleware := middleware.NewAuth(viper)
+	authMiddleware := middleware.NewAuth(viper, repository.TokenRepositoryFactory(log), usecase.APIKeyUseCaseFactory(log, viper))
+	idempotencyMiddleware := middleware.NewIdempotency(viper, log, repository.IdempotencyRepositoryFactory(log))
 	return &RouteConfig{
-		App:            app,
-		Log:            log,
-		Viper:          viper,
-		UserHandler:    handler.UserHandlerFactory(log, viper),
-		AuthMiddleware: authMiddleware,
+		App:                   app,
+		Log:                   log,
+		Viper:                 viper,
+		UserHandler:           userHandler,
+		GiftHandler:           giftHandler,
+		GiftCodeHandler:       giftCodeHandler,
+		RedemptionHandler:     redemptionHandler,
+		RatingHandler:         ratingHandler,
+		RoleHandler:           roleHandler,
+		TokenHandler:          tokenHandler,
+		JWKSHandler:           jwksHandler,
+		OAuthHandler:          oauthHandler,
+		TwoFactorHandler:      twoFactorHandler,
+		APIKeyHandler:         apiKeyHandler,
+		PointHandler:          pointHandler,
+		SchedulerHandler:      schedulerHandler,
+		AuthMiddleware:        authMiddleware,
+		IdempotencyMiddleware: idempotencyMiddleware,
 	}
 }
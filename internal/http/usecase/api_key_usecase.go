@@ -0,0 +1,240 @@
+package usecase
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/dto"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/request"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/repository"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// apiKeyPrefixLength is how much of a key is kept in clear text to identify it.
+const apiKeyPrefixLength = 12
+
+var (
+	ErrAPIKeyInvalid         = errors.New("API key is invalid, expired or revoked")
+	ErrAPIKeyScopeNotGranted = errors.New("API key scopes must be permissions you have been granted")
+	ErrAPIKeyExpiryInPast    = errors.New("API key expiry must be in the future")
+)
+
+type IAPIKeyUseCase interface {
+	FindAllPaginated(userID uuid.UUID, page int, pageSize int, search string) (*[]response.APIKeyResponse, int64, error)
+	CreateAPIKey(userID uuid.UUID, twoFactorVerified bool, payload *request.APIKeyRequest) (*response.APIKeyResponse, error)
+	RevokeAPIKey(userID uuid.UUID, id uuid.UUID) (bool, error)
+	Authenticate(key string) (jwt.MapClaims, error)
+}
+
+type APIKeyUseCase struct {
+	Log            *logrus.Logger
+	Viper          *viper.Viper
+	Repository     repository.IAPIKeyRepository
+	UserRepository repository.IUserRepository
+	DTO            dto.IAPIKeyDTO
+	UserDTO        dto.IUserDTO
+}
+
+func NewAPIKeyUseCase(
+	log *logrus.Logger,
+	viper *viper.Viper,
+	repository repository.IAPIKeyRepository,
+	userRepository repository.IUserRepository,
+	dto dto.IAPIKeyDTO,
+	userDTO dto.IUserDTO,
+) IAPIKeyUseCase {
+	return &APIKeyUseCase{
+		Log:            log,
+		Viper:          viper,
+		Repository:     repository,
+		UserRepository: userRepository,
+		DTO:            dto,
+		UserDTO:        userDTO,
+	}
+}
+
+func APIKeyUseCaseFactory(log *logrus.Logger, viper *viper.Viper) IAPIKeyUseCase {
+	apiKeyRepository := repository.APIKeyRepositoryFactory(log)
+	userRepository := repository.UserRepositoryFactory(log)
+	apiKeyDTO := dto.APIKeyDTOFactory(log)
+	userDTO := dto.UserDTOFactory(log)
+	return NewAPIKeyUseCase(log, viper, apiKeyRepository, userRepository, apiKeyDTO, userDTO)
+}
+
+func (a *APIKeyUseCase) FindAllPaginated(userID uuid.UUID, page int, pageSize int, search string) (*[]response.APIKeyResponse, int64, error) {
+	apiKeys, total, err := a.Repository.FindAllByUserPaginated(userID, page, pageSize, search)
+	if err != nil {
+		a.Log.Error("[APIKeyUseCase.FindAllPaginated] " + err.Error())
+		return nil, 0, err
+	}
+
+	return a.DTO.ConvertEntitiesToAPIKeyResponses(apiKeys), total, nil
+}
+
+// CreateAPIKey generates a key for the user. The key itself is only part of
+// this response, afterwards only its prefix is known.
+func (a *APIKeyUseCase) CreateAPIKey(userID uuid.UUID, twoFactorVerified bool, payload *request.APIKeyRequest) (*response.APIKeyResponse, error) {
+	user, err := a.UserRepository.FindById(userID)
+	if err != nil {
+		a.Log.Error("[APIKeyUseCase.CreateAPIKey] " + err.Error())
+		return nil, err
+	}
+
+	if user == nil {
+		a.Log.Warn("[APIKeyUseCase.CreateAPIKey] User not found")
+		return nil, ErrUserNotFound
+	}
+
+	granted := make(map[string]bool)
+	for _, permission := range a.UserDTO.ConvertEntityToUserResponse(user).Permissions {
+		granted[permission] = true
+	}
+
+	for _, scope := range payload.Scopes {
+		if !granted[scope] {
+			a.Log.Warn("[APIKeyUseCase.CreateAPIKey] Scope not granted to user " + scope)
+			return nil, ErrAPIKeyScopeNotGranted
+		}
+	}
+
+	if payload.ExpiresAt != nil && !payload.ExpiresAt.After(time.Now()) {
+		a.Log.Warn("[APIKeyUseCase.CreateAPIKey] Expiry in the past")
+		return nil, ErrAPIKeyExpiryInPast
+	}
+
+	secret, err := utils.GenerateRandomString(32)
+	if err != nil {
+		a.Log.Error("[APIKeyUseCase.CreateAPIKey] " + err.Error())
+		return nil, err
+	}
+
+	key := entity.API_KEY_PREFIX + secret
+
+	apiKey, err := a.Repository.CreateAPIKey(&entity.APIKey{
+		UserID:            user.ID,
+		Name:              payload.Name,
+		Prefix:            key[:apiKeyPrefixLength],
+		KeyHash:           utils.HashToken(key),
+		Scopes:            strings.Join(payload.Scopes, " "),
+		TwoFactorVerified: twoFactorVerified,
+		ExpiredAt:         payload.ExpiresAt,
+	})
+	if err != nil {
+		a.Log.Error("[APIKeyUseCase.CreateAPIKey] " + err.Error())
+		return nil, err
+	}
+
+	result := a.DTO.ConvertEntityToAPIKeyResponse(apiKey)
+	result.Key = key
+
+	return result, nil
+}
+
+func (a *APIKeyUseCase) RevokeAPIKey(userID uuid.UUID, id uuid.UUID) (bool, error) {
+	apiKey, err := a.Repository.FindByIdAndUser(id, userID)
+	if err != nil {
+		a.Log.Error("[APIKeyUseCase.RevokeAPIKey] " + err.Error())
+		return false, err
+	}
+
+	if apiKey == nil {
+		a.Log.Warn("[APIKeyUseCase.RevokeAPIKey] API key not found")
+		return false, nil
+	}
+
+	if err := a.Repository.RevokeAPIKey(apiKey.ID); err != nil {
+		a.Log.Error("[APIKeyUseCase.RevokeAPIKey] " + err.Error())
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Authenticate resolves an API key into the same claims an access token of its
+// owner carries. The permissions are those of the user today, narrowed down to
+// the scopes of the key, so a revoked role takes effect immediately.
+func (a *APIKeyUseCase) Authenticate(key string) (jwt.MapClaims, error) {
+	if !strings.HasPrefix(key, entity.API_KEY_PREFIX) {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	apiKey, err := a.Repository.FindByHash(utils.HashToken(key))
+	if err != nil {
+		a.Log.Error("[APIKeyUseCase.Authenticate] " + err.Error())
+		return nil, err
+	}
+
+	now := time.Now()
+
+	if apiKey == nil || !apiKey.IsUsable(now) {
+		a.Log.Warn("[APIKeyUseCase.Authenticate] API key invalid, expired or revoked")
+		return nil, ErrAPIKeyInvalid
+	}
+
+	user, err := a.UserRepository.FindById(apiKey.UserID)
+	if err != nil {
+		a.Log.Error("[APIKeyUseCase.Authenticate] " + err.Error())
+		return nil, err
+	}
+
+	if user == nil || user.Status != entity.USER_ACTIVE {
+		a.Log.Warn("[APIKeyUseCase.Authenticate] Owner of API key not found or not active")
+		return nil, ErrAPIKeyInvalid
+	}
+
+	if err := a.Repository.TouchLastUsed(apiKey.ID, now); err != nil {
+		a.Log.Error("[APIKeyUseCase.Authenticate] " + err.Error())
+		return nil, err
+	}
+
+	return apiKeyClaims(a.UserDTO.ConvertEntityToUserResponse(user), apiKey), nil
+}
+
+// apiKeyClaims builds the claims with the types a parsed JWT would have, so the
+// middlewares reading them cannot tell the two apart. A key with scopes carries
+// no roles, otherwise its roles would pass role checks its scopes do not cover.
+func apiKeyClaims(user *response.UserResponse, apiKey *entity.APIKey) jwt.MapClaims {
+	scopes := apiKey.ScopeList()
+
+	roles := make([]interface{}, 0)
+	if user.Roles != nil && len(scopes) == 0 {
+		for _, role := range *user.Roles {
+			roles = append(roles, map[string]interface{}{
+				"name":   role.Name,
+				"status": string(role.Status),
+			})
+		}
+	}
+
+	allowed := make(map[string]bool)
+	for _, scope := range scopes {
+		allowed[scope] = true
+	}
+
+	permissions := make([]interface{}, 0)
+	for _, permission := range user.Permissions {
+		if len(scopes) == 0 || allowed[permission] {
+			permissions = append(permissions, permission)
+		}
+	}
+
+	return jwt.MapClaims{
+		"id":           user.ID.String(),
+		"name":         user.Name,
+		"username":     user.Username,
+		"email":        user.Email,
+		"roles":        roles,
+		"permissions":  permissions,
+		"token_use":    utils.TokenUseAccess,
+		"mfa":          apiKey.TwoFactorVerified,
+		"mfa_required": user.TwoFactorRequired,
+		"api_key_id":   apiKey.ID.String(),
+	}
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CSVFileError is returned when an uploaded CSV cannot be processed at all, as
+// opposed to single rows being rejected.
+type CSVFileError struct {
+	Message string
+}
+
+func (e *CSVFileError) Error() string {
+	return e.Message
+}
+
+// csvRecord is a data row of an uploaded CSV, keyed by the lower cased column
+// names of the header. Error is set when the row does not match the header.
+type csvRecord struct {
+	RowNumber int
+	Fields    map[string]string
+	Error     string
+}
+
+// readCSVRecords reads a CSV with a header row that has at least the required
+// columns. Row numbers are the line numbers of the file, so they match what a
+// spreadsheet shows.
+func readCSVRecords(content []byte, required []string, maxRows int) ([]csvRecord, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, &CSVFileError{Message: "file is empty"}
+		}
+		return nil, &CSVFileError{Message: "file is not a valid CSV: " + err.Error()}
+	}
+
+	columns := make([]string, len(header))
+	present := make(map[string]bool)
+	for i, name := range header {
+		columns[i] = strings.ToLower(strings.TrimSpace(name))
+		present[columns[i]] = true
+	}
+
+	for _, name := range required {
+		if !present[name] {
+			return nil, &CSVFileError{Message: "file must have the columns " + strings.Join(required, ", ")}
+		}
+	}
+
+	records := []csvRecord{}
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, &CSVFileError{Message: "file is not a valid CSV: " + err.Error()}
+		}
+
+		if len(records) == maxRows {
+			return nil, &CSVFileError{Message: fmt.Sprintf("file has more than %d rows", maxRows)}
+		}
+
+		line, _ := reader.FieldPos(0)
+		record := csvRecord{RowNumber: line, Fields: make(map[string]string)}
+
+		if len(fields) != len(columns) {
+			record.Error = fmt.Sprintf("expected %d columns, got %d", len(columns), len(fields))
+		} else {
+			for i, value := range fields {
+				record.Fields[columns[i]] = strings.TrimSpace(value)
+			}
+		}
+
+		records = append(records, record)
+	}
+
+	if len(records) == 0 {
+		return nil, &CSVFileError{Message: "file has no rows"}
+	}
+
+	return records, nil
+}
@@ -0,0 +1,219 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/dto"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/repository"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// defaultGiftCodeImportMaxRows caps a CSV import when gift_codes.import.max_rows
+// is not set.
+const defaultGiftCodeImportMaxRows = 10000
+
+var ErrGiftCodeNotAvailable = errors.New("only available gift codes can be voided")
+
+type IGiftCodeUseCase interface {
+	FindAllPaginated(giftID uuid.UUID, status string, page int, pageSize int, search string) (*[]response.GiftCodeResponse, int64, error)
+	ImportCodes(giftID uuid.UUID, content []byte) (*response.GiftCodeImportResponse, error)
+	VoidCode(giftID uuid.UUID, id uuid.UUID) (*response.GiftCodeResponse, error)
+}
+
+type GiftCodeUseCase struct {
+	Log            *logrus.Logger
+	Viper          *viper.Viper
+	Repository     repository.IGiftCodeRepository
+	GiftRepository repository.IGiftRepository
+	DTO            dto.IGiftCodeDTO
+}
+
+func NewGiftCodeUseCase(
+	log *logrus.Logger,
+	viper *viper.Viper,
+	repository repository.IGiftCodeRepository,
+	giftRepository repository.IGiftRepository,
+	dto dto.IGiftCodeDTO,
+) IGiftCodeUseCase {
+	return &GiftCodeUseCase{
+		Log:            log,
+		Viper:          viper,
+		Repository:     repository,
+		GiftRepository: giftRepository,
+		DTO:            dto,
+	}
+}
+
+func GiftCodeUseCaseFactory(log *logrus.Logger, viper *viper.Viper) IGiftCodeUseCase {
+	giftCodeRepository := repository.GiftCodeRepositoryFactory(log)
+	giftRepository := repository.GiftRepositoryFactory(log)
+	giftCodeDTO := dto.GiftCodeDTOFactory(log)
+	return NewGiftCodeUseCase(log, viper, giftCodeRepository, giftRepository, giftCodeDTO)
+}
+
+func (g *GiftCodeUseCase) FindAllPaginated(giftID uuid.UUID, status string, page int, pageSize int, search string) (*[]response.GiftCodeResponse, int64, error) {
+	if err := g.ensureGiftExists(giftID); err != nil {
+		g.Log.Error("[GiftCodeUseCase.FindAllPaginated] " + err.Error())
+		return nil, 0, err
+	}
+
+	codeHash := ""
+	if search != "" {
+		codeCipher, err := utils.LoadCodeCipher(g.Viper)
+		if err != nil {
+			g.Log.Error("[GiftCodeUseCase.FindAllPaginated] " + err.Error())
+			return nil, 0, err
+		}
+		codeHash = codeCipher.Hash(search)
+	}
+
+	codes, total, err := g.Repository.FindAllByGiftPaginated(giftID, status, codeHash, page, pageSize)
+	if err != nil {
+		g.Log.Error("[GiftCodeUseCase.FindAllPaginated] " + err.Error())
+		return nil, 0, err
+	}
+
+	return g.DTO.ConvertEntitiesToGiftCodeResponses(codes), total, nil
+}
+
+// ImportCodes adds the codes of a supplier CSV with a code column to the stock
+// of the gift. Codes that are already known, also to other gifts, are skipped
+// so the same file can safely be imported twice.
+func (g *GiftCodeUseCase) ImportCodes(giftID uuid.UUID, content []byte) (*response.GiftCodeImportResponse, error) {
+	if err := g.ensureGiftExists(giftID); err != nil {
+		g.Log.Error("[GiftCodeUseCase.ImportCodes] " + err.Error())
+		return nil, err
+	}
+
+	maxRows := g.Viper.GetInt("gift_codes.import.max_rows")
+	if maxRows <= 0 {
+		maxRows = defaultGiftCodeImportMaxRows
+	}
+
+	records, err := readCSVRecords(content, []string{"code"}, maxRows)
+	if err != nil {
+		g.Log.Warn("[GiftCodeUseCase.ImportCodes] " + err.Error())
+		return nil, err
+	}
+
+	codeCipher, err := utils.LoadCodeCipher(g.Viper)
+	if err != nil {
+		g.Log.Error("[GiftCodeUseCase.ImportCodes] " + err.Error())
+		return nil, err
+	}
+
+	result := &response.GiftCodeImportResponse{
+		TotalRows: len(records),
+		Rejected:  []response.GiftCodeImportErrorResponse{},
+	}
+
+	seen := make(map[string]bool)
+	codes := []entity.GiftCode{}
+	for _, record := range records {
+		code := record.Fields["code"]
+
+		rejection := record.Error
+		switch {
+		case rejection != "":
+		case code == "":
+			rejection = "code is required"
+		case len(code) > 255:
+			rejection = "code must be at most 255 characters"
+		}
+
+		if rejection != "" {
+			result.Rejected = append(result.Rejected, response.GiftCodeImportErrorResponse{
+				RowNumber: record.RowNumber,
+				Error:     rejection,
+			})
+			continue
+		}
+
+		if seen[code] {
+			result.Duplicates++
+			continue
+		}
+		seen[code] = true
+
+		encrypted, err := codeCipher.Encrypt(code)
+		if err != nil {
+			g.Log.Error("[GiftCodeUseCase.ImportCodes] " + err.Error())
+			return nil, err
+		}
+
+		codes = append(codes, entity.GiftCode{
+			GiftID:        giftID,
+			CodeHash:      codeCipher.Hash(code),
+			CodeHint:      utils.CodeHint(code),
+			EncryptedCode: encrypted.Ciphertext,
+			EncryptedKey:  encrypted.WrappedKey,
+			KeyID:         encrypted.KeyID,
+			Status:        entity.GIFT_CODE_AVAILABLE,
+		})
+	}
+
+	if len(codes) > 0 {
+		imported, err := g.Repository.ImportCodes(codes)
+		if err != nil {
+			g.Log.Error("[GiftCodeUseCase.ImportCodes] " + err.Error())
+			return nil, err
+		}
+
+		result.Imported = imported
+		result.Duplicates += int64(len(codes)) - imported
+	}
+
+	return result, nil
+}
+
+// VoidCode withdraws an available code from the stock. It returns nil when the
+// code does not belong to the gift.
+func (g *GiftCodeUseCase) VoidCode(giftID uuid.UUID, id uuid.UUID) (*response.GiftCodeResponse, error) {
+	code, err := g.Repository.FindByIdAndGift(id, giftID)
+	if err != nil {
+		g.Log.Error("[GiftCodeUseCase.VoidCode] " + err.Error())
+		return nil, err
+	}
+
+	if code == nil {
+		g.Log.Warn("[GiftCodeUseCase.VoidCode] Gift code not found")
+		return nil, nil
+	}
+
+	voided, err := g.Repository.VoidCode(code.ID)
+	if err != nil {
+		g.Log.Error("[GiftCodeUseCase.VoidCode] " + err.Error())
+		return nil, err
+	}
+
+	if !voided {
+		g.Log.Warn("[GiftCodeUseCase.VoidCode] Gift code is " + string(code.Status))
+		return nil, ErrGiftCodeNotAvailable
+	}
+
+	code, err = g.Repository.FindByIdAndGift(id, giftID)
+	if err != nil {
+		g.Log.Error("[GiftCodeUseCase.VoidCode] " + err.Error())
+		return nil, err
+	}
+
+	return g.DTO.ConvertEntityToGiftCodeResponse(code), nil
+}
+
+func (g *GiftCodeUseCase) ensureGiftExists(giftID uuid.UUID) error {
+	gift, err := g.GiftRepository.FindById(giftID)
+	if err != nil {
+		return err
+	}
+
+	if gift == nil {
+		return repository.ErrGiftNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/dto"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/request"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/repository"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+var (
+	ErrGiftRedeemCodeTaken = errors.New("redeem code already used by another gift")
+	ErrGiftInvalidWindow   = errors.New("expired_at must be after available_from")
+)
+
+type IGiftUseCase interface {
+	FindAllPaginated(page int, pageSize int, search string, sort string, onlyAvailable bool) (*[]response.GiftResponse, int64, error)
+	FindByID(id uuid.UUID, onlyAvailable bool) (*response.GiftResponse, error)
+	CreateGift(payload *request.GiftRequest) (*response.GiftResponse, error)
+	UpdateGift(id uuid.UUID, payload *request.GiftRequest) (*response.GiftResponse, error)
+	DeleteGift(id uuid.UUID) (bool, error)
+}
+
+type GiftUseCase struct {
+	Log        *logrus.Logger
+	Repository repository.IGiftRepository
+	DTO        dto.IGiftDTO
+}
+
+func NewGiftUseCase(
+	log *logrus.Logger,
+	repository repository.IGiftRepository,
+	dto dto.IGiftDTO,
+) IGiftUseCase {
+	return &GiftUseCase{
+		Log:        log,
+		Repository: repository,
+		DTO:        dto,
+	}
+}
+
+func GiftUseCaseFactory(log *logrus.Logger, viper *viper.Viper) IGiftUseCase {
+	repository := repository.GiftRepositoryFactory(log)
+	dto := dto.GiftDTOFactory(log, viper)
+	return NewGiftUseCase(log, repository, dto)
+}
+
+// FindAllPaginated lists the gifts, only those that can be redeemed right now
+// when onlyAvailable is set.
+func (g *GiftUseCase) FindAllPaginated(page int, pageSize int, search string, sort string, onlyAvailable bool) (*[]response.GiftResponse, int64, error) {
+	var availableAt *time.Time
+	if onlyAvailable {
+		now := time.Now().UTC()
+		availableAt = &now
+	}
+
+	gifts, total, err := g.Repository.FindAllPaginated(page, pageSize, search, sort, availableAt)
+	if err != nil {
+		g.Log.Error("[GiftUseCase.FindAllPaginated] " + err.Error())
+		return nil, 0, err
+	}
+
+	return g.DTO.ConvertEntitiesToGiftResponses(gifts), total, nil
+}
+
+// FindByID returns a gift. When onlyAvailable is set, a gift outside of its
+// availability window is reported as not found.
+func (g *GiftUseCase) FindByID(id uuid.UUID, onlyAvailable bool) (*response.GiftResponse, error) {
+	gift, err := g.Repository.FindById(id)
+	if err != nil {
+		g.Log.Error("[GiftUseCase.FindByID] " + err.Error())
+		return nil, err
+	}
+
+	if gift == nil || (onlyAvailable && !gift.IsAvailable(time.Now())) {
+		g.Log.Warn("[GiftUseCase.FindByID] Gift not found")
+		return nil, nil
+	}
+
+	return g.DTO.ConvertEntityToGiftResponse(gift), nil
+}
+
+func (g *GiftUseCase) CreateGift(payload *request.GiftRequest) (*response.GiftResponse, error) {
+	if !isValidGiftWindow(payload) {
+		g.Log.Warn("[GiftUseCase.CreateGift] " + ErrGiftInvalidWindow.Error())
+		return nil, ErrGiftInvalidWindow
+	}
+
+	existing, err := g.Repository.FindByRedeemCode(payload.RedeemCode)
+	if err != nil {
+		g.Log.Error("[GiftUseCase.CreateGift] " + err.Error())
+		return nil, err
+	}
+
+	if existing != nil {
+		g.Log.Warn("[GiftUseCase.CreateGift] Redeem code already used")
+		return nil, ErrGiftRedeemCodeTaken
+	}
+
+	gift, err := g.Repository.CreateGift(&entity.Gift{
+		RedeemCode:    payload.RedeemCode,
+		Name:          payload.Name,
+		Description:   payload.Description,
+		Price:         payload.Price,
+		AvailableFrom: toUTC(payload.AvailableFrom),
+		ExpiredAt:     toUTC(payload.ExpiredAt),
+
+		MaxPerUser:        payload.MaxPerUser,
+		MaxPerUserDaily:   payload.MaxPerUserDaily,
+		MaxPerUserWeekly:  payload.MaxPerUserWeekly,
+		MaxPerUserMonthly: payload.MaxPerUserMonthly,
+		MaxDaily:          payload.MaxDaily,
+	})
+	if err != nil {
+		g.Log.Error("[GiftUseCase.CreateGift] " + err.Error())
+		return nil, err
+	}
+
+	return g.DTO.ConvertEntityToGiftResponse(gift), nil
+}
+
+func (g *GiftUseCase) UpdateGift(id uuid.UUID, payload *request.GiftRequest) (*response.GiftResponse, error) {
+	if !isValidGiftWindow(payload) {
+		g.Log.Warn("[GiftUseCase.UpdateGift] " + ErrGiftInvalidWindow.Error())
+		return nil, ErrGiftInvalidWindow
+	}
+
+	gift, err := g.Repository.FindById(id)
+	if err != nil {
+		g.Log.Error("[GiftUseCase.UpdateGift] " + err.Error())
+		return nil, err
+	}
+
+	if gift == nil {
+		g.Log.Warn("[GiftUseCase.UpdateGift] Gift not found")
+		return nil, nil
+	}
+
+	existing, err := g.Repository.FindByRedeemCode(payload.RedeemCode)
+	if err != nil {
+		g.Log.Error("[GiftUseCase.UpdateGift] " + err.Error())
+		return nil, err
+	}
+
+	if existing != nil && existing.ID != gift.ID {
+		g.Log.Warn("[GiftUseCase.UpdateGift] Redeem code already used")
+		return nil, ErrGiftRedeemCodeTaken
+	}
+
+	gift.RedeemCode = payload.RedeemCode
+	gift.Name = payload.Name
+	gift.Description = payload.Description
+	gift.Price = payload.Price
+	gift.AvailableFrom = toUTC(payload.AvailableFrom)
+	gift.ExpiredAt = toUTC(payload.ExpiredAt)
+	gift.MaxPerUser = payload.MaxPerUser
+	gift.MaxPerUserDaily = payload.MaxPerUserDaily
+	gift.MaxPerUserWeekly = payload.MaxPerUserWeekly
+	gift.MaxPerUserMonthly = payload.MaxPerUserMonthly
+	gift.MaxDaily = payload.MaxDaily
+
+	gift, err = g.Repository.UpdateGift(gift)
+	if err != nil {
+		g.Log.Error("[GiftUseCase.UpdateGift] " + err.Error())
+		return nil, err
+	}
+
+	return g.DTO.ConvertEntityToGiftResponse(gift), nil
+}
+
+func (g *GiftUseCase) DeleteGift(id uuid.UUID) (bool, error) {
+	gift, err := g.Repository.FindById(id)
+	if err != nil {
+		g.Log.Error("[GiftUseCase.DeleteGift] " + err.Error())
+		return false, err
+	}
+
+	if gift == nil {
+		g.Log.Warn("[GiftUseCase.DeleteGift] Gift not found")
+		return false, nil
+	}
+
+	if err := g.Repository.DeleteGift(id); err != nil {
+		g.Log.Error("[GiftUseCase.DeleteGift] " + err.Error())
+		return false, err
+	}
+
+	return true, nil
+}
+
+// isValidGiftWindow reports whether the availability window of the gift ends
+// after it starts.
+func isValidGiftWindow(payload *request.GiftRequest) bool {
+	return payload.AvailableFrom == nil || payload.ExpiredAt == nil || payload.ExpiredAt.After(*payload.AvailableFrom)
+}
+
+// toUTC converts an optional timestamp for storage.
+func toUTC(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	converted := t.UTC()
+	return &converted
+}
@@ -0,0 +1,538 @@
+package usecase
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/dto"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/request"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/repository"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const defaultAuthorizationCodeTTL = 10 * time.Minute
+
+var (
+	ErrOAuthUnknownScope          = errors.New("scope does not match any permission")
+	ErrOAuthRedirectURIRequired   = errors.New("authorization_code clients need at least one redirect uri")
+	ErrOAuthClientMustBeConfident = errors.New("client_credentials clients must be confidential")
+)
+
+// OAuthError is one of the error codes defined by RFC 6749, rendered by the
+// OAuth2 endpoints as {"error", "error_description"}.
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+type IOAuthUseCase interface {
+	Authorize(userID uuid.UUID, payload *request.OAuthAuthorizeRequest) (*response.OAuthAuthorizeResponse, error)
+	Token(payload *request.OAuthTokenRequest) (*response.OAuthTokenResponse, error)
+	Introspect(payload *request.OAuthIntrospectRequest) (*response.OAuthIntrospectionResponse, error)
+	FindAllClientsPaginated(page int, pageSize int, search string) (*[]response.OAuthClientResponse, int64, error)
+	FindClientByID(id uuid.UUID) (*response.OAuthClientResponse, error)
+	CreateClient(payload *request.OAuthClientRequest) (*response.OAuthClientResponse, error)
+	DeleteClient(id uuid.UUID) (bool, error)
+}
+
+type OAuthUseCase struct {
+	Log                  *logrus.Logger
+	Viper                *viper.Viper
+	Repository           repository.IOAuthRepository
+	UserRepository       repository.IUserRepository
+	TokenRepository      repository.ITokenRepository
+	PermissionRepository repository.IPermissionRepository
+	DTO                  dto.IOAuthDTO
+	UserDTO              dto.IUserDTO
+}
+
+func NewOAuthUseCase(
+	log *logrus.Logger,
+	viper *viper.Viper,
+	repository repository.IOAuthRepository,
+	userRepository repository.IUserRepository,
+	tokenRepository repository.ITokenRepository,
+	permissionRepository repository.IPermissionRepository,
+	dto dto.IOAuthDTO,
+	userDTO dto.IUserDTO,
+) IOAuthUseCase {
+	return &OAuthUseCase{
+		Log:                  log,
+		Viper:                viper,
+		Repository:           repository,
+		UserRepository:       userRepository,
+		TokenRepository:      tokenRepository,
+		PermissionRepository: permissionRepository,
+		DTO:                  dto,
+		UserDTO:              userDTO,
+	}
+}
+
+func OAuthUseCaseFactory(log *logrus.Logger, viper *viper.Viper) IOAuthUseCase {
+	oauthRepository := repository.OAuthRepositoryFactory(log)
+	userRepository := repository.UserRepositoryFactory(log)
+	tokenRepository := repository.TokenRepositoryFactory(log)
+	permissionRepository := repository.PermissionRepositoryFactory(log)
+	oauthDTO := dto.OAuthDTOFactory(log)
+	userDTO := dto.UserDTOFactory(log)
+	return NewOAuthUseCase(log, viper, oauthRepository, userRepository, tokenRepository, permissionRepository, oauthDTO, userDTO)
+}
+
+// Authorize issues an authorization code once the signed in user approved the
+// client's request. The granted scopes are the requested scopes the client is
+// registered for and the user actually holds as permissions.
+func (o *OAuthUseCase) Authorize(userID uuid.UUID, payload *request.OAuthAuthorizeRequest) (*response.OAuthAuthorizeResponse, error) {
+	client, err := o.Repository.FindClientByClientID(payload.ClientID)
+	if err != nil {
+		o.Log.Error("[OAuthUseCase.Authorize] " + err.Error())
+		return nil, err
+	}
+
+	if client == nil {
+		o.Log.Warn("[OAuthUseCase.Authorize] OAuth client not found")
+		return nil, &OAuthError{Code: "invalid_client", Description: "unknown client"}
+	}
+
+	if !client.AllowsGrantType(entity.OAUTH_GRANT_AUTHORIZATION_CODE) {
+		return nil, &OAuthError{Code: "unauthorized_client", Description: "client may not use the authorization code grant"}
+	}
+
+	if !client.HasRedirectURI(payload.RedirectURI) {
+		return nil, &OAuthError{Code: "invalid_request", Description: "redirect_uri is not registered for the client"}
+	}
+
+	requested, oauthErr := resolveScopes(payload.Scope, client)
+	if oauthErr != nil {
+		return nil, oauthErr
+	}
+
+	user, err := o.UserRepository.FindById(userID)
+	if err != nil {
+		o.Log.Error("[OAuthUseCase.Authorize] " + err.Error())
+		return nil, err
+	}
+
+	if user == nil {
+		o.Log.Warn("[OAuthUseCase.Authorize] User not found")
+		return nil, &OAuthError{Code: "access_denied", Description: "user not found"}
+	}
+
+	granted := intersectScopes(requested, o.UserDTO.ConvertEntityToUserResponse(user).Permissions)
+	if len(granted) == 0 {
+		return nil, &OAuthError{Code: "access_denied", Description: "the user holds none of the requested scopes"}
+	}
+
+	code, err := utils.GenerateRandomString(32)
+	if err != nil {
+		o.Log.Error("[OAuthUseCase.Authorize] " + err.Error())
+		return nil, err
+	}
+
+	ttl := time.Duration(o.Viper.GetInt("oauth.authorization_code_ttl")) * time.Minute
+	if ttl <= 0 {
+		ttl = defaultAuthorizationCodeTTL
+	}
+
+	if err := o.Repository.CreateAuthorizationCode(&entity.OAuthAuthorizationCode{
+		CodeHash:            utils.HashToken(code),
+		OAuthClientID:       client.ID,
+		UserID:              user.ID,
+		RedirectURI:         payload.RedirectURI,
+		Scopes:              strings.Join(granted, " "),
+		CodeChallenge:       payload.CodeChallenge,
+		CodeChallengeMethod: payload.CodeChallengeMethod,
+		ExpiredAt:           time.Now().Add(ttl),
+	}); err != nil {
+		o.Log.Error("[OAuthUseCase.Authorize] " + err.Error())
+		return nil, err
+	}
+
+	redirectURI, err := url.Parse(payload.RedirectURI)
+	if err != nil {
+		return nil, &OAuthError{Code: "invalid_request", Description: "redirect_uri is not a valid url"}
+	}
+
+	query := redirectURI.Query()
+	query.Set("code", code)
+	if payload.State != "" {
+		query.Set("state", payload.State)
+	}
+	redirectURI.RawQuery = query.Encode()
+
+	return &response.OAuthAuthorizeResponse{
+		RedirectURI: redirectURI.String(),
+		Code:        code,
+		State:       payload.State,
+		ExpiresIn:   int64(ttl.Seconds()),
+	}, nil
+}
+
+func (o *OAuthUseCase) Token(payload *request.OAuthTokenRequest) (*response.OAuthTokenResponse, error) {
+	client, err := o.authenticateClient(payload.ClientID, payload.ClientSecret)
+	if err != nil {
+		o.Log.Error("[OAuthUseCase.Token] " + err.Error())
+		return nil, err
+	}
+
+	switch payload.GrantType {
+	case entity.OAUTH_GRANT_AUTHORIZATION_CODE:
+		return o.exchangeAuthorizationCode(client, payload)
+	case entity.OAUTH_GRANT_CLIENT_CREDENTIALS:
+		return o.issueClientCredentials(client, payload)
+	case "":
+		return nil, &OAuthError{Code: "invalid_request", Description: "grant_type is required"}
+	default:
+		return nil, &OAuthError{Code: "unsupported_grant_type", Description: "grant_type " + payload.GrantType + " is not supported"}
+	}
+}
+
+// Introspect reports whether an access token is currently active, following
+// RFC 7662. Only confidential clients may introspect tokens.
+func (o *OAuthUseCase) Introspect(payload *request.OAuthIntrospectRequest) (*response.OAuthIntrospectionResponse, error) {
+	client, err := o.authenticateClient(payload.ClientID, payload.ClientSecret)
+	if err != nil {
+		o.Log.Error("[OAuthUseCase.Introspect] " + err.Error())
+		return nil, err
+	}
+
+	if !client.Confidential {
+		return nil, &OAuthError{Code: "unauthorized_client", Description: "only confidential clients may introspect tokens"}
+	}
+
+	if payload.Token == "" {
+		return nil, &OAuthError{Code: "invalid_request", Description: "token is required"}
+	}
+
+	keys, err := utils.LoadKeySet(o.Viper)
+	if err != nil {
+		o.Log.Error("[OAuthUseCase.Introspect] " + err.Error())
+		return nil, err
+	}
+
+	token, err := jwt.Parse(payload.Token, keys.Keyfunc)
+	if err != nil || !token.Valid {
+		return &response.OAuthIntrospectionResponse{Active: false}, nil
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return &response.OAuthIntrospectionResponse{Active: false}, nil
+	}
+
+	jti, _ := claims["jti"].(string)
+	if jti != "" {
+		revoked, err := o.TokenRepository.IsAccessTokenRevoked(jti)
+		if err != nil {
+			o.Log.Error("[OAuthUseCase.Introspect] " + err.Error())
+			return nil, err
+		}
+
+		if revoked {
+			return &response.OAuthIntrospectionResponse{Active: false}, nil
+		}
+	}
+
+	result := &response.OAuthIntrospectionResponse{
+		Active:    true,
+		TokenType: "Bearer",
+		Jti:       jti,
+	}
+
+	result.Scope, _ = claims["scope"].(string)
+	result.ClientID, _ = claims["client_id"].(string)
+	result.Username, _ = claims["username"].(string)
+	result.Sub, _ = claims["sub"].(string)
+	if result.Sub == "" {
+		result.Sub, _ = claims["id"].(string)
+	}
+
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		result.Exp = exp.Unix()
+	}
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		result.Iat = iat.Unix()
+	}
+
+	return result, nil
+}
+
+func (o *OAuthUseCase) FindAllClientsPaginated(page int, pageSize int, search string) (*[]response.OAuthClientResponse, int64, error) {
+	clients, total, err := o.Repository.FindAllClientsPaginated(page, pageSize, search)
+	if err != nil {
+		o.Log.Error("[OAuthUseCase.FindAllClientsPaginated] " + err.Error())
+		return nil, 0, err
+	}
+
+	return o.DTO.ConvertEntitiesToOAuthClientResponses(clients), total, nil
+}
+
+func (o *OAuthUseCase) FindClientByID(id uuid.UUID) (*response.OAuthClientResponse, error) {
+	client, err := o.Repository.FindClientById(id)
+	if err != nil {
+		o.Log.Error("[OAuthUseCase.FindClientByID] " + err.Error())
+		return nil, err
+	}
+
+	if client == nil {
+		o.Log.Warn("[OAuthUseCase.FindClientByID] OAuth client not found")
+		return nil, nil
+	}
+
+	return o.DTO.ConvertEntityToOAuthClientResponse(client), nil
+}
+
+// CreateClient registers a client. The generated secret of a confidential
+// client is only part of this response, afterwards just its hash is known.
+func (o *OAuthUseCase) CreateClient(payload *request.OAuthClientRequest) (*response.OAuthClientResponse, error) {
+	permissions, err := o.PermissionRepository.GetAllPermissions()
+	if err != nil {
+		o.Log.Error("[OAuthUseCase.CreateClient] " + err.Error())
+		return nil, err
+	}
+
+	known := make(map[string]bool)
+	for _, permission := range *permissions {
+		known[permission.Name] = true
+	}
+
+	for _, scope := range payload.Scopes {
+		if !known[scope] {
+			o.Log.Warn("[OAuthUseCase.CreateClient] Unknown scope " + scope)
+			return nil, ErrOAuthUnknownScope
+		}
+	}
+
+	client := &entity.OAuthClient{
+		Name:         payload.Name,
+		RedirectURIs: strings.Join(payload.RedirectURIs, " "),
+		Scopes:       strings.Join(payload.Scopes, " "),
+		GrantTypes:   strings.Join(payload.GrantTypes, " "),
+		Confidential: payload.Confidential,
+	}
+
+	if client.AllowsGrantType(entity.OAUTH_GRANT_AUTHORIZATION_CODE) && len(payload.RedirectURIs) == 0 {
+		return nil, ErrOAuthRedirectURIRequired
+	}
+
+	if client.AllowsGrantType(entity.OAUTH_GRANT_CLIENT_CREDENTIALS) && !client.Confidential {
+		return nil, ErrOAuthClientMustBeConfident
+	}
+
+	client.ClientID, err = utils.GenerateRandomString(16)
+	if err != nil {
+		o.Log.Error("[OAuthUseCase.CreateClient] " + err.Error())
+		return nil, err
+	}
+
+	var secret string
+	if client.Confidential {
+		secret, err = utils.GenerateRandomString(32)
+		if err != nil {
+			o.Log.Error("[OAuthUseCase.CreateClient] " + err.Error())
+			return nil, err
+		}
+		client.SecretHash = utils.HashToken(secret)
+	}
+
+	client, err = o.Repository.CreateClient(client)
+	if err != nil {
+		o.Log.Error("[OAuthUseCase.CreateClient] " + err.Error())
+		return nil, err
+	}
+
+	result := o.DTO.ConvertEntityToOAuthClientResponse(client)
+	result.ClientSecret = secret
+
+	return result, nil
+}
+
+func (o *OAuthUseCase) DeleteClient(id uuid.UUID) (bool, error) {
+	client, err := o.Repository.FindClientById(id)
+	if err != nil {
+		o.Log.Error("[OAuthUseCase.DeleteClient] " + err.Error())
+		return false, err
+	}
+
+	if client == nil {
+		o.Log.Warn("[OAuthUseCase.DeleteClient] OAuth client not found")
+		return false, nil
+	}
+
+	if err := o.Repository.DeleteClient(id); err != nil {
+		o.Log.Error("[OAuthUseCase.DeleteClient] " + err.Error())
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (o *OAuthUseCase) exchangeAuthorizationCode(client *entity.OAuthClient, payload *request.OAuthTokenRequest) (*response.OAuthTokenResponse, error) {
+	if !client.AllowsGrantType(entity.OAUTH_GRANT_AUTHORIZATION_CODE) {
+		return nil, &OAuthError{Code: "unauthorized_client", Description: "client may not use the authorization code grant"}
+	}
+
+	if payload.Code == "" || payload.RedirectURI == "" || payload.CodeVerifier == "" {
+		return nil, &OAuthError{Code: "invalid_request", Description: "code, redirect_uri and code_verifier are required"}
+	}
+
+	jti := uuid.New().String()
+
+	code, err := o.Repository.ConsumeAuthorizationCode(utils.HashToken(payload.Code), client.ID, payload.RedirectURI, codeChallenge(payload.CodeVerifier), jti)
+	if err != nil {
+		o.Log.Error("[OAuthUseCase.exchangeAuthorizationCode] " + err.Error())
+		switch {
+		case errors.Is(err, repository.ErrAuthorizationCodeReused):
+			// a replayed code means it leaked, so the token it was exchanged for is revoked
+			if code.AccessTokenJTI != "" {
+				if err := o.TokenRepository.RevokeAccessToken(code.AccessTokenJTI, code.UserID, time.Now().Add(utils.AccessTokenTTL(o.Viper))); err != nil {
+					o.Log.Error("[OAuthUseCase.exchangeAuthorizationCode] " + err.Error())
+					return nil, err
+				}
+			}
+			return nil, &OAuthError{Code: "invalid_grant", Description: err.Error()}
+		case errors.Is(err, repository.ErrAuthorizationCodeInvalid),
+			errors.Is(err, repository.ErrRedirectURIMismatch),
+			errors.Is(err, repository.ErrCodeChallengeMismatch):
+			return nil, &OAuthError{Code: "invalid_grant", Description: err.Error()}
+		default:
+			return nil, err
+		}
+	}
+
+	user, err := o.UserRepository.FindById(code.UserID)
+	if err != nil {
+		o.Log.Error("[OAuthUseCase.exchangeAuthorizationCode] " + err.Error())
+		return nil, err
+	}
+
+	if user == nil || user.Status != entity.USER_ACTIVE {
+		return nil, &OAuthError{Code: "invalid_grant", Description: "the user is no longer active"}
+	}
+
+	// drop scopes the user lost since approving the request
+	scopes := intersectScopes(code.ScopeList(), o.UserDTO.ConvertEntityToUserResponse(user).Permissions)
+
+	return o.issueToken(map[string]interface{}{
+		"jti":         jti,
+		"sub":         user.ID.String(),
+		"id":          user.ID,
+		"name":        user.Name,
+		"username":    user.Username,
+		"email":       user.Email,
+		"roles":       []map[string]interface{}{},
+		"permissions": scopes,
+		"scope":       strings.Join(scopes, " "),
+		"client_id":   client.ClientID,
+	})
+}
+
+func (o *OAuthUseCase) issueClientCredentials(client *entity.OAuthClient, payload *request.OAuthTokenRequest) (*response.OAuthTokenResponse, error) {
+	if !client.Confidential || !client.AllowsGrantType(entity.OAUTH_GRANT_CLIENT_CREDENTIALS) {
+		return nil, &OAuthError{Code: "unauthorized_client", Description: "client may not use the client credentials grant"}
+	}
+
+	scopes, oauthErr := resolveScopes(payload.Scope, client)
+	if oauthErr != nil {
+		return nil, oauthErr
+	}
+
+	return o.issueToken(map[string]interface{}{
+		"sub":         client.ClientID,
+		"roles":       []map[string]interface{}{},
+		"permissions": scopes,
+		"scope":       strings.Join(scopes, " "),
+		"client_id":   client.ClientID,
+	})
+}
+
+func (o *OAuthUseCase) issueToken(claims map[string]interface{}) (*response.OAuthTokenResponse, error) {
+	token, err := utils.GenerateTokenForOAuth2(&claims)
+	if err != nil {
+		o.Log.Error("[OAuthUseCase.issueToken] " + err.Error())
+		return nil, err
+	}
+
+	scope, _ := claims["scope"].(string)
+
+	return &response.OAuthTokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(utils.AccessTokenTTL(o.Viper).Seconds()),
+		Scope:       scope,
+	}, nil
+}
+
+// authenticateClient looks the client up and checks its secret. Public clients
+// have no secret and rely on PKCE instead.
+func (o *OAuthUseCase) authenticateClient(clientID string, clientSecret string) (*entity.OAuthClient, error) {
+	if clientID == "" {
+		return nil, &OAuthError{Code: "invalid_client", Description: "client authentication failed"}
+	}
+
+	client, err := o.Repository.FindClientByClientID(clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	if client == nil {
+		return nil, &OAuthError{Code: "invalid_client", Description: "client authentication failed"}
+	}
+
+	if client.Confidential && subtle.ConstantTimeCompare([]byte(utils.HashToken(clientSecret)), []byte(client.SecretHash)) != 1 {
+		return nil, &OAuthError{Code: "invalid_client", Description: "client authentication failed"}
+	}
+
+	return client, nil
+}
+
+// resolveScopes parses a space separated scope parameter, defaulting to every
+// scope of the client, and rejects scopes the client is not registered for.
+func resolveScopes(scope string, client *entity.OAuthClient) ([]string, *OAuthError) {
+	requested := strings.Fields(scope)
+	if len(requested) == 0 {
+		return client.ScopeList(), nil
+	}
+
+	if len(intersectScopes(requested, client.ScopeList())) != len(requested) {
+		return nil, &OAuthError{Code: "invalid_scope", Description: "the client is not allowed to request every given scope"}
+	}
+
+	return requested, nil
+}
+
+func intersectScopes(scopes []string, allowed []string) []string {
+	set := make(map[string]bool)
+	for _, scope := range allowed {
+		set[scope] = true
+	}
+
+	result := []string{}
+	for _, scope := range scopes {
+		if set[scope] {
+			result = append(result, scope)
+			delete(set, scope)
+		}
+	}
+	return result
+}
+
+// codeChallenge returns the S256 PKCE challenge of a code verifier.
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,353 @@
+package usecase
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/dto"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/request"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/repository"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// defaultPointBatchMaxRows caps a CSV upload when points.batch.max_rows is not set.
+const defaultPointBatchMaxRows = 5000
+
+var (
+	ErrPointBatchKeyReused   = errors.New("batch key was already used for a different file")
+	ErrPointReasonRequired   = errors.New("reason is required")
+	ErrCannotAdjustOwnPoints = errors.New("you cannot grant or deduct your own points")
+)
+
+type IPointUseCase interface {
+	FindBalance(userID uuid.UUID) (*response.PointBalanceResponse, error)
+	FindHistoryPaginated(userID uuid.UUID, page int, pageSize int) (*[]response.PointLedgerResponse, int64, error)
+	FindUserBalance(userID uuid.UUID) (*response.PointBalanceResponse, error)
+	FindUserHistoryPaginated(userID uuid.UUID, page int, pageSize int) (*[]response.PointLedgerResponse, int64, error)
+	AdjustPoints(actorID uuid.UUID, userID uuid.UUID, payload *request.PointAdjustmentRequest) (*response.PointLedgerResponse, error)
+	FindAllBatchesPaginated(page int, pageSize int, search string) (*[]response.PointBatchResponse, int64, error)
+	FindBatchByID(id uuid.UUID) (*response.PointBatchResponse, error)
+	UploadBatch(actorID uuid.UUID, payload *request.PointBatchRequest, fileName string, content []byte) (*response.PointBatchResponse, bool, error)
+}
+
+type PointUseCase struct {
+	Log            *logrus.Logger
+	Viper          *viper.Viper
+	Repository     repository.IPointRepository
+	UserRepository repository.IUserRepository
+	DTO            dto.IPointDTO
+}
+
+func NewPointUseCase(
+	log *logrus.Logger,
+	viper *viper.Viper,
+	repository repository.IPointRepository,
+	userRepository repository.IUserRepository,
+	dto dto.IPointDTO,
+) IPointUseCase {
+	return &PointUseCase{
+		Log:            log,
+		Viper:          viper,
+		Repository:     repository,
+		UserRepository: userRepository,
+		DTO:            dto,
+	}
+}
+
+func PointUseCaseFactory(log *logrus.Logger, viper *viper.Viper) IPointUseCase {
+	pointRepository := repository.PointRepositoryFactory(log)
+	userRepository := repository.UserRepositoryFactory(log)
+	pointDTO := dto.PointDTOFactory(log)
+	return NewPointUseCase(log, viper, pointRepository, userRepository, pointDTO)
+}
+
+func (p *PointUseCase) FindBalance(userID uuid.UUID) (*response.PointBalanceResponse, error) {
+	wallet, err := p.Repository.FindWalletByUserID(userID)
+	if err != nil {
+		p.Log.Error("[PointUseCase.FindBalance] " + err.Error())
+		return nil, err
+	}
+
+	return p.DTO.ConvertEntityToPointBalanceResponse(userID, wallet), nil
+}
+
+func (p *PointUseCase) FindHistoryPaginated(userID uuid.UUID, page int, pageSize int) (*[]response.PointLedgerResponse, int64, error) {
+	entries, total, err := p.Repository.FindLedgerByUserPaginated(userID, page, pageSize)
+	if err != nil {
+		p.Log.Error("[PointUseCase.FindHistoryPaginated] " + err.Error())
+		return nil, 0, err
+	}
+
+	return p.DTO.ConvertEntitiesToPointLedgerResponses(entries), total, nil
+}
+
+// FindUserBalance is FindBalance for admins, failing for unknown users instead
+// of reporting an empty wallet.
+func (p *PointUseCase) FindUserBalance(userID uuid.UUID) (*response.PointBalanceResponse, error) {
+	if err := p.ensureUserExists(userID); err != nil {
+		p.Log.Error("[PointUseCase.FindUserBalance] " + err.Error())
+		return nil, err
+	}
+
+	return p.FindBalance(userID)
+}
+
+func (p *PointUseCase) FindUserHistoryPaginated(userID uuid.UUID, page int, pageSize int) (*[]response.PointLedgerResponse, int64, error) {
+	if err := p.ensureUserExists(userID); err != nil {
+		p.Log.Error("[PointUseCase.FindUserHistoryPaginated] " + err.Error())
+		return nil, 0, err
+	}
+
+	return p.FindHistoryPaginated(userID, page, pageSize)
+}
+
+// AdjustPoints grants or deducts points on behalf of an admin. A deduction
+// never takes the balance below zero, and admins cannot adjust their own
+// wallet.
+func (p *PointUseCase) AdjustPoints(actorID uuid.UUID, userID uuid.UUID, payload *request.PointAdjustmentRequest) (*response.PointLedgerResponse, error) {
+	if actorID == userID {
+		p.Log.Warn("[PointUseCase.AdjustPoints] User tried to adjust own points")
+		return nil, ErrCannotAdjustOwnPoints
+	}
+
+	reason := strings.TrimSpace(payload.Reason)
+	if reason == "" {
+		p.Log.Warn("[PointUseCase.AdjustPoints] Blank reason")
+		return nil, ErrPointReasonRequired
+	}
+
+	if err := p.ensureUserExists(userID); err != nil {
+		p.Log.Error("[PointUseCase.AdjustPoints] " + err.Error())
+		return nil, err
+	}
+
+	entry := &entity.PointLedger{
+		UserID:  userID,
+		Type:    entity.PointEntryType(payload.Type),
+		Amount:  payload.Amount,
+		Reason:  reason,
+		ActorID: &actorID,
+	}
+	if entry.Type == entity.POINT_DEBIT {
+		entry.Amount = -payload.Amount
+	}
+
+	entry, err := p.Repository.AddEntry(entry)
+	if err != nil {
+		p.Log.Error("[PointUseCase.AdjustPoints] " + err.Error())
+		return nil, err
+	}
+
+	return p.DTO.ConvertEntityToPointLedgerResponse(entry), nil
+}
+
+func (p *PointUseCase) FindAllBatchesPaginated(page int, pageSize int, search string) (*[]response.PointBatchResponse, int64, error) {
+	batches, total, err := p.Repository.FindAllBatchesPaginated(page, pageSize, search)
+	if err != nil {
+		p.Log.Error("[PointUseCase.FindAllBatchesPaginated] " + err.Error())
+		return nil, 0, err
+	}
+
+	return p.DTO.ConvertEntitiesToPointBatchResponses(batches), total, nil
+}
+
+func (p *PointUseCase) FindBatchByID(id uuid.UUID) (*response.PointBatchResponse, error) {
+	batch, err := p.Repository.FindBatchById(id)
+	if err != nil {
+		p.Log.Error("[PointUseCase.FindBatchByID] " + err.Error())
+		return nil, err
+	}
+
+	if batch == nil {
+		return nil, nil
+	}
+
+	return p.DTO.ConvertEntityToPointBatchResponse(batch), nil
+}
+
+// UploadBatch credits the users listed in a CSV with the columns email and
+// amount, and optionally reason. The batch key makes the upload safe to retry:
+// the same file under the same key returns the report of the first upload, or
+// processes only the rows an interrupted upload did not get to. The returned
+// bool is false when no new batch was created.
+func (p *PointUseCase) UploadBatch(actorID uuid.UUID, payload *request.PointBatchRequest, fileName string, content []byte) (*response.PointBatchResponse, bool, error) {
+	reason := strings.TrimSpace(payload.Reason)
+	if reason == "" {
+		p.Log.Warn("[PointUseCase.UploadBatch] Blank reason")
+		return nil, false, ErrPointReasonRequired
+	}
+
+	maxRows := p.Viper.GetInt("points.batch.max_rows")
+	if maxRows <= 0 {
+		maxRows = defaultPointBatchMaxRows
+	}
+
+	rows, err := parsePointBatchCSV(content, maxRows)
+	if err != nil {
+		p.Log.Warn("[PointUseCase.UploadBatch] " + err.Error())
+		return nil, false, err
+	}
+
+	fileHash := utils.HashToken(string(content))
+
+	batch, created, err := p.Repository.CreateBatch(&entity.PointBatch{
+		BatchKey: payload.BatchKey,
+		FileName: fileName,
+		FileHash: fileHash,
+		Reason:   reason,
+		Status:   entity.POINT_BATCH_PROCESSING,
+		ActorID:  actorID,
+	})
+	if err != nil {
+		p.Log.Error("[PointUseCase.UploadBatch] " + err.Error())
+		return nil, false, err
+	}
+
+	if batch.FileHash != fileHash {
+		p.Log.Warn("[PointUseCase.UploadBatch] Batch key reused for a different file " + payload.BatchKey)
+		return nil, false, ErrPointBatchKeyReused
+	}
+
+	if batch.Status == entity.POINT_BATCH_COMPLETED {
+		return p.DTO.ConvertEntityToPointBatchResponse(batch), false, nil
+	}
+
+	processed, err := p.Repository.FindBatchRowNumbers(batch.ID)
+	if err != nil {
+		p.Log.Error("[PointUseCase.UploadBatch] " + err.Error())
+		return nil, false, err
+	}
+
+	for _, row := range rows {
+		if processed[row.RowNumber] {
+			continue
+		}
+
+		if err := p.processBatchRow(batch, actorID, row); err != nil {
+			p.Log.Error("[PointUseCase.UploadBatch] " + err.Error())
+			return nil, false, err
+		}
+	}
+
+	batch, err = p.Repository.CompleteBatch(batch.ID, len(rows))
+	if err != nil {
+		p.Log.Error("[PointUseCase.UploadBatch] " + err.Error())
+		return nil, false, err
+	}
+
+	return p.DTO.ConvertEntityToPointBatchResponse(batch), created, nil
+}
+
+// processBatchRow credits a single row, or records why it could not be.
+func (p *PointUseCase) processBatchRow(batch *entity.PointBatch, actorID uuid.UUID, row pointBatchRow) error {
+	result := &entity.PointBatchRow{
+		BatchID:   batch.ID,
+		RowNumber: row.RowNumber,
+		Email:     row.Email,
+		Amount:    row.Amount,
+		Status:    entity.POINT_BATCH_ROW_FAILED,
+		Error:     row.Error,
+	}
+
+	if result.Error != "" {
+		return p.Repository.CreateBatchRow(result)
+	}
+
+	user, err := p.UserRepository.FindByEmail(row.Email)
+	if err != nil {
+		return err
+	}
+
+	if user == nil {
+		result.Error = ErrUserNotFound.Error()
+		return p.Repository.CreateBatchRow(result)
+	}
+
+	if user.ID == actorID {
+		result.Error = ErrCannotAdjustOwnPoints.Error()
+		return p.Repository.CreateBatchRow(result)
+	}
+
+	reason := row.Reason
+	if reason == "" {
+		reason = batch.Reason
+	}
+
+	return p.Repository.CreditBatchRow(result, &entity.PointLedger{
+		UserID:        user.ID,
+		Type:          entity.POINT_CREDIT,
+		Amount:        row.Amount,
+		Reason:        reason,
+		ReferenceType: entity.POINT_REFERENCE_POINT_BATCH,
+		ReferenceID:   &batch.ID,
+		ActorID:       &actorID,
+	})
+}
+
+func (p *PointUseCase) ensureUserExists(userID uuid.UUID) error {
+	user, err := p.UserRepository.FindById(userID)
+	if err != nil {
+		return err
+	}
+
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// pointBatchRow is a parsed CSV row. Error is set when the row is malformed, it
+// is then reported as failed without touching any wallet.
+type pointBatchRow struct {
+	RowNumber int
+	Email     string
+	Amount    int64
+	Reason    string
+	Error     string
+}
+
+// parsePointBatchCSV reads the rows of a batch upload.
+func parsePointBatchCSV(content []byte, maxRows int) ([]pointBatchRow, error) {
+	records, err := readCSVRecords(content, []string{"email", "amount"}, maxRows)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]pointBatchRow, 0, len(records))
+	for _, record := range records {
+		row := pointBatchRow{RowNumber: record.RowNumber, Error: record.Error}
+		if row.Error != "" {
+			rows = append(rows, row)
+			continue
+		}
+
+		row.Email = normalizeEmail(record.Fields["email"])
+		row.Reason = record.Fields["reason"]
+
+		amount, err := strconv.ParseInt(record.Fields["amount"], 10, 64)
+		switch {
+		case row.Email == "":
+			row.Error = "email is required"
+		case len(row.Email) > 255:
+			row.Email = row.Email[:255]
+			row.Error = "email is too long"
+		case err != nil || amount <= 0:
+			row.Error = "amount must be a positive whole number"
+		case len(row.Reason) > 255:
+			row.Error = "reason must be at most 255 characters"
+		default:
+			row.Amount = amount
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
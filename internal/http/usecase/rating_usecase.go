@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/dto"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/request"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/repository"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrRedemptionNotFound     = errors.New("redemption not found")
+	ErrRedemptionNotOwned     = errors.New("redemption does not belong to this user")
+	ErrRedemptionAlreadyRated = errors.New("redemption has already been rated")
+)
+
+type IRatingUseCase interface {
+	RateRedemption(userID uuid.UUID, redemptionID uuid.UUID, payload *request.RatingRequest) (*response.RatingResponse, error)
+}
+
+type RatingUseCase struct {
+	Log                  *logrus.Logger
+	Repository           repository.IRatingRepository
+	RedemptionRepository repository.IRedemptionRepository
+	DTO                  dto.IRatingDTO
+}
+
+func NewRatingUseCase(
+	log *logrus.Logger,
+	repository repository.IRatingRepository,
+	redemptionRepository repository.IRedemptionRepository,
+	dto dto.IRatingDTO,
+) IRatingUseCase {
+	return &RatingUseCase{
+		Log:                  log,
+		Repository:           repository,
+		RedemptionRepository: redemptionRepository,
+		DTO:                  dto,
+	}
+}
+
+func RatingUseCaseFactory(log *logrus.Logger) IRatingUseCase {
+	ratingRepository := repository.RatingRepositoryFactory(log)
+	redemptionRepository := repository.RedemptionRepositoryFactory(log)
+	dto := dto.RatingDTOFactory(log)
+	return NewRatingUseCase(log, ratingRepository, redemptionRepository, dto)
+}
+
+func (r *RatingUseCase) RateRedemption(userID uuid.UUID, redemptionID uuid.UUID, payload *request.RatingRequest) (*response.RatingResponse, error) {
+	redemption, err := r.RedemptionRepository.FindById(redemptionID)
+	if err != nil {
+		r.Log.Error("[RatingUseCase.RateRedemption] " + err.Error())
+		return nil, err
+	}
+
+	if redemption == nil {
+		r.Log.Warn("[RatingUseCase.RateRedemption] Redemption not found")
+		return nil, ErrRedemptionNotFound
+	}
+
+	if redemption.UserID != userID {
+		r.Log.Warn("[RatingUseCase.RateRedemption] Redemption does not belong to user")
+		return nil, ErrRedemptionNotOwned
+	}
+
+	existing, err := r.Repository.FindByRedemptionID(redemptionID)
+	if err != nil {
+		r.Log.Error("[RatingUseCase.RateRedemption] " + err.Error())
+		return nil, err
+	}
+
+	if existing != nil {
+		r.Log.Warn("[RatingUseCase.RateRedemption] Redemption already rated")
+		return nil, ErrRedemptionAlreadyRated
+	}
+
+	rating, err := r.Repository.CreateRating(&entity.Rating{
+		RedemptionID: &redemption.ID,
+		Rating:       payload.Rating,
+		Comment:      payload.Comment,
+	})
+	if err != nil {
+		r.Log.Error("[RatingUseCase.RateRedemption] " + err.Error())
+		return nil, err
+	}
+
+	return r.DTO.ConvertEntityToRatingResponse(rating), nil
+}
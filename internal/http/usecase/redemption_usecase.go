@@ -0,0 +1,226 @@
+package usecase
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/dto"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/messaging"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/request"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/repository"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+type IRedemptionUseCase interface {
+	Redeem(userID uuid.UUID, giftID uuid.UUID) (*response.RedemptionResponse, error)
+	FindMine(userID uuid.UUID, id uuid.UUID) (*response.RedemptionResponse, error)
+	FindAllMinePaginated(userID uuid.UUID, status string, page int, pageSize int) (*[]response.RedemptionResponse, int64, error)
+	FindAllPaginated(status string, page int, pageSize int) (*[]response.RedemptionResponse, int64, error)
+	FindByID(id uuid.UUID) (*response.RedemptionResponse, error)
+	UpdateStatus(actorID uuid.UUID, id uuid.UUID, payload *request.RedemptionStatusRequest) (*response.RedemptionResponse, error)
+	Cancel(actorID uuid.UUID, canManage bool, id uuid.UUID, payload *request.RedemptionCancelRequest) (*response.RedemptionResponse, error)
+}
+
+type RedemptionUseCase struct {
+	Log            *logrus.Logger
+	Viper          *viper.Viper
+	Repository     repository.IRedemptionRepository
+	UserRepository repository.IUserRepository
+	DTO            dto.IRedemptionDTO
+	MailMessage    messaging.IMailMessage
+}
+
+func NewRedemptionUseCase(
+	log *logrus.Logger,
+	viper *viper.Viper,
+	repository repository.IRedemptionRepository,
+	userRepository repository.IUserRepository,
+	dto dto.IRedemptionDTO,
+	mailMessage messaging.IMailMessage,
+) IRedemptionUseCase {
+	return &RedemptionUseCase{
+		Log:            log,
+		Viper:          viper,
+		Repository:     repository,
+		UserRepository: userRepository,
+		DTO:            dto,
+		MailMessage:    mailMessage,
+	}
+}
+
+func RedemptionUseCaseFactory(log *logrus.Logger, viper *viper.Viper) IRedemptionUseCase {
+	redemptionRepository := repository.RedemptionRepositoryFactory(log)
+	userRepository := repository.UserRepositoryFactory(log)
+	dto := dto.RedemptionDTOFactory(log, viper)
+	mailMessage := messaging.MailMessageFactory(log)
+	return NewRedemptionUseCase(log, viper, redemptionRepository, userRepository, dto, mailMessage)
+}
+
+func (r *RedemptionUseCase) Redeem(userID uuid.UUID, giftID uuid.UUID) (*response.RedemptionResponse, error) {
+	// the calendar periods of the redemption limits follow app.timezone
+	location, err := utils.LoadTimezone(r.Viper)
+	if err != nil {
+		r.Log.Error("[RedemptionUseCase.Redeem] " + err.Error())
+		return nil, err
+	}
+
+	redemption, err := r.Repository.Redeem(userID, giftID, time.Now().In(location))
+	if err != nil {
+		r.Log.Error("[RedemptionUseCase.Redeem] " + err.Error())
+		return nil, err
+	}
+
+	return r.DTO.ConvertEntityToRedemptionResponse(redemption), nil
+}
+
+// FindMine returns a redemption of the user together with its timeline and,
+// once fulfilled, the decrypted gift code. This is the only place a gift code
+// is ever decrypted.
+func (r *RedemptionUseCase) FindMine(userID uuid.UUID, id uuid.UUID) (*response.RedemptionResponse, error) {
+	redemption, err := r.Repository.FindByIdAndUser(id, userID)
+	if err != nil {
+		r.Log.Error("[RedemptionUseCase.FindMine] " + err.Error())
+		return nil, err
+	}
+
+	if redemption == nil {
+		r.Log.Warn("[RedemptionUseCase.FindMine] Redemption not found")
+		return nil, nil
+	}
+
+	result := r.DTO.ConvertEntityToRedemptionResponse(redemption)
+
+	if redemption.GiftCode != nil && redemption.GiftCode.Status == entity.GIFT_CODE_ISSUED {
+		codeCipher, err := utils.LoadCodeCipher(r.Viper)
+		if err != nil {
+			r.Log.Error("[RedemptionUseCase.FindMine] " + err.Error())
+			return nil, err
+		}
+
+		result.Code, err = codeCipher.Decrypt(&utils.EncryptedCode{
+			KeyID:      redemption.GiftCode.KeyID,
+			WrappedKey: redemption.GiftCode.EncryptedKey,
+			Ciphertext: redemption.GiftCode.EncryptedCode,
+		})
+		if err != nil {
+			r.Log.Error("[RedemptionUseCase.FindMine] " + err.Error())
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (r *RedemptionUseCase) FindAllMinePaginated(userID uuid.UUID, status string, page int, pageSize int) (*[]response.RedemptionResponse, int64, error) {
+	redemptions, total, err := r.Repository.FindAllByUserPaginated(userID, status, page, pageSize)
+	if err != nil {
+		r.Log.Error("[RedemptionUseCase.FindAllMinePaginated] " + err.Error())
+		return nil, 0, err
+	}
+
+	return r.DTO.ConvertEntitiesToRedemptionResponses(redemptions), total, nil
+}
+
+func (r *RedemptionUseCase) FindAllPaginated(status string, page int, pageSize int) (*[]response.RedemptionResponse, int64, error) {
+	redemptions, total, err := r.Repository.FindAllPaginated(status, page, pageSize)
+	if err != nil {
+		r.Log.Error("[RedemptionUseCase.FindAllPaginated] " + err.Error())
+		return nil, 0, err
+	}
+
+	return r.DTO.ConvertEntitiesToRedemptionResponses(redemptions), total, nil
+}
+
+func (r *RedemptionUseCase) FindByID(id uuid.UUID) (*response.RedemptionResponse, error) {
+	redemption, err := r.Repository.FindById(id)
+	if err != nil {
+		r.Log.Error("[RedemptionUseCase.FindByID] " + err.Error())
+		return nil, err
+	}
+
+	if redemption == nil {
+		r.Log.Warn("[RedemptionUseCase.FindByID] Redemption not found")
+		return nil, nil
+	}
+
+	return r.DTO.ConvertEntityToRedemptionResponse(redemption), nil
+}
+
+func (r *RedemptionUseCase) UpdateStatus(actorID uuid.UUID, id uuid.UUID, payload *request.RedemptionStatusRequest) (*response.RedemptionResponse, error) {
+	redemption, err := r.Repository.Transition(id, entity.RedemptionStatus(payload.Status), &actorID, payload.Note)
+	if err != nil {
+		r.Log.Error("[RedemptionUseCase.UpdateStatus] " + err.Error())
+		return nil, err
+	}
+
+	if redemption.Status == entity.REDEMPTION_CANCELLED {
+		r.sendCancellationEmail(redemption, payload.Note)
+	}
+
+	return r.DTO.ConvertEntityToRedemptionResponse(redemption), nil
+}
+
+// Cancel cancels a pending redemption on behalf of its owner, or of any user
+// when canManage is set. The gift code goes back to the inventory and the
+// points are given back, then the owner is notified by email. Redemptions of
+// other users are reported as not found.
+func (r *RedemptionUseCase) Cancel(actorID uuid.UUID, canManage bool, id uuid.UUID, payload *request.RedemptionCancelRequest) (*response.RedemptionResponse, error) {
+	redemption, err := r.Repository.FindById(id)
+	if err != nil {
+		r.Log.Error("[RedemptionUseCase.Cancel] " + err.Error())
+		return nil, err
+	}
+
+	if redemption == nil || (redemption.UserID != actorID && !canManage) {
+		r.Log.Warn("[RedemptionUseCase.Cancel] Redemption not found")
+		return nil, repository.ErrRedemptionNotFound
+	}
+
+	redemption, err = r.Repository.Transition(id, entity.REDEMPTION_CANCELLED, &actorID, payload.Reason)
+	if err != nil {
+		r.Log.Error("[RedemptionUseCase.Cancel] " + err.Error())
+		return nil, err
+	}
+
+	r.sendCancellationEmail(redemption, payload.Reason)
+
+	return r.DTO.ConvertEntityToRedemptionResponse(redemption), nil
+}
+
+// sendCancellationEmail tells the owner that the redemption was cancelled. The
+// cancellation is already committed, so a failure is only logged.
+func (r *RedemptionUseCase) sendCancellationEmail(redemption *entity.Redemption, reason string) {
+	user, err := r.UserRepository.FindById(redemption.UserID)
+	if err != nil {
+		r.Log.Error("[RedemptionUseCase.sendCancellationEmail] " + err.Error())
+		return
+	}
+
+	if user == nil {
+		r.Log.Warn("[RedemptionUseCase.sendCancellationEmail] User not found")
+		return
+	}
+
+	body := "Your redemption of " + redemption.Gift.Name + " has been cancelled."
+	if redemption.PointsSpent > 0 {
+		body += " " + strconv.FormatInt(redemption.PointsSpent, 10) + " points have been returned to your balance."
+	}
+	if reason != "" {
+		body += " Reason: " + reason
+	}
+
+	if _, err := r.MailMessage.SendMail(&request.MailRequest{
+		Email:   user.Email,
+		Subject: "Redemption Cancelled",
+		Body:    body,
+		From:    r.Viper.GetString("mail.from"),
+		To:      user.Email,
+	}); err != nil {
+		r.Log.Error("[RedemptionUseCase.sendCancellationEmail] " + err.Error())
+	}
+}
@@ -0,0 +1,261 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/dto"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/request"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/repository"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrRoleNameTaken = errors.New("role name already used by another role")
+	ErrUserNotFound  = errors.New("user not found")
+)
+
+type IRoleUseCase interface {
+	FindAllPaginated(page int, pageSize int, search string) (*[]response.RoleResponse, int64, error)
+	FindByID(id uuid.UUID) (*response.RoleResponse, error)
+	StoreRole(payload *request.RoleRequest) (*response.RoleResponse, error)
+	UpdateRole(id uuid.UUID, payload *request.RoleRequest) (*response.RoleResponse, error)
+	DeleteRole(id uuid.UUID) (bool, error)
+	FindAllPermissions() (*[]response.PermissionResponse, error)
+	FindUserRoles(userID uuid.UUID) (*response.UserRolesResponse, error)
+	AssignRolesToUser(userID uuid.UUID, payload *request.AssignRolesRequest) (*response.UserRolesResponse, error)
+	RevokeRoleFromUser(userID uuid.UUID, roleID uuid.UUID) (*response.UserRolesResponse, error)
+}
+
+type RoleUseCase struct {
+	Log                  *logrus.Logger
+	Repository           repository.IRoleRepository
+	UserRepository       repository.IUserRepository
+	PermissionRepository repository.IPermissionRepository
+	DTO                  dto.IRoleDTO
+	PermissionDTO        dto.IPermissionDTO
+}
+
+func NewRoleUseCase(
+	log *logrus.Logger,
+	repository repository.IRoleRepository,
+	userRepository repository.IUserRepository,
+	permissionRepository repository.IPermissionRepository,
+	dto dto.IRoleDTO,
+	permissionDTO dto.IPermissionDTO,
+) IRoleUseCase {
+	return &RoleUseCase{
+		Log:                  log,
+		Repository:           repository,
+		UserRepository:       userRepository,
+		PermissionRepository: permissionRepository,
+		DTO:                  dto,
+		PermissionDTO:        permissionDTO,
+	}
+}
+
+func RoleUseCaseFactory(log *logrus.Logger) IRoleUseCase {
+	roleRepository := repository.RoleRepositoryFactory(log)
+	userRepository := repository.UserRepositoryFactory(log)
+	permissionRepository := repository.PermissionRepositoryFactory(log)
+	roleDTO := dto.RoleDTOFactory(log)
+	permissionDTO := dto.PermissionDTOFactory(log)
+	return NewRoleUseCase(log, roleRepository, userRepository, permissionRepository, roleDTO, permissionDTO)
+}
+
+func (r *RoleUseCase) FindAllPaginated(page int, pageSize int, search string) (*[]response.RoleResponse, int64, error) {
+	roles, total, err := r.Repository.FindAllPaginated(page, pageSize, search)
+	if err != nil {
+		r.Log.Error("[RoleUseCase.FindAllPaginated] " + err.Error())
+		return nil, 0, err
+	}
+
+	return r.DTO.ConvertEntitiesToRoleResponses(roles), total, nil
+}
+
+func (r *RoleUseCase) FindByID(id uuid.UUID) (*response.RoleResponse, error) {
+	role, err := r.Repository.FindById(id)
+	if err != nil {
+		r.Log.Error("[RoleUseCase.FindByID] " + err.Error())
+		return nil, err
+	}
+
+	if role == nil {
+		r.Log.Warn("[RoleUseCase.FindByID] Role not found")
+		return nil, nil
+	}
+
+	return r.DTO.ConvertEntityToRoleResponse(role), nil
+}
+
+func (r *RoleUseCase) StoreRole(payload *request.RoleRequest) (*response.RoleResponse, error) {
+	existing, err := r.Repository.FindByName(payload.Name)
+	if err != nil {
+		r.Log.Error("[RoleUseCase.StoreRole] " + err.Error())
+		return nil, err
+	}
+
+	if existing != nil {
+		r.Log.Warn("[RoleUseCase.StoreRole] Role name already used")
+		return nil, ErrRoleNameTaken
+	}
+
+	role := &entity.Role{
+		Name:      payload.Name,
+		GuardName: payload.GuardName,
+		Status:    payload.Status,
+	}
+	if payload.RequireTwoFactor != nil {
+		role.RequireTwoFactor = *payload.RequireTwoFactor
+	}
+
+	role, err = r.Repository.StoreRole(role, payload.PermissionIDs)
+	if err != nil {
+		r.Log.Error("[RoleUseCase.StoreRole] " + err.Error())
+		return nil, err
+	}
+
+	return r.DTO.ConvertEntityToRoleResponse(role), nil
+}
+
+func (r *RoleUseCase) UpdateRole(id uuid.UUID, payload *request.RoleRequest) (*response.RoleResponse, error) {
+	role, err := r.Repository.FindById(id)
+	if err != nil {
+		r.Log.Error("[RoleUseCase.UpdateRole] " + err.Error())
+		return nil, err
+	}
+
+	if role == nil {
+		r.Log.Warn("[RoleUseCase.UpdateRole] Role not found")
+		return nil, nil
+	}
+
+	existing, err := r.Repository.FindByName(payload.Name)
+	if err != nil {
+		r.Log.Error("[RoleUseCase.UpdateRole] " + err.Error())
+		return nil, err
+	}
+
+	if existing != nil && existing.ID != role.ID {
+		r.Log.Warn("[RoleUseCase.UpdateRole] Role name already used")
+		return nil, ErrRoleNameTaken
+	}
+
+	role.Name = payload.Name
+	role.GuardName = payload.GuardName
+	role.Status = payload.Status
+	if payload.RequireTwoFactor != nil {
+		role.RequireTwoFactor = *payload.RequireTwoFactor
+	}
+
+	role, err = r.Repository.UpdateRole(role, payload.PermissionIDs)
+	if err != nil {
+		r.Log.Error("[RoleUseCase.UpdateRole] " + err.Error())
+		return nil, err
+	}
+
+	return r.DTO.ConvertEntityToRoleResponse(role), nil
+}
+
+func (r *RoleUseCase) DeleteRole(id uuid.UUID) (bool, error) {
+	role, err := r.Repository.FindById(id)
+	if err != nil {
+		r.Log.Error("[RoleUseCase.DeleteRole] " + err.Error())
+		return false, err
+	}
+
+	if role == nil {
+		r.Log.Warn("[RoleUseCase.DeleteRole] Role not found")
+		return false, nil
+	}
+
+	if err := r.Repository.DeleteRole(id); err != nil {
+		r.Log.Error("[RoleUseCase.DeleteRole] " + err.Error())
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *RoleUseCase) FindAllPermissions() (*[]response.PermissionResponse, error) {
+	permissions, err := r.PermissionRepository.GetAllPermissions()
+	if err != nil {
+		r.Log.Error("[RoleUseCase.FindAllPermissions] " + err.Error())
+		return nil, err
+	}
+
+	return r.PermissionDTO.ConvertEntitiesToPermissionResponses(permissions), nil
+}
+
+func (r *RoleUseCase) FindUserRoles(userID uuid.UUID) (*response.UserRolesResponse, error) {
+	user, err := r.UserRepository.FindById(userID)
+	if err != nil {
+		r.Log.Error("[RoleUseCase.FindUserRoles] " + err.Error())
+		return nil, err
+	}
+
+	if user == nil {
+		r.Log.Warn("[RoleUseCase.FindUserRoles] User not found")
+		return nil, ErrUserNotFound
+	}
+
+	assigned, err := r.Repository.GetAllRolesInUserID(userID)
+	if err != nil {
+		r.Log.Error("[RoleUseCase.FindUserRoles] " + err.Error())
+		return nil, err
+	}
+
+	available, err := r.Repository.GetAllRolesNotInUserID(userID)
+	if err != nil {
+		r.Log.Error("[RoleUseCase.FindUserRoles] " + err.Error())
+		return nil, err
+	}
+
+	return &response.UserRolesResponse{
+		UserID:    userID,
+		Assigned:  r.DTO.ConvertEntitiesToRoleResponses(assigned),
+		Available: r.DTO.ConvertEntitiesToRoleResponses(available),
+	}, nil
+}
+
+func (r *RoleUseCase) AssignRolesToUser(userID uuid.UUID, payload *request.AssignRolesRequest) (*response.UserRolesResponse, error) {
+	user, err := r.UserRepository.FindById(userID)
+	if err != nil {
+		r.Log.Error("[RoleUseCase.AssignRolesToUser] " + err.Error())
+		return nil, err
+	}
+
+	if user == nil {
+		r.Log.Warn("[RoleUseCase.AssignRolesToUser] User not found")
+		return nil, ErrUserNotFound
+	}
+
+	if err := r.Repository.AssignRolesToUser(userID, payload.RoleIDs); err != nil {
+		r.Log.Error("[RoleUseCase.AssignRolesToUser] " + err.Error())
+		return nil, err
+	}
+
+	return r.FindUserRoles(userID)
+}
+
+func (r *RoleUseCase) RevokeRoleFromUser(userID uuid.UUID, roleID uuid.UUID) (*response.UserRolesResponse, error) {
+	user, err := r.UserRepository.FindById(userID)
+	if err != nil {
+		r.Log.Error("[RoleUseCase.RevokeRoleFromUser] " + err.Error())
+		return nil, err
+	}
+
+	if user == nil {
+		r.Log.Warn("[RoleUseCase.RevokeRoleFromUser] User not found")
+		return nil, ErrUserNotFound
+	}
+
+	if err := r.Repository.RevokeRoleFromUser(userID, roleID); err != nil {
+		r.Log.Error("[RoleUseCase.RevokeRoleFromUser] " + err.Error())
+		return nil, err
+	}
+
+	return r.FindUserRoles(userID)
+}
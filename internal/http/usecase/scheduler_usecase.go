@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/dto"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+type ISchedulerUseCase interface {
+	FindAllRunsPaginated(jobName string, status string, page int, pageSize int) (*[]response.SchedulerRunResponse, int64, error)
+}
+
+type SchedulerUseCase struct {
+	Log        *logrus.Logger
+	Repository repository.ISchedulerRepository
+	DTO        dto.ISchedulerDTO
+}
+
+func NewSchedulerUseCase(
+	log *logrus.Logger,
+	repository repository.ISchedulerRepository,
+	dto dto.ISchedulerDTO,
+) ISchedulerUseCase {
+	return &SchedulerUseCase{
+		Log:        log,
+		Repository: repository,
+		DTO:        dto,
+	}
+}
+
+func SchedulerUseCaseFactory(log *logrus.Logger, viper *viper.Viper) ISchedulerUseCase {
+	schedulerRepository := repository.SchedulerRepositoryFactory(log)
+	dto := dto.SchedulerDTOFactory(log, viper)
+	return NewSchedulerUseCase(log, schedulerRepository, dto)
+}
+
+func (s *SchedulerUseCase) FindAllRunsPaginated(jobName string, status string, page int, pageSize int) (*[]response.SchedulerRunResponse, int64, error) {
+	runs, total, err := s.Repository.FindAllRunsPaginated(jobName, status, page, pageSize)
+	if err != nil {
+		s.Log.Error("[SchedulerUseCase.FindAllRunsPaginated] " + err.Error())
+		return nil, 0, err
+	}
+
+	return s.DTO.ConvertEntitiesToSchedulerRunResponses(runs), total, nil
+}
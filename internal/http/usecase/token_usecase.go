@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/dto"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/request"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/repository"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+type ITokenUseCase interface {
+	IssueTokens(user *response.UserResponse, twoFactorVerified bool) (*response.TokenResponse, error)
+	Refresh(payload *request.RefreshTokenRequest) (*response.TokenResponse, error)
+	Logout(userID uuid.UUID, claims jwt.MapClaims, payload *request.LogoutRequest) error
+	LogoutAll(userID uuid.UUID, claims jwt.MapClaims) error
+}
+
+type TokenUseCase struct {
+	Log            *logrus.Logger
+	Viper          *viper.Viper
+	Repository     repository.ITokenRepository
+	UserRepository repository.IUserRepository
+	UserDTO        dto.IUserDTO
+}
+
+func NewTokenUseCase(
+	log *logrus.Logger,
+	viper *viper.Viper,
+	repository repository.ITokenRepository,
+	userRepository repository.IUserRepository,
+	userDTO dto.IUserDTO,
+) ITokenUseCase {
+	return &TokenUseCase{
+		Log:            log,
+		Viper:          viper,
+		Repository:     repository,
+		UserRepository: userRepository,
+		UserDTO:        userDTO,
+	}
+}
+
+func TokenUseCaseFactory(log *logrus.Logger, viper *viper.Viper) ITokenUseCase {
+	tokenRepository := repository.TokenRepositoryFactory(log)
+	userRepository := repository.UserRepositoryFactory(log)
+	userDTO := dto.UserDTOFactory(log)
+	return NewTokenUseCase(log, viper, tokenRepository, userRepository, userDTO)
+}
+
+// IssueTokens starts a new session for the user with a fresh access token and
+// the first refresh token of a new family.
+func (t *TokenUseCase) IssueTokens(user *response.UserResponse, twoFactorVerified bool) (*response.TokenResponse, error) {
+	refreshToken, err := utils.GenerateRandomString(32)
+	if err != nil {
+		t.Log.Error("[TokenUseCase.IssueTokens] " + err.Error())
+		return nil, err
+	}
+
+	if err := t.Repository.CreateRefreshToken(&entity.RefreshToken{
+		UserID:            user.ID,
+		FamilyID:          uuid.New(),
+		TokenHash:         utils.HashToken(refreshToken),
+		ExpiredAt:         time.Now().Add(utils.RefreshTokenTTL(t.Viper)),
+		TwoFactorVerified: twoFactorVerified,
+	}); err != nil {
+		t.Log.Error("[TokenUseCase.IssueTokens] " + err.Error())
+		return nil, err
+	}
+
+	return t.tokenResponse(user, refreshToken, twoFactorVerified)
+}
+
+func (t *TokenUseCase) Refresh(payload *request.RefreshTokenRequest) (*response.TokenResponse, error) {
+	refreshToken, err := utils.GenerateRandomString(32)
+	if err != nil {
+		t.Log.Error("[TokenUseCase.Refresh] " + err.Error())
+		return nil, err
+	}
+
+	rotated, err := t.Repository.RotateRefreshToken(utils.HashToken(payload.RefreshToken), &entity.RefreshToken{
+		TokenHash: utils.HashToken(refreshToken),
+		ExpiredAt: time.Now().Add(utils.RefreshTokenTTL(t.Viper)),
+	})
+	if err != nil {
+		t.Log.Error("[TokenUseCase.Refresh] " + err.Error())
+		return nil, err
+	}
+
+	// the user is reloaded so the new access token carries current roles and permissions
+	user, err := t.UserRepository.FindById(rotated.UserID)
+	if err != nil {
+		t.Log.Error("[TokenUseCase.Refresh] " + err.Error())
+		return nil, err
+	}
+
+	if user == nil || user.Status != entity.USER_ACTIVE {
+		t.Log.Warn("[TokenUseCase.Refresh] User not found or not active")
+		if err := t.Repository.RevokeFamily(rotated.FamilyID); err != nil {
+			t.Log.Error("[TokenUseCase.Refresh] " + err.Error())
+			return nil, err
+		}
+		return nil, repository.ErrRefreshTokenInvalid
+	}
+
+	return t.tokenResponse(t.UserDTO.ConvertEntityToUserResponse(user), refreshToken, rotated.TwoFactorVerified)
+}
+
+// Logout revokes the access token used for the request and, when given, the
+// session of the refresh token.
+func (t *TokenUseCase) Logout(userID uuid.UUID, claims jwt.MapClaims, payload *request.LogoutRequest) error {
+	if err := t.revokeAccessToken(userID, claims); err != nil {
+		t.Log.Error("[TokenUseCase.Logout] " + err.Error())
+		return err
+	}
+
+	if payload.RefreshToken == "" {
+		return nil
+	}
+
+	if err := t.Repository.RevokeRefreshTokenFamily(utils.HashToken(payload.RefreshToken), userID); err != nil {
+		t.Log.Error("[TokenUseCase.Logout] " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// LogoutAll revokes the current access token and every refresh token of the
+// user, ending all of their sessions once the other access tokens expire.
+func (t *TokenUseCase) LogoutAll(userID uuid.UUID, claims jwt.MapClaims) error {
+	if err := t.revokeAccessToken(userID, claims); err != nil {
+		t.Log.Error("[TokenUseCase.LogoutAll] " + err.Error())
+		return err
+	}
+
+	if err := t.Repository.RevokeAllRefreshTokens(userID); err != nil {
+		t.Log.Error("[TokenUseCase.LogoutAll] " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (t *TokenUseCase) revokeAccessToken(userID uuid.UUID, claims jwt.MapClaims) error {
+	jti, ok := claims["jti"].(string)
+	if !ok || jti == "" {
+		return nil
+	}
+
+	expiredAt := time.Now().Add(utils.AccessTokenTTL(t.Viper))
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		expiredAt = exp.Time
+	}
+
+	return t.Repository.RevokeAccessToken(jti, userID, expiredAt)
+}
+
+func (t *TokenUseCase) tokenResponse(user *response.UserResponse, refreshToken string, twoFactorVerified bool) (*response.TokenResponse, error) {
+	token, err := utils.GenerateToken(user, twoFactorVerified)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.TokenResponse{
+		Token:                 token,
+		TokenType:             "Bearer",
+		ExpiresIn:             int64(utils.AccessTokenTTL(t.Viper).Seconds()),
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresIn: int64(utils.RefreshTokenTTL(t.Viper).Seconds()),
+		User:                  user,
+	}, nil
+}
@@ -0,0 +1,443 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/dto"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/request"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/repository"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	defaultTwoFactorChallengeTTL    = 5 * time.Minute
+	defaultTwoFactorMaxAttempts     = 5
+	defaultTwoFactorLockoutDuration = 15 * time.Minute
+	twoFactorRecoveryCodeCount      = 10
+	// twoFactorSkew accepts the previous and the next code to tolerate clock drift
+	twoFactorSkew = 1
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotSetUp         = errors.New("two-factor authentication has not been set up")
+	ErrTwoFactorNotEnabled       = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorRequiredByRole   = errors.New("two-factor authentication is required by one of your roles")
+	ErrInvalidTwoFactorCode      = errors.New("two-factor code is invalid")
+	ErrInvalidTwoFactorChallenge = errors.New("two-factor challenge is invalid or expired, please sign in again")
+	ErrIncorrectPassword         = errors.New("password is incorrect")
+)
+
+type ITwoFactorUseCase interface {
+	Setup(userID uuid.UUID) (*response.TwoFactorSetupResponse, error)
+	Confirm(userID uuid.UUID, payload *request.TwoFactorConfirmRequest) (*response.TwoFactorRecoveryCodesResponse, error)
+	Disable(userID uuid.UUID, payload *request.TwoFactorDisableRequest) error
+	ResetForUser(actorID uuid.UUID, id uuid.UUID) (bool, error)
+	CreateChallenge(user *response.UserResponse) (*response.TwoFactorChallengeResponse, error)
+	VerifyChallenge(payload *request.TwoFactorLoginRequest) (*response.UserResponse, error)
+}
+
+type TwoFactorUseCase struct {
+	Log                    *logrus.Logger
+	Viper                  *viper.Viper
+	Repository             repository.ITwoFactorRepository
+	UserRepository         repository.IUserRepository
+	TokenRepository        repository.ITokenRepository
+	LoginAttemptRepository repository.ILoginAttemptRepository
+	UserDTO                dto.IUserDTO
+}
+
+func NewTwoFactorUseCase(
+	log *logrus.Logger,
+	viper *viper.Viper,
+	repository repository.ITwoFactorRepository,
+	userRepository repository.IUserRepository,
+	tokenRepository repository.ITokenRepository,
+	loginAttemptRepository repository.ILoginAttemptRepository,
+	userDTO dto.IUserDTO,
+) ITwoFactorUseCase {
+	return &TwoFactorUseCase{
+		Log:                    log,
+		Viper:                  viper,
+		Repository:             repository,
+		UserRepository:         userRepository,
+		TokenRepository:        tokenRepository,
+		LoginAttemptRepository: loginAttemptRepository,
+		UserDTO:                userDTO,
+	}
+}
+
+func TwoFactorUseCaseFactory(log *logrus.Logger, viper *viper.Viper) ITwoFactorUseCase {
+	twoFactorRepository := repository.TwoFactorRepositoryFactory(log)
+	userRepository := repository.UserRepositoryFactory(log)
+	tokenRepository := repository.TokenRepositoryFactory(log)
+	loginAttemptRepository := repository.LoginAttemptRepositoryFactory(log)
+	userDTO := dto.UserDTOFactory(log)
+	return NewTwoFactorUseCase(log, viper, twoFactorRepository, userRepository, tokenRepository, loginAttemptRepository, userDTO)
+}
+
+// Setup generates a new secret for the user. It only takes effect once a code
+// generated from it is confirmed.
+func (t *TwoFactorUseCase) Setup(userID uuid.UUID) (*response.TwoFactorSetupResponse, error) {
+	user, err := t.findUser(userID)
+	if err != nil {
+		t.Log.Error("[TwoFactorUseCase.Setup] " + err.Error())
+		return nil, err
+	}
+
+	if user.TwoFactorEnabled() {
+		t.Log.Warn("[TwoFactorUseCase.Setup] Two-factor authentication already enabled")
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Log.Error("[TwoFactorUseCase.Setup] " + err.Error())
+		return nil, err
+	}
+
+	if err := t.Repository.SetSecret(user.ID, secret); err != nil {
+		t.Log.Error("[TwoFactorUseCase.Setup] " + err.Error())
+		return nil, err
+	}
+
+	issuer := t.Viper.GetString("auth.two_factor.issuer")
+	if issuer == "" {
+		issuer = t.Viper.GetString("app.name")
+	}
+
+	return &response.TwoFactorSetupResponse{
+		Secret:     secret,
+		OtpauthURI: utils.TOTPURI(issuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm enables 2FA once the user proved their authenticator works, and
+// returns the recovery codes. They are shown this one time only.
+func (t *TwoFactorUseCase) Confirm(userID uuid.UUID, payload *request.TwoFactorConfirmRequest) (*response.TwoFactorRecoveryCodesResponse, error) {
+	user, err := t.findUser(userID)
+	if err != nil {
+		t.Log.Error("[TwoFactorUseCase.Confirm] " + err.Error())
+		return nil, err
+	}
+
+	if user.TwoFactorEnabled() {
+		t.Log.Warn("[TwoFactorUseCase.Confirm] Two-factor authentication already enabled")
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	if user.TwoFactorSecret == "" {
+		t.Log.Warn("[TwoFactorUseCase.Confirm] Two-factor authentication not set up")
+		return nil, ErrTwoFactorNotSetUp
+	}
+
+	step, ok := utils.ValidateTOTP(user.TwoFactorSecret, payload.Code, time.Now(), twoFactorSkew)
+	if !ok {
+		t.Log.Warn("[TwoFactorUseCase.Confirm] Invalid two-factor code")
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes := make([]string, 0, twoFactorRecoveryCodeCount)
+	hashes := make([]string, 0, twoFactorRecoveryCodeCount)
+	for i := 0; i < twoFactorRecoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			t.Log.Error("[TwoFactorUseCase.Confirm] " + err.Error())
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, utils.HashToken(normalizeRecoveryCode(code)))
+	}
+
+	if err := t.Repository.Enable(user.ID, step, hashes); err != nil {
+		t.Log.Error("[TwoFactorUseCase.Confirm] " + err.Error())
+		return nil, err
+	}
+
+	return &response.TwoFactorRecoveryCodesResponse{
+		RecoveryCodes: codes,
+	}, nil
+}
+
+func (t *TwoFactorUseCase) Disable(userID uuid.UUID, payload *request.TwoFactorDisableRequest) error {
+	user, err := t.findUser(userID)
+	if err != nil {
+		t.Log.Error("[TwoFactorUseCase.Disable] " + err.Error())
+		return err
+	}
+
+	if !user.TwoFactorEnabled() {
+		t.Log.Warn("[TwoFactorUseCase.Disable] Two-factor authentication not enabled")
+		return ErrTwoFactorNotEnabled
+	}
+
+	if t.UserDTO.ConvertEntityToUserResponse(user).TwoFactorRequired {
+		t.Log.Warn("[TwoFactorUseCase.Disable] Two-factor authentication required by role")
+		return ErrTwoFactorRequiredByRole
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(payload.Password)); err != nil {
+		t.Log.Warn("[TwoFactorUseCase.Disable] Password not match")
+		return ErrIncorrectPassword
+	}
+
+	if err := t.checkSecondFactor(user, payload.Code); err != nil {
+		t.Log.Warn("[TwoFactorUseCase.Disable] " + err.Error())
+		return err
+	}
+
+	if err := t.Repository.Disable(user.ID); err != nil {
+		t.Log.Error("[TwoFactorUseCase.Disable] " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// ResetForUser lets an administrator turn off 2FA for a user who lost both the
+// authenticator and the recovery codes. The user's sessions are ended as well.
+// Administrators cannot reset their own 2FA or that of users who hold roles or
+// permissions they lack.
+func (t *TwoFactorUseCase) ResetForUser(actorID uuid.UUID, id uuid.UUID) (bool, error) {
+	if actorID == id {
+		t.Log.Warn("[TwoFactorUseCase.ResetForUser] User tried to reset own two-factor authentication")
+		return false, ErrCannotModifySelf
+	}
+
+	user, err := t.UserRepository.FindById(id)
+	if err != nil {
+		t.Log.Error("[TwoFactorUseCase.ResetForUser] " + err.Error())
+		return false, err
+	}
+
+	if user == nil {
+		t.Log.Warn("[TwoFactorUseCase.ResetForUser] User not found")
+		return false, nil
+	}
+
+	actor, err := t.UserRepository.FindById(actorID)
+	if err != nil {
+		t.Log.Error("[TwoFactorUseCase.ResetForUser] " + err.Error())
+		return false, err
+	}
+
+	if actor == nil || !holdsAllRolesAndPermissions(actor, user) {
+		t.Log.Warn("[TwoFactorUseCase.ResetForUser] User outranks the actor")
+		return false, ErrUserOutranksActor
+	}
+
+	if err := t.Repository.Disable(user.ID); err != nil {
+		t.Log.Error("[TwoFactorUseCase.ResetForUser] " + err.Error())
+		return false, err
+	}
+
+	if err := t.TokenRepository.RevokeAllRefreshTokens(user.ID); err != nil {
+		t.Log.Error("[TwoFactorUseCase.ResetForUser] " + err.Error())
+		return false, err
+	}
+
+	return true, nil
+}
+
+// CreateChallenge issues the token a user with 2FA exchanges, together with a
+// code, for real tokens at /api/login/2fa.
+func (t *TwoFactorUseCase) CreateChallenge(user *response.UserResponse) (*response.TwoFactorChallengeResponse, error) {
+	ttl := time.Duration(t.Viper.GetInt("auth.two_factor.challenge_ttl")) * time.Minute
+	if ttl <= 0 {
+		ttl = defaultTwoFactorChallengeTTL
+	}
+
+	token, err := utils.GenerateTwoFactorChallengeToken(user.ID, ttl)
+	if err != nil {
+		t.Log.Error("[TwoFactorUseCase.CreateChallenge] " + err.Error())
+		return nil, err
+	}
+
+	return &response.TwoFactorChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+		ExpiresIn:         int64(ttl.Seconds()),
+	}, nil
+}
+
+// VerifyChallenge checks the code entered for a challenge. A challenge can be
+// used once, a wrong code means signing in with the password again. Wrong codes
+// are counted per user and lock the second factor once there are too many, and
+// only a completed challenge clears the failed logins of the email.
+func (t *TwoFactorUseCase) VerifyChallenge(payload *request.TwoFactorLoginRequest) (*response.UserResponse, error) {
+	keys, err := utils.LoadKeySet(t.Viper)
+	if err != nil {
+		t.Log.Error("[TwoFactorUseCase.VerifyChallenge] " + err.Error())
+		return nil, err
+	}
+
+	token, err := jwt.Parse(payload.ChallengeToken, keys.Keyfunc)
+	if err != nil || !token.Valid {
+		t.Log.Warn("[TwoFactorUseCase.VerifyChallenge] Invalid challenge token")
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["token_use"] != utils.TokenUseTwoFactorChallenge {
+		t.Log.Warn("[TwoFactorUseCase.VerifyChallenge] Token is not a challenge token")
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+
+	jti, _ := claims["jti"].(string)
+	sub, _ := claims["sub"].(string)
+	userID, err := uuid.Parse(sub)
+	if err != nil || jti == "" {
+		t.Log.Warn("[TwoFactorUseCase.VerifyChallenge] Challenge token misses its claims")
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+
+	revoked, err := t.TokenRepository.IsAccessTokenRevoked(jti)
+	if err != nil {
+		t.Log.Error("[TwoFactorUseCase.VerifyChallenge] " + err.Error())
+		return nil, err
+	}
+
+	if revoked {
+		t.Log.Warn("[TwoFactorUseCase.VerifyChallenge] Challenge token already used")
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+
+	expiredAt := time.Now().Add(defaultTwoFactorChallengeTTL)
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		expiredAt = exp.Time
+	}
+
+	if err := t.TokenRepository.RevokeAccessToken(jti, userID, expiredAt); err != nil {
+		t.Log.Error("[TwoFactorUseCase.VerifyChallenge] " + err.Error())
+		return nil, err
+	}
+
+	user, err := t.UserRepository.FindById(userID)
+	if err != nil {
+		t.Log.Error("[TwoFactorUseCase.VerifyChallenge] " + err.Error())
+		return nil, err
+	}
+
+	if user == nil || user.Status != entity.USER_ACTIVE || !user.TwoFactorEnabled() {
+		t.Log.Warn("[TwoFactorUseCase.VerifyChallenge] User cannot complete the challenge")
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+
+	if err := t.checkSecondFactor(user, payload.Code); err != nil {
+		t.Log.Warn("[TwoFactorUseCase.VerifyChallenge] " + err.Error())
+		return nil, err
+	}
+
+	if err := t.LoginAttemptRepository.Reset(entity.LOGIN_ATTEMPT_EMAIL, normalizeEmail(user.Email)); err != nil {
+		t.Log.Error("[TwoFactorUseCase.VerifyChallenge] " + err.Error())
+		return nil, err
+	}
+
+	return t.UserDTO.ConvertEntityToUserResponse(user), nil
+}
+
+func (t *TwoFactorUseCase) findUser(userID uuid.UUID) (*entity.User, error) {
+	user, err := t.UserRepository.FindById(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+// checkSecondFactor verifies a TOTP or recovery code of the user. Wrong codes
+// are counted per user, whichever challenge or client IP they come from, and
+// lock the second factor for auth.two_factor.lockout_duration minutes once they
+// reach auth.two_factor.max_attempts.
+func (t *TwoFactorUseCase) checkSecondFactor(user *entity.User, code string) error {
+	maxAttempts := t.Viper.GetInt("auth.two_factor.max_attempts")
+	if maxAttempts <= 0 {
+		maxAttempts = defaultTwoFactorMaxAttempts
+	}
+
+	lockoutDuration := time.Duration(t.Viper.GetInt("auth.two_factor.lockout_duration")) * time.Minute
+	if lockoutDuration <= 0 {
+		lockoutDuration = defaultTwoFactorLockoutDuration
+	}
+
+	identifier := user.ID.String()
+	now := time.Now()
+
+	attempt, err := t.LoginAttemptRepository.FindByIdentifier(entity.LOGIN_ATTEMPT_TWO_FACTOR, identifier)
+	if err != nil {
+		return err
+	}
+
+	if attempt != nil && attempt.IsLocked(now) {
+		return &TooManyRequestsError{
+			Message:    "too many invalid two-factor codes, please try again later",
+			RetryAfter: attempt.LockedUntil.Sub(now),
+		}
+	}
+
+	ok, err := t.verifySecondFactor(user, code)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		return t.LoginAttemptRepository.Reset(entity.LOGIN_ATTEMPT_TWO_FACTOR, identifier)
+	}
+
+	attempt, err = t.LoginAttemptRepository.RecordFailure(entity.LOGIN_ATTEMPT_TWO_FACTOR, identifier, lockoutDuration)
+	if err != nil {
+		return err
+	}
+
+	if attempt.Failures >= maxAttempts {
+		t.Log.Warn("[TwoFactorUseCase.checkSecondFactor] Locking out two-factor authentication of user " + identifier)
+		if err := t.LoginAttemptRepository.Lock(entity.LOGIN_ATTEMPT_TWO_FACTOR, identifier, now.Add(lockoutDuration)); err != nil {
+			return err
+		}
+	}
+
+	return ErrInvalidTwoFactorCode
+}
+
+// verifySecondFactor accepts a TOTP code that was not used before, or an unused
+// recovery code which is consumed.
+func (t *TwoFactorUseCase) verifySecondFactor(user *entity.User, code string) (bool, error) {
+	if step, ok := utils.ValidateTOTP(user.TwoFactorSecret, code, time.Now(), twoFactorSkew); ok {
+		return t.Repository.ConsumeStep(user.ID, step)
+	}
+
+	return t.Repository.UseRecoveryCode(user.ID, utils.HashToken(normalizeRecoveryCode(code)))
+}
+
+// generateRecoveryCode returns a code such as "k3vq-7xm2a" that is easy to
+// type from a printout.
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, 5)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(buf))
+	return code[:4] + "-" + code[4:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
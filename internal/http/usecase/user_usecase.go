@@ -2,6 +2,11 @@ package usecase
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
 	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/dto"
@@ -9,47 +14,132 @@ import (
 	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/request"
 	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
 	"github.com/IlhamSetiaji/gift-redeem-be/internal/repository"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 	"golang.org/x/crypto/bcrypt"
-	"golang.org/x/exp/rand"
 )
 
+const (
+	defaultVerificationTokenTTL       = 15 * time.Minute
+	defaultVerificationResendInterval = 60 * time.Second
+	defaultVerificationMaxAttempts    = 5
+	defaultRegistrationRole           = "user"
+	defaultPasswordResetTokenTTL      = 30 * time.Minute
+	defaultPasswordResetInterval      = 60 * time.Second
+	defaultPasswordResetMaxAttempts   = 5
+	defaultLoginFailureWindow         = 15 * time.Minute
+	defaultLoginDelayAfter            = 3
+	defaultLoginBaseDelay             = 1 * time.Second
+	defaultLoginMaxDelay              = 60 * time.Second
+	defaultLoginLockoutThreshold      = 10
+	defaultLoginLockoutDuration       = 30 * time.Minute
+	defaultLoginIPDelayAfter          = 10
+	defaultLoginIPLockoutThreshold    = 100
+	defaultUnlockTokenTTL             = 30 * time.Minute
+	defaultUnlockMaxAttempts          = 5
+)
+
+var (
+	ErrUserAlreadyRegistered = errors.New("user already registered")
+	ErrInvalidUserToken      = errors.New("token is invalid or has expired")
+	ErrUserEmailTaken        = errors.New("email already used by another user")
+	ErrCannotModifySelf      = errors.New("you cannot perform this action on your own account")
+	ErrUserInactive          = errors.New("user account is inactive")
+	ErrInvalidCredentials    = errors.New("invalid credentials")
+	ErrEmailNotVerified      = errors.New("email not verified")
+	ErrUserOutranksActor     = errors.New("user holds roles or permissions you do not have")
+)
+
+// dummyPasswordHash is compared against when the email is unknown, so a login
+// takes as long for an unknown email as for a wrong password.
+var (
+	dummyPasswordHash     []byte
+	dummyPasswordHashOnce sync.Once
+)
+
+// TooManyRequestsError is returned when an action is throttled, telling the
+// caller how long to wait before trying again.
+type TooManyRequestsError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *TooManyRequestsError) Error() string {
+	return e.Message
+}
+
 type IUserUseCase interface {
-	Login(payload *request.UserLoginRequest) (*response.UserResponse, error)
+	Login(payload *request.UserLoginRequest, ip string) (*response.UserResponse, error)
+	UnlockAccount(payload *request.UnlockAccountRequest) error
 	Register(payload *request.UserRegisterRequest) (*response.UserResponse, error)
+	VerifyEmail(payload *request.VerifyEmailRequest) (*response.UserResponse, error)
+	ResendVerificationEmail(payload *request.ResendVerificationEmailRequest) error
+	ForgotPassword(payload *request.ForgotPasswordRequest) error
+	ResetPassword(payload *request.ResetPasswordRequest) error
 	FindByID(id uuid.UUID) (*response.UserResponse, error)
+	FindAllPaginated(page int, pageSize int, search string) (*[]response.UserResponse, int64, error)
+	UpdateUser(actorID uuid.UUID, id uuid.UUID, payload *request.UserRequest) (*response.UserResponse, error)
+	UpdateUserStatus(actorID uuid.UUID, id uuid.UUID, status entity.UserStatus) (*response.UserResponse, error)
+	DeleteUser(actorID uuid.UUID, id uuid.UUID) (bool, error)
 }
 
 type UserUseCase struct {
-	Log         *logrus.Logger
-	Repository  repository.IUserRepository
-	DTO         dto.IUserDTO
-	MailMessage messaging.IMailMessage
+	Log                    *logrus.Logger
+	Viper                  *viper.Viper
+	Repository             repository.IUserRepository
+	RoleRepository         repository.IRoleRepository
+	TokenRepository        repository.ITokenRepository
+	LoginAttemptRepository repository.ILoginAttemptRepository
+	DTO                    dto.IUserDTO
+	MailMessage            messaging.IMailMessage
 }
 
 func NewUserUseCase(
 	log *logrus.Logger,
+	viper *viper.Viper,
 	repository repository.IUserRepository,
+	roleRepository repository.IRoleRepository,
+	tokenRepository repository.ITokenRepository,
+	loginAttemptRepository repository.ILoginAttemptRepository,
 	dto dto.IUserDTO,
 	mailMessage messaging.IMailMessage,
 ) IUserUseCase {
 	return &UserUseCase{
-		Log:         log,
-		Repository:  repository,
-		DTO:         dto,
-		MailMessage: mailMessage,
+		Log:                    log,
+		Viper:                  viper,
+		Repository:             repository,
+		RoleRepository:         roleRepository,
+		TokenRepository:        tokenRepository,
+		LoginAttemptRepository: loginAttemptRepository,
+		DTO:                    dto,
+		MailMessage:            mailMessage,
 	}
 }
 
-func UserUseCaseFactory(log *logrus.Logger) IUserUseCase {
-	repository := repository.UserRepositoryFactory(log)
+func UserUseCaseFactory(log *logrus.Logger, viper *viper.Viper) IUserUseCase {
+	userRepository := repository.UserRepositoryFactory(log)
+	roleRepository := repository.RoleRepositoryFactory(log)
+	tokenRepository := repository.TokenRepositoryFactory(log)
+	loginAttemptRepository := repository.LoginAttemptRepositoryFactory(log)
 	dto := dto.UserDTOFactory(log)
 	mailMessage := messaging.MailMessageFactory(log)
-	return NewUserUseCase(log, repository, dto, mailMessage)
+	return NewUserUseCase(log, viper, userRepository, roleRepository, tokenRepository, loginAttemptRepository, dto, mailMessage)
 }
 
-func (u *UserUseCase) Login(payload *request.UserLoginRequest) (*response.UserResponse, error) {
+// Login checks the credentials of a user. Unknown emails and wrong passwords
+// both fail with ErrInvalidCredentials and count towards the throttling of the
+// email and the client IP; the account state is only revealed once the password
+// is correct.
+func (u *UserUseCase) Login(payload *request.UserLoginRequest, ip string) (*response.UserResponse, error) {
+	email := normalizeEmail(payload.Email)
+
+	if err := u.checkLoginThrottle(email, ip); err != nil {
+		u.Log.Warn("[UserUseCase.Login] " + err.Error())
+		return nil, err
+	}
+
 	user, err := u.Repository.FindByEmail(payload.Email)
 	if err != nil {
 		u.Log.Error("[UserUseCase.Login] " + err.Error())
@@ -58,22 +148,71 @@ func (u *UserUseCase) Login(payload *request.UserLoginRequest) (*response.UserRe
 
 	if user == nil {
 		u.Log.Warn("[UserUseCase.Login] User not found")
-		return nil, nil
+		bcrypt.CompareHashAndPassword(getDummyPasswordHash(), []byte(payload.Password))
+		if err := u.recordLoginFailure(email, ip, nil); err != nil {
+			u.Log.Error("[UserUseCase.Login] " + err.Error())
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(payload.Password)); err != nil {
+		u.Log.Warn("[UserUseCase.Login] Password not match")
+		if err := u.recordLoginFailure(email, ip, user); err != nil {
+			u.Log.Error("[UserUseCase.Login] " + err.Error())
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
+	}
+
+	// with 2FA the login only succeeds once the second factor is verified too, so
+	// the failures of the email are cleared by the challenge instead
+	if !user.TwoFactorEnabled() {
+		if err := u.LoginAttemptRepository.Reset(entity.LOGIN_ATTEMPT_EMAIL, email); err != nil {
+			u.Log.Error("[UserUseCase.Login] " + err.Error())
+			return nil, err
+		}
 	}
 
 	if user.EmailVerifiedAt.IsZero() {
 		u.Log.Warn("[UserUseCase.Login] User email not verified")
-		return nil, errors.New("email not verified")
+		return nil, ErrEmailNotVerified
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(payload.Password)); err != nil {
-		u.Log.Error("Password not match")
-		return nil, errors.New("email or password is incorrect")
+	if user.Status == entity.USER_INACTIVE {
+		u.Log.Warn("[UserUseCase.Login] User is inactive")
+		return nil, ErrUserInactive
 	}
 
 	return u.DTO.ConvertEntityToUserResponse(user), nil
 }
 
+// UnlockAccount lifts the lockout of an email with the code sent when it was
+// locked.
+func (u *UserUseCase) UnlockAccount(payload *request.UnlockAccountRequest) error {
+	maxAttempts := u.Viper.GetInt("auth.login_protection.unlock_max_attempts")
+	if maxAttempts <= 0 {
+		maxAttempts = defaultUnlockMaxAttempts
+	}
+
+	if _, err := u.checkUserToken(payload.Email, payload.Token, entity.UserTokenUnlockAccount, maxAttempts); err != nil {
+		u.Log.Warn("[UserUseCase.UnlockAccount] " + err.Error())
+		return err
+	}
+
+	if err := u.LoginAttemptRepository.Reset(entity.LOGIN_ATTEMPT_EMAIL, normalizeEmail(payload.Email)); err != nil {
+		u.Log.Error("[UserUseCase.UnlockAccount] " + err.Error())
+		return err
+	}
+
+	if err := u.Repository.DeleteUserTokens(payload.Email, entity.UserTokenUnlockAccount); err != nil {
+		u.Log.Error("[UserUseCase.UnlockAccount] " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
 func (u *UserUseCase) FindByID(id uuid.UUID) (*response.UserResponse, error) {
 	user, err := u.Repository.FindById(id)
 	if err != nil {
@@ -89,6 +228,221 @@ func (u *UserUseCase) FindByID(id uuid.UUID) (*response.UserResponse, error) {
 	return u.DTO.ConvertEntityToUserResponse(user), nil
 }
 
+func (u *UserUseCase) FindAllPaginated(page int, pageSize int, search string) (*[]response.UserResponse, int64, error) {
+	users, total, err := u.Repository.FindAllPaginated(page, pageSize, search)
+	if err != nil {
+		u.Log.Error("[UserUseCase.FindAllPaginated] " + err.Error())
+		return nil, 0, err
+	}
+
+	return u.DTO.ConvertEntitiesToUserResponses(users), total, nil
+}
+
+// UpdateUser edits the profile of another user. Roles are assigned by
+// superadmins and the status changes through activate and deactivate, so
+// neither can be set here.
+func (u *UserUseCase) UpdateUser(actorID uuid.UUID, id uuid.UUID, payload *request.UserRequest) (*response.UserResponse, error) {
+	if actorID == id {
+		u.Log.Warn("[UserUseCase.UpdateUser] User tried to update own account")
+		return nil, ErrCannotModifySelf
+	}
+
+	user, err := u.Repository.FindById(id)
+	if err != nil {
+		u.Log.Error("[UserUseCase.UpdateUser] " + err.Error())
+		return nil, err
+	}
+
+	if user == nil {
+		u.Log.Warn("[UserUseCase.UpdateUser] User not found")
+		return nil, nil
+	}
+
+	// changing the email or password of a user takes over their account, so it
+	// is only allowed on users the actor could not gain anything from
+	outranked, err := u.outranks(actorID, user)
+	if err != nil {
+		u.Log.Error("[UserUseCase.UpdateUser] " + err.Error())
+		return nil, err
+	}
+
+	if !outranked {
+		u.Log.Warn("[UserUseCase.UpdateUser] User outranks the actor")
+		return nil, ErrUserOutranksActor
+	}
+
+	if payload.Email != "" && payload.Email != user.Email {
+		existing, err := u.Repository.FindByEmail(payload.Email)
+		if err != nil {
+			u.Log.Error("[UserUseCase.UpdateUser] " + err.Error())
+			return nil, err
+		}
+
+		if existing != nil {
+			u.Log.Warn("[UserUseCase.UpdateUser] Email already used")
+			return nil, ErrUserEmailTaken
+		}
+	}
+
+	// empty fields are left untouched by the repository
+	var updated = &entity.User{
+		ID:       user.ID,
+		Name:     payload.Name,
+		Username: payload.Username,
+		Email:    payload.Email,
+		Gender:   payload.Gender,
+	}
+
+	if payload.Password != "" {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(payload.Password), bcrypt.DefaultCost)
+		if err != nil {
+			u.Log.Error("[UserUseCase.UpdateUser] " + err.Error())
+			return nil, err
+		}
+		updated.Password = string(hashedPassword)
+	}
+
+	updated, err = u.Repository.UpdateUser(updated)
+	if err != nil {
+		u.Log.Error("[UserUseCase.UpdateUser] " + err.Error())
+		return nil, err
+	}
+
+	// sessions started with the old password must not outlive it
+	if payload.Password != "" {
+		if err := u.TokenRepository.RevokeAllRefreshTokens(id); err != nil {
+			u.Log.Error("[UserUseCase.UpdateUser] " + err.Error())
+			return nil, err
+		}
+	}
+
+	return u.DTO.ConvertEntityToUserResponse(updated), nil
+}
+
+// outranks reports whether the actor holds every role and permission of user.
+func (u *UserUseCase) outranks(actorID uuid.UUID, user *entity.User) (bool, error) {
+	actor, err := u.Repository.FindById(actorID)
+	if err != nil {
+		return false, err
+	}
+
+	return actor != nil && holdsAllRolesAndPermissions(actor, user), nil
+}
+
+// holdsAllRolesAndPermissions reports whether the active roles of actor grant
+// every role and every permission of user.
+func holdsAllRolesAndPermissions(actor *entity.User, user *entity.User) bool {
+	roles := make(map[string]bool)
+	permissions := make(map[string]bool)
+	for _, role := range actor.Roles {
+		if role.Status == entity.ROLE_INACTIVE {
+			continue
+		}
+		roles[role.Name] = true
+		for _, permission := range role.Permissions {
+			permissions[permission.Name] = true
+		}
+	}
+
+	for _, role := range user.Roles {
+		if !roles[role.Name] {
+			return false
+		}
+		for _, permission := range role.Permissions {
+			if !permissions[permission.Name] {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (u *UserUseCase) UpdateUserStatus(actorID uuid.UUID, id uuid.UUID, status entity.UserStatus) (*response.UserResponse, error) {
+	if actorID == id {
+		u.Log.Warn("[UserUseCase.UpdateUserStatus] User tried to change own status")
+		return nil, ErrCannotModifySelf
+	}
+
+	user, err := u.Repository.FindById(id)
+	if err != nil {
+		u.Log.Error("[UserUseCase.UpdateUserStatus] " + err.Error())
+		return nil, err
+	}
+
+	if user == nil {
+		u.Log.Warn("[UserUseCase.UpdateUserStatus] User not found")
+		return nil, nil
+	}
+
+	outranked, err := u.outranks(actorID, user)
+	if err != nil {
+		u.Log.Error("[UserUseCase.UpdateUserStatus] " + err.Error())
+		return nil, err
+	}
+
+	if !outranked {
+		u.Log.Warn("[UserUseCase.UpdateUserStatus] User outranks the actor")
+		return nil, ErrUserOutranksActor
+	}
+
+	if err := u.Repository.UpdateUserStatus(id, status); err != nil {
+		u.Log.Error("[UserUseCase.UpdateUserStatus] " + err.Error())
+		return nil, err
+	}
+
+	// a deactivated user must not be able to refresh their way back in
+	if status == entity.USER_INACTIVE {
+		if err := u.TokenRepository.RevokeAllRefreshTokens(id); err != nil {
+			u.Log.Error("[UserUseCase.UpdateUserStatus] " + err.Error())
+			return nil, err
+		}
+	}
+
+	return u.FindByID(id)
+}
+
+func (u *UserUseCase) DeleteUser(actorID uuid.UUID, id uuid.UUID) (bool, error) {
+	if actorID == id {
+		u.Log.Warn("[UserUseCase.DeleteUser] User tried to delete own account")
+		return false, ErrCannotModifySelf
+	}
+
+	user, err := u.Repository.FindById(id)
+	if err != nil {
+		u.Log.Error("[UserUseCase.DeleteUser] " + err.Error())
+		return false, err
+	}
+
+	if user == nil {
+		u.Log.Warn("[UserUseCase.DeleteUser] User not found")
+		return false, nil
+	}
+
+	outranked, err := u.outranks(actorID, user)
+	if err != nil {
+		u.Log.Error("[UserUseCase.DeleteUser] " + err.Error())
+		return false, err
+	}
+
+	if !outranked {
+		u.Log.Warn("[UserUseCase.DeleteUser] User outranks the actor")
+		return false, ErrUserOutranksActor
+	}
+
+	if err := u.Repository.DeleteUser(id); err != nil {
+		u.Log.Error("[UserUseCase.DeleteUser] " + err.Error())
+		return false, err
+	}
+
+	if err := u.TokenRepository.RevokeAllRefreshTokens(id); err != nil {
+		u.Log.Error("[UserUseCase.DeleteUser] " + err.Error())
+		return false, err
+	}
+
+	return true, nil
+}
+
 func (u *UserUseCase) Register(payload *request.UserRegisterRequest) (*response.UserResponse, error) {
 	user, err := u.Repository.FindByEmail(payload.Email)
 	if err != nil {
@@ -98,7 +452,23 @@ func (u *UserUseCase) Register(payload *request.UserRegisterRequest) (*response.
 
 	if user != nil {
 		u.Log.Warn("[UserUseCase.Register] User already registered")
-		return nil, errors.New("user already registered")
+		return nil, ErrUserAlreadyRegistered
+	}
+
+	roleName := u.Viper.GetString("auth.registration.default_role")
+	if roleName == "" {
+		roleName = defaultRegistrationRole
+	}
+
+	role, err := u.RoleRepository.FindByName(roleName)
+	if err != nil {
+		u.Log.Error("[UserUseCase.Register] " + err.Error())
+		return nil, err
+	}
+
+	if role == nil {
+		u.Log.Error("[UserUseCase.Register] Default role not found: " + roleName)
+		return nil, errors.New("default role " + roleName + " not found")
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(payload.Password), bcrypt.DefaultCost)
@@ -116,27 +486,476 @@ func (u *UserUseCase) Register(payload *request.UserRegisterRequest) (*response.
 		Status:   entity.USER_PENDING,
 	}
 
-	if _, err := u.Repository.CreateUser(user, payload.RoleIDs); err != nil {
+	if _, err := u.Repository.CreateUser(user, []uuid.UUID{role.ID}); err != nil {
 		u.Log.Error("[UserUseCase.Register] " + err.Error())
 		return nil, err
 	}
 
-	randomIntToken := rand.Intn(100000)
-	if err := u.Repository.CreateUserToken(payload.Email, randomIntToken); err != nil {
+	if err := u.sendVerificationEmail(payload.Email); err != nil {
 		u.Log.Error("[UserUseCase.Register] " + err.Error())
 		return nil, err
 	}
 
+	return u.DTO.ConvertEntityToUserResponse(user), nil
+}
+
+// VerifyEmail verifies the email of a pending user. Unknown emails, verified
+// emails and wrong codes all fail with ErrInvalidUserToken, so the response
+// does not reveal which accounts exist.
+func (u *UserUseCase) VerifyEmail(payload *request.VerifyEmailRequest) (*response.UserResponse, error) {
+	user, err := u.Repository.FindByEmail(payload.Email)
+	if err != nil {
+		u.Log.Error("[UserUseCase.VerifyEmail] " + err.Error())
+		return nil, err
+	}
+
+	if user == nil {
+		u.Log.Warn("[UserUseCase.VerifyEmail] User not found")
+		return nil, ErrInvalidUserToken
+	}
+
+	if !user.EmailVerifiedAt.IsZero() {
+		u.Log.Warn("[UserUseCase.VerifyEmail] User email already verified")
+		return nil, ErrInvalidUserToken
+	}
+
+	maxAttempts := u.Viper.GetInt("auth.verification.max_attempts")
+	if maxAttempts <= 0 {
+		maxAttempts = defaultVerificationMaxAttempts
+	}
+
+	if _, err := u.checkUserToken(payload.Email, payload.Token, entity.UserTokenVerification, maxAttempts); err != nil {
+		u.Log.Warn("[UserUseCase.VerifyEmail] " + err.Error())
+		return nil, err
+	}
+
+	if err := u.Repository.VerifyUserEmail(user); err != nil {
+		u.Log.Error("[UserUseCase.VerifyEmail] " + err.Error())
+		return nil, err
+	}
+
+	return u.FindByID(user.ID)
+}
+
+func (u *UserUseCase) ResendVerificationEmail(payload *request.ResendVerificationEmailRequest) error {
+	user, err := u.Repository.FindByEmail(payload.Email)
+	if err != nil {
+		u.Log.Error("[UserUseCase.ResendVerificationEmail] " + err.Error())
+		return err
+	}
+
+	// do not reveal whether the email is registered or already verified
+	if user == nil {
+		u.Log.Warn("[UserUseCase.ResendVerificationEmail] User not found")
+		return nil
+	}
+
+	if !user.EmailVerifiedAt.IsZero() {
+		u.Log.Warn("[UserUseCase.ResendVerificationEmail] User email already verified")
+		return nil
+	}
+
+	latestToken, err := u.Repository.FindLatestUserToken(payload.Email, entity.UserTokenVerification)
+	if err != nil {
+		u.Log.Error("[UserUseCase.ResendVerificationEmail] " + err.Error())
+		return err
+	}
+
+	if latestToken != nil {
+		interval := time.Duration(u.Viper.GetInt("auth.verification.resend_interval")) * time.Second
+		if interval <= 0 {
+			interval = defaultVerificationResendInterval
+		}
+
+		// throttled silently, like unknown emails, so pending accounts cannot be told apart
+		if time.Since(latestToken.CreatedAt) < interval {
+			u.Log.Warn("[UserUseCase.ResendVerificationEmail] Verification email requested too often")
+			return nil
+		}
+	}
+
+	if err := u.sendVerificationEmail(payload.Email); err != nil {
+		u.Log.Error("[UserUseCase.ResendVerificationEmail] " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (u *UserUseCase) ForgotPassword(payload *request.ForgotPasswordRequest) error {
+	user, err := u.Repository.FindByEmail(payload.Email)
+	if err != nil {
+		u.Log.Error("[UserUseCase.ForgotPassword] " + err.Error())
+		return err
+	}
+
+	// respond the same way for unknown emails so accounts cannot be enumerated
+	if user == nil {
+		u.Log.Warn("[UserUseCase.ForgotPassword] User not found")
+		return nil
+	}
+
+	latestToken, err := u.Repository.FindLatestUserToken(payload.Email, entity.UserTokenResetPassword)
+	if err != nil {
+		u.Log.Error("[UserUseCase.ForgotPassword] " + err.Error())
+		return err
+	}
+
+	interval := time.Duration(u.Viper.GetInt("auth.password_reset.resend_interval")) * time.Second
+	if interval <= 0 {
+		interval = defaultPasswordResetInterval
+	}
+
+	if latestToken != nil && time.Since(latestToken.CreatedAt) < interval {
+		u.Log.Warn("[UserUseCase.ForgotPassword] Password reset requested too often")
+		return nil
+	}
+
+	ttl := time.Duration(u.Viper.GetInt("auth.password_reset.token_ttl")) * time.Minute
+	if ttl <= 0 {
+		ttl = defaultPasswordResetTokenTTL
+	}
+
+	token, err := utils.GenerateNumericToken(8)
+	if err != nil {
+		u.Log.Error("[UserUseCase.ForgotPassword] " + err.Error())
+		return err
+	}
+
+	// only the most recently issued reset token is ever valid
+	if err := u.Repository.DeleteUserTokens(payload.Email, entity.UserTokenResetPassword); err != nil {
+		u.Log.Error("[UserUseCase.ForgotPassword] " + err.Error())
+		return err
+	}
+
+	if err := u.Repository.CreateUserToken(payload.Email, token, entity.UserTokenResetPassword, time.Now().Add(ttl)); err != nil {
+		u.Log.Error("[UserUseCase.ForgotPassword] " + err.Error())
+		return err
+	}
+
 	if _, err := u.MailMessage.SendMail(&request.MailRequest{
 		Email:   payload.Email,
-		Subject: "Email Verification",
-		Body:    "Your verification code is " + string(randomIntToken),
-		From:    "ilham.ahmadz18@gmail.com",
+		Subject: "Reset Password",
+		Body:    "Your password reset code is " + strconv.Itoa(token) + ". It expires in " + strconv.Itoa(int(ttl.Minutes())) + " minutes.",
+		From:    u.Viper.GetString("mail.from"),
 		To:      payload.Email,
 	}); err != nil {
-		u.Log.Error("[UserUseCase.Register] " + err.Error())
+		u.Log.Error("[UserUseCase.ForgotPassword] " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (u *UserUseCase) ResetPassword(payload *request.ResetPasswordRequest) error {
+	maxAttempts := u.Viper.GetInt("auth.password_reset.max_attempts")
+	if maxAttempts <= 0 {
+		maxAttempts = defaultPasswordResetMaxAttempts
+	}
+
+	if _, err := u.checkUserToken(payload.Email, payload.Token, entity.UserTokenResetPassword, maxAttempts); err != nil {
+		u.Log.Warn("[UserUseCase.ResetPassword] " + err.Error())
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(payload.Password), bcrypt.DefaultCost)
+	if err != nil {
+		u.Log.Error("[UserUseCase.ResetPassword] " + err.Error())
+		return err
+	}
+
+	if err := u.Repository.ResetPassword(payload.Email, string(hashedPassword)); err != nil {
+		u.Log.Error("[UserUseCase.ResetPassword] " + err.Error())
+		return err
+	}
+
+	// the reset proves ownership of the email, so a running lockout is lifted
+	if err := u.LoginAttemptRepository.Reset(entity.LOGIN_ATTEMPT_EMAIL, normalizeEmail(payload.Email)); err != nil {
+		u.Log.Error("[UserUseCase.ResetPassword] " + err.Error())
+		return err
+	}
+
+	// sign out every existing session since the old password may be compromised
+	user, err := u.Repository.FindByEmail(payload.Email)
+	if err != nil {
+		u.Log.Error("[UserUseCase.ResetPassword] " + err.Error())
+		return err
+	}
+
+	if user != nil {
+		if err := u.TokenRepository.RevokeAllRefreshTokens(user.ID); err != nil {
+			u.Log.Error("[UserUseCase.ResetPassword] " + err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkUserToken returns the unexpired token of the type issued to the email
+// with the given code. Every wrong code counts against all tokens of the type
+// for the email, and after maxAttempts wrong codes they are deleted, so a code
+// cannot be guessed before it expires.
+func (u *UserUseCase) checkUserToken(email string, code int, tokenType entity.UserTokenType, maxAttempts int) (*entity.UserToken, error) {
+	userToken, err := u.Repository.FindUserToken(email, code, tokenType)
+	if err != nil {
 		return nil, err
 	}
 
-	return u.DTO.ConvertEntityToUserResponse(user), nil
+	if userToken != nil && time.Now().Before(userToken.ExpiredAt) && userToken.Attempts < maxAttempts {
+		return userToken, nil
+	}
+
+	if err := u.Repository.RecordUserTokenFailure(email, tokenType, maxAttempts); err != nil {
+		return nil, err
+	}
+
+	return nil, ErrInvalidUserToken
+}
+
+func (u *UserUseCase) sendVerificationEmail(email string) error {
+	ttl := time.Duration(u.Viper.GetInt("auth.verification.token_ttl")) * time.Minute
+	if ttl <= 0 {
+		ttl = defaultVerificationTokenTTL
+	}
+
+	token, err := utils.GenerateNumericToken(6)
+	if err != nil {
+		return err
+	}
+
+	// only the most recently issued verification token is ever valid
+	if err := u.Repository.DeleteUserTokens(email, entity.UserTokenVerification); err != nil {
+		return err
+	}
+
+	if err := u.Repository.CreateUserToken(email, token, entity.UserTokenVerification, time.Now().Add(ttl)); err != nil {
+		return err
+	}
+
+	if _, err := u.MailMessage.SendMail(&request.MailRequest{
+		Email:   email,
+		Subject: "Email Verification",
+		Body:    "Your verification code is " + strconv.Itoa(token),
+		From:    u.Viper.GetString("mail.from"),
+		To:      email,
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// loginPolicy holds the brute-force protection settings of
+// auth.login_protection.
+type loginPolicy struct {
+	window             time.Duration
+	delayAfter         int
+	baseDelay          time.Duration
+	maxDelay           time.Duration
+	lockoutThreshold   int
+	lockoutDuration    time.Duration
+	ipDelayAfter       int
+	ipLockoutThreshold int
+}
+
+func (u *UserUseCase) loginPolicy() loginPolicy {
+	policy := loginPolicy{
+		window:             time.Duration(u.Viper.GetInt("auth.login_protection.failure_window")) * time.Minute,
+		delayAfter:         u.Viper.GetInt("auth.login_protection.delay_after"),
+		baseDelay:          time.Duration(u.Viper.GetInt("auth.login_protection.base_delay")) * time.Second,
+		maxDelay:           time.Duration(u.Viper.GetInt("auth.login_protection.max_delay")) * time.Second,
+		lockoutThreshold:   u.Viper.GetInt("auth.login_protection.lockout_threshold"),
+		lockoutDuration:    time.Duration(u.Viper.GetInt("auth.login_protection.lockout_duration")) * time.Minute,
+		ipDelayAfter:       u.Viper.GetInt("auth.login_protection.ip_delay_after"),
+		ipLockoutThreshold: u.Viper.GetInt("auth.login_protection.ip_lockout_threshold"),
+	}
+
+	if policy.window <= 0 {
+		policy.window = defaultLoginFailureWindow
+	}
+	if policy.delayAfter <= 0 {
+		policy.delayAfter = defaultLoginDelayAfter
+	}
+	if policy.baseDelay <= 0 {
+		policy.baseDelay = defaultLoginBaseDelay
+	}
+	if policy.maxDelay <= 0 {
+		policy.maxDelay = defaultLoginMaxDelay
+	}
+	if policy.lockoutThreshold <= 0 {
+		policy.lockoutThreshold = defaultLoginLockoutThreshold
+	}
+	if policy.lockoutDuration <= 0 {
+		policy.lockoutDuration = defaultLoginLockoutDuration
+	}
+	if policy.ipDelayAfter <= 0 {
+		policy.ipDelayAfter = defaultLoginIPDelayAfter
+	}
+	if policy.ipLockoutThreshold <= 0 {
+		policy.ipLockoutThreshold = defaultLoginIPLockoutThreshold
+	}
+
+	return policy
+}
+
+// delay is how long to wait after the last failure before the next attempt. It
+// doubles with every failure past delayAfter, up to maxDelay.
+func (p loginPolicy) delay(failures int, delayAfter int) time.Duration {
+	if failures < delayAfter {
+		return 0
+	}
+
+	delay := p.baseDelay
+	for i := delayAfter; i < failures && delay < p.maxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > p.maxDelay {
+		delay = p.maxDelay
+	}
+
+	return delay
+}
+
+// checkLoginThrottle refuses a login while the email or the IP is locked out or
+// still has to wait after its last failure.
+func (u *UserUseCase) checkLoginThrottle(email string, ip string) error {
+	policy := u.loginPolicy()
+	now := time.Now()
+
+	checks := []struct {
+		scope      entity.LoginAttemptScope
+		identifier string
+		delayAfter int
+	}{
+		{entity.LOGIN_ATTEMPT_EMAIL, email, policy.delayAfter},
+		{entity.LOGIN_ATTEMPT_IP, ip, policy.ipDelayAfter},
+	}
+
+	for _, check := range checks {
+		if check.identifier == "" {
+			continue
+		}
+
+		attempt, err := u.LoginAttemptRepository.FindByIdentifier(check.scope, check.identifier)
+		if err != nil {
+			return err
+		}
+
+		if attempt == nil {
+			continue
+		}
+
+		if attempt.IsLocked(now) {
+			message := "too many failed login attempts, please try again later"
+			if check.scope == entity.LOGIN_ATTEMPT_EMAIL {
+				message = "too many failed login attempts, the account is temporarily locked. Use the code sent to your email to unlock it or try again later"
+			}
+			return &TooManyRequestsError{
+				Message:    message,
+				RetryAfter: attempt.LockedUntil.Sub(now),
+			}
+		}
+
+		if now.Sub(attempt.LastFailedAt) > policy.window {
+			continue
+		}
+
+		if wait := attempt.LastFailedAt.Add(policy.delay(attempt.Failures, check.delayAfter)).Sub(now); wait > 0 {
+			return &TooManyRequestsError{
+				Message:    fmt.Sprintf("too many failed login attempts, please wait %d seconds before trying again", int(wait.Seconds())+1),
+				RetryAfter: wait,
+			}
+		}
+	}
+
+	return nil
+}
+
+// recordLoginFailure counts a failed login for the email and the IP and locks
+// them out once they reach their threshold. The owner of a locked account, if
+// there is one, gets an email with a code to unlock it.
+func (u *UserUseCase) recordLoginFailure(email string, ip string, user *entity.User) error {
+	policy := u.loginPolicy()
+
+	attempt, err := u.LoginAttemptRepository.RecordFailure(entity.LOGIN_ATTEMPT_EMAIL, email, policy.window)
+	if err != nil {
+		return err
+	}
+
+	if attempt.Failures >= policy.lockoutThreshold {
+		u.Log.Warn("[UserUseCase.recordLoginFailure] Locking out email " + email)
+		if err := u.LoginAttemptRepository.Lock(entity.LOGIN_ATTEMPT_EMAIL, email, time.Now().Add(policy.lockoutDuration)); err != nil {
+			return err
+		}
+
+		if user != nil {
+			if err := u.sendUnlockEmail(user.Email, policy.lockoutDuration); err != nil {
+				return err
+			}
+		}
+	}
+
+	if ip == "" {
+		return nil
+	}
+
+	attempt, err = u.LoginAttemptRepository.RecordFailure(entity.LOGIN_ATTEMPT_IP, ip, policy.window)
+	if err != nil {
+		return err
+	}
+
+	if attempt.Failures >= policy.ipLockoutThreshold {
+		u.Log.Warn("[UserUseCase.recordLoginFailure] Locking out IP " + ip)
+		if err := u.LoginAttemptRepository.Lock(entity.LOGIN_ATTEMPT_IP, ip, time.Now().Add(policy.lockoutDuration)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (u *UserUseCase) sendUnlockEmail(email string, lockoutDuration time.Duration) error {
+	ttl := time.Duration(u.Viper.GetInt("auth.login_protection.unlock_token_ttl")) * time.Minute
+	if ttl <= 0 {
+		ttl = defaultUnlockTokenTTL
+	}
+
+	token, err := utils.GenerateNumericToken(8)
+	if err != nil {
+		return err
+	}
+
+	if err := u.Repository.DeleteUserTokens(email, entity.UserTokenUnlockAccount); err != nil {
+		return err
+	}
+
+	if err := u.Repository.CreateUserToken(email, token, entity.UserTokenUnlockAccount, time.Now().Add(ttl)); err != nil {
+		return err
+	}
+
+	if _, err := u.MailMessage.SendMail(&request.MailRequest{
+		Email:   email,
+		Subject: "Account Locked",
+		Body: "Your account has been locked for " + strconv.Itoa(int(lockoutDuration.Minutes())) + " minutes after too many failed sign in attempts. " +
+			"Your unlock code is " + strconv.Itoa(token) + ". If these attempts were not yours, consider resetting your password.",
+		From: u.Viper.GetString("mail.from"),
+		To:   email,
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func getDummyPasswordHash() []byte {
+	dummyPasswordHashOnce.Do(func() {
+		dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	})
+	return dummyPasswordHash
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
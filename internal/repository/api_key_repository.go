@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// apiKeyTouchInterval limits how often the last used timestamp of a key is
+// written, a busy script would otherwise update the row on every request.
+const apiKeyTouchInterval = time.Minute
+
+type IAPIKeyRepository interface {
+	FindAllByUserPaginated(userID uuid.UUID, page int, pageSize int, search string) (*[]entity.APIKey, int64, error)
+	FindByIdAndUser(id uuid.UUID, userID uuid.UUID) (*entity.APIKey, error)
+	FindByHash(keyHash string) (*entity.APIKey, error)
+	CreateAPIKey(apiKey *entity.APIKey) (*entity.APIKey, error)
+	RevokeAPIKey(id uuid.UUID) error
+	TouchLastUsed(id uuid.UUID, usedAt time.Time) error
+}
+
+type APIKeyRepository struct {
+	Log *logrus.Logger
+	DB  *gorm.DB
+}
+
+func NewAPIKeyRepository(log *logrus.Logger, db *gorm.DB) IAPIKeyRepository {
+	return &APIKeyRepository{
+		Log: log,
+		DB:  db,
+	}
+}
+
+func APIKeyRepositoryFactory(log *logrus.Logger) IAPIKeyRepository {
+	db := config.NewDatabase()
+	return NewAPIKeyRepository(log, db)
+}
+
+func (r *APIKeyRepository) FindAllByUserPaginated(userID uuid.UUID, page int, pageSize int, search string) (*[]entity.APIKey, int64, error) {
+	var apiKeys []entity.APIKey
+	var total int64
+
+	query := r.DB.Model(&entity.APIKey{}).Where("user_id = ?", userID)
+
+	if search != "" {
+		query = query.Where("name LIKE ? OR prefix LIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		r.Log.Error("[APIKeyRepository.FindAllByUserPaginated] " + err.Error())
+		return nil, 0, errors.New("[APIKeyRepository.FindAllByUserPaginated] " + err.Error())
+	}
+
+	if err := query.Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&apiKeys).Error; err != nil {
+		r.Log.Error("[APIKeyRepository.FindAllByUserPaginated] " + err.Error())
+		return nil, 0, errors.New("[APIKeyRepository.FindAllByUserPaginated] " + err.Error())
+	}
+
+	return &apiKeys, total, nil
+}
+
+func (r *APIKeyRepository) FindByIdAndUser(id uuid.UUID, userID uuid.UUID) (*entity.APIKey, error) {
+	var apiKey entity.APIKey
+	err := r.DB.Where("id = ? AND user_id = ?", id, userID).First(&apiKey).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Warn("[APIKeyRepository.FindByIdAndUser] API key not found")
+			return nil, nil
+		} else {
+			r.Log.Error("[APIKeyRepository.FindByIdAndUser] " + err.Error())
+			return nil, errors.New("[APIKeyRepository.FindByIdAndUser] " + err.Error())
+		}
+	}
+	return &apiKey, nil
+}
+
+func (r *APIKeyRepository) FindByHash(keyHash string) (*entity.APIKey, error) {
+	var apiKey entity.APIKey
+	err := r.DB.Where("key_hash = ?", keyHash).First(&apiKey).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Warn("[APIKeyRepository.FindByHash] API key not found")
+			return nil, nil
+		} else {
+			r.Log.Error("[APIKeyRepository.FindByHash] " + err.Error())
+			return nil, errors.New("[APIKeyRepository.FindByHash] " + err.Error())
+		}
+	}
+	return &apiKey, nil
+}
+
+func (r *APIKeyRepository) CreateAPIKey(apiKey *entity.APIKey) (*entity.APIKey, error) {
+	if err := r.DB.Omit(clause.Associations).Create(apiKey).Error; err != nil {
+		r.Log.Error("[APIKeyRepository.CreateAPIKey] " + err.Error())
+		return nil, errors.New("[APIKeyRepository.CreateAPIKey] " + err.Error())
+	}
+
+	return apiKey, nil
+}
+
+func (r *APIKeyRepository) RevokeAPIKey(id uuid.UUID) error {
+	if err := r.DB.Model(&entity.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		r.Log.Error("[APIKeyRepository.RevokeAPIKey] " + err.Error())
+		return errors.New("[APIKeyRepository.RevokeAPIKey] " + err.Error())
+	}
+	return nil
+}
+
+// TouchLastUsed records that the key was used, at most once per
+// apiKeyTouchInterval.
+func (r *APIKeyRepository) TouchLastUsed(id uuid.UUID, usedAt time.Time) error {
+	if err := r.DB.Model(&entity.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, usedAt.Add(-apiKeyTouchInterval)).
+		Update("last_used_at", usedAt).Error; err != nil {
+		r.Log.Error("[APIKeyRepository.TouchLastUsed] " + err.Error())
+		return errors.New("[APIKeyRepository.TouchLastUsed] " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// giftCodeImportBatchSize is how many codes are inserted per statement.
+const giftCodeImportBatchSize = 500
+
+type IGiftCodeRepository interface {
+	FindAllByGiftPaginated(giftID uuid.UUID, status string, codeHash string, page int, pageSize int) (*[]entity.GiftCode, int64, error)
+	FindByIdAndGift(id uuid.UUID, giftID uuid.UUID) (*entity.GiftCode, error)
+	ImportCodes(codes []entity.GiftCode) (int64, error)
+	VoidCode(id uuid.UUID) (bool, error)
+	VoidCodesOfExpiredGifts(expiredBefore time.Time) (int64, error)
+	FindAllNotUnderKey(keyID string, limit int) (*[]entity.GiftCode, error)
+	UpdateEncryption(code *entity.GiftCode) error
+}
+
+type GiftCodeRepository struct {
+	Log *logrus.Logger
+	DB  *gorm.DB
+}
+
+func NewGiftCodeRepository(log *logrus.Logger, db *gorm.DB) IGiftCodeRepository {
+	return &GiftCodeRepository{
+		Log: log,
+		DB:  db,
+	}
+}
+
+func GiftCodeRepositoryFactory(log *logrus.Logger) IGiftCodeRepository {
+	db := config.NewDatabase()
+	return NewGiftCodeRepository(log, db)
+}
+
+// FindAllByGiftPaginated optionally narrows the codes down to the one with the
+// given hash, codes cannot be searched otherwise since they are encrypted.
+func (r *GiftCodeRepository) FindAllByGiftPaginated(giftID uuid.UUID, status string, codeHash string, page int, pageSize int) (*[]entity.GiftCode, int64, error) {
+	var codes []entity.GiftCode
+	var total int64
+
+	query := r.DB.Model(&entity.GiftCode{}).Where("gift_id = ?", giftID)
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if codeHash != "" {
+		query = query.Where("code_hash = ?", codeHash)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		r.Log.Error("[GiftCodeRepository.FindAllByGiftPaginated] " + err.Error())
+		return nil, 0, errors.New("[GiftCodeRepository.FindAllByGiftPaginated] " + err.Error())
+	}
+
+	if err := query.Order("created_at DESC").Order("id").Offset((page - 1) * pageSize).Limit(pageSize).Find(&codes).Error; err != nil {
+		r.Log.Error("[GiftCodeRepository.FindAllByGiftPaginated] " + err.Error())
+		return nil, 0, errors.New("[GiftCodeRepository.FindAllByGiftPaginated] " + err.Error())
+	}
+
+	return &codes, total, nil
+}
+
+func (r *GiftCodeRepository) FindByIdAndGift(id uuid.UUID, giftID uuid.UUID) (*entity.GiftCode, error) {
+	var code entity.GiftCode
+	err := r.DB.Where("id = ? AND gift_id = ?", id, giftID).First(&code).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Warn("[GiftCodeRepository.FindByIdAndGift] Gift code not found")
+			return nil, nil
+		} else {
+			r.Log.Error("[GiftCodeRepository.FindByIdAndGift] " + err.Error())
+			return nil, errors.New("[GiftCodeRepository.FindByIdAndGift] " + err.Error())
+		}
+	}
+	return &code, nil
+}
+
+// ImportCodes stores the codes in one transaction, skipping codes that exist
+// already, and returns how many were actually stored.
+func (r *GiftCodeRepository) ImportCodes(codes []entity.GiftCode) (int64, error) {
+	tx := r.DB.Begin()
+	if tx.Error != nil {
+		return 0, errors.New("[GiftCodeRepository.ImportCodes] failed to begin transaction: " + tx.Error.Error())
+	}
+
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Omit(clause.Associations).CreateInBatches(&codes, giftCodeImportBatchSize)
+	if result.Error != nil {
+		tx.Rollback()
+		r.Log.Error("[GiftCodeRepository.ImportCodes] " + result.Error.Error())
+		return 0, errors.New("[GiftCodeRepository.ImportCodes] " + result.Error.Error())
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[GiftCodeRepository.ImportCodes] failed to commit transaction: " + err.Error())
+		return 0, errors.New("[GiftCodeRepository.ImportCodes] failed to commit transaction: " + err.Error())
+	}
+
+	return result.RowsAffected, nil
+}
+
+// VoidCode withdraws an available code. It returns false when the code is no
+// longer available, for instance because it was issued in the meantime.
+func (r *GiftCodeRepository) VoidCode(id uuid.UUID) (bool, error) {
+	result := r.DB.Model(&entity.GiftCode{}).
+		Where("id = ? AND status = ?", id, entity.GIFT_CODE_AVAILABLE).
+		Updates(map[string]interface{}{
+			"status":    entity.GIFT_CODE_VOID,
+			"voided_at": time.Now(),
+		})
+	if result.Error != nil {
+		r.Log.Error("[GiftCodeRepository.VoidCode] " + result.Error.Error())
+		return false, errors.New("[GiftCodeRepository.VoidCode] " + result.Error.Error())
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// FindAllNotUnderKey returns codes whose data key is wrapped with another key
+// than the given one.
+func (r *GiftCodeRepository) FindAllNotUnderKey(keyID string, limit int) (*[]entity.GiftCode, error) {
+	var codes []entity.GiftCode
+	if err := r.DB.Where("key_id <> ? AND encrypted_code IS NOT NULL", keyID).Order("id").Limit(limit).Find(&codes).Error; err != nil {
+		r.Log.Error("[GiftCodeRepository.FindAllNotUnderKey] " + err.Error())
+		return nil, errors.New("[GiftCodeRepository.FindAllNotUnderKey] " + err.Error())
+	}
+	return &codes, nil
+}
+
+func (r *GiftCodeRepository) UpdateEncryption(code *entity.GiftCode) error {
+	if err := r.DB.Model(&entity.GiftCode{}).Where("id = ?", code.ID).Updates(map[string]interface{}{
+		"encrypted_code": code.EncryptedCode,
+		"encrypted_key":  code.EncryptedKey,
+		"key_id":         code.KeyID,
+	}).Error; err != nil {
+		r.Log.Error("[GiftCodeRepository.UpdateEncryption] " + err.Error())
+		return errors.New("[GiftCodeRepository.UpdateEncryption] " + err.Error())
+	}
+	return nil
+}
+
+// VoidCodesOfExpiredGifts withdraws the available codes of gifts that expired
+// before the given time, so they no longer count as stock.
+func (r *GiftCodeRepository) VoidCodesOfExpiredGifts(expiredBefore time.Time) (int64, error) {
+	expiredGifts := r.DB.Session(&gorm.Session{NewDB: true}).Model(&entity.Gift{}).
+		Select("id").Where("expired_at < ?", expiredBefore)
+
+	result := r.DB.Model(&entity.GiftCode{}).
+		Where("status = ? AND gift_id IN (?)", entity.GIFT_CODE_AVAILABLE, expiredGifts).
+		Updates(map[string]interface{}{
+			"status":    entity.GIFT_CODE_VOID,
+			"voided_at": time.Now(),
+		})
+	if result.Error != nil {
+		r.Log.Error("[GiftCodeRepository.VoidCodesOfExpiredGifts] " + result.Error.Error())
+		return 0, errors.New("[GiftCodeRepository.VoidCodesOfExpiredGifts] " + result.Error.Error())
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type IGiftRepository interface {
+	FindAllPaginated(page int, pageSize int, search string, sort string, availableAt *time.Time) (*[]entity.Gift, int64, error)
+	FindById(id uuid.UUID) (*entity.Gift, error)
+	FindByRedeemCode(redeemCode string) (*entity.Gift, error)
+	CreateGift(gift *entity.Gift) (*entity.Gift, error)
+	UpdateGift(gift *entity.Gift) (*entity.Gift, error)
+	DeleteGift(id uuid.UUID) error
+}
+
+type GiftRepository struct {
+	Log *logrus.Logger
+	DB  *gorm.DB
+}
+
+func NewGiftRepository(log *logrus.Logger, db *gorm.DB) IGiftRepository {
+	return &GiftRepository{
+		Log: log,
+		DB:  db,
+	}
+}
+
+func GiftRepositoryFactory(log *logrus.Logger) IGiftRepository {
+	db := config.NewDatabase()
+	return NewGiftRepository(log, db)
+}
+
+// giftSortColumns maps the sort keys accepted by the catalog to their columns.
+var giftSortColumns = map[string]string{
+	"name":         "gifts.name",
+	"price":        "gifts.price",
+	"stock":        "available_stock",
+	"created_at":   "gifts.created_at",
+	"expired_at":   "gifts.expired_at",
+	"rating":       "avg_rating",
+	"rating_count": "rating_count",
+}
+
+// GiftWithStockAndRatings selects the gift columns together with the number of
+// available codes, the average rating and the number of ratings given by the
+// gift's redeemers.
+func GiftWithStockAndRatings(db *gorm.DB) *gorm.DB {
+	stock := db.Session(&gorm.Session{NewDB: true}).Table("gift_codes").
+		Select("gift_codes.gift_id, COUNT(gift_codes.id) AS available_stock").
+		Where("gift_codes.status = ?", entity.GIFT_CODE_AVAILABLE).
+		Group("gift_codes.gift_id")
+
+	ratings := db.Session(&gorm.Session{NewDB: true}).Table("ratings").
+		Select("redemptions.gift_id, AVG(ratings.rating) AS avg_rating, COUNT(ratings.id) AS rating_count").
+		Joins("JOIN redemptions ON redemptions.id = ratings.redemption_id").
+		Where("ratings.deleted_at IS NULL AND redemptions.deleted_at IS NULL").
+		Group("redemptions.gift_id")
+
+	return db.Select("gifts.*, COALESCE(gift_stock.available_stock, 0) AS available_stock, COALESCE(gift_ratings.avg_rating, 0) AS avg_rating, COALESCE(gift_ratings.rating_count, 0) AS rating_count").
+		Joins("LEFT JOIN (?) AS gift_stock ON gift_stock.gift_id = gifts.id", stock).
+		Joins("LEFT JOIN (?) AS gift_ratings ON gift_ratings.gift_id = gifts.id", ratings)
+}
+
+// giftOrder turns a sort key such as "price" or "-rating" into an ORDER BY
+// expression, defaulting to the newest gifts first.
+func giftOrder(sort string) string {
+	direction := "ASC"
+	if strings.HasPrefix(sort, "-") {
+		direction = "DESC"
+		sort = strings.TrimPrefix(sort, "-")
+	}
+
+	column, ok := giftSortColumns[sort]
+	if !ok {
+		return "gifts.created_at DESC"
+	}
+
+	return column + " " + direction + ", gifts.created_at DESC"
+}
+
+// FindAllPaginated lists the gifts, only those within their availability
+// window at availableAt when it is given.
+func (r *GiftRepository) FindAllPaginated(page int, pageSize int, search string, sort string, availableAt *time.Time) (*[]entity.Gift, int64, error) {
+	var gifts []entity.Gift
+	var total int64
+
+	query := r.DB.Model(&entity.Gift{})
+
+	if availableAt != nil {
+		query = query.Where("(gifts.available_from IS NULL OR gifts.available_from <= ?) AND (gifts.expired_at IS NULL OR gifts.expired_at > ?)",
+			*availableAt, *availableAt)
+	}
+
+	if search != "" {
+		query = query.Where("gifts.name LIKE ? OR gifts.redeem_code LIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		r.Log.Error("[GiftRepository.FindAllPaginated] " + err.Error())
+		return nil, 0, errors.New("[GiftRepository.FindAllPaginated] " + err.Error())
+	}
+
+	if err := query.Scopes(GiftWithStockAndRatings).Order(giftOrder(sort)).Offset((page - 1) * pageSize).Limit(pageSize).Find(&gifts).Error; err != nil {
+		r.Log.Error("[GiftRepository.FindAllPaginated] " + err.Error())
+		return nil, 0, errors.New("[GiftRepository.FindAllPaginated] " + err.Error())
+	}
+
+	return &gifts, total, nil
+}
+
+func (r *GiftRepository) FindById(id uuid.UUID) (*entity.Gift, error) {
+	var gift entity.Gift
+	err := r.DB.Scopes(GiftWithStockAndRatings).Where("gifts.id = ?", id).First(&gift).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Warn("[GiftRepository.FindById] Gift not found")
+			return nil, nil
+		} else {
+			r.Log.Error("[GiftRepository.FindById] " + err.Error())
+			return nil, errors.New("[GiftRepository.FindById] " + err.Error())
+		}
+	}
+	return &gift, nil
+}
+
+func (r *GiftRepository) FindByRedeemCode(redeemCode string) (*entity.Gift, error) {
+	var gift entity.Gift
+	err := r.DB.Scopes(GiftWithStockAndRatings).Where("gifts.redeem_code = ?", redeemCode).First(&gift).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Warn("[GiftRepository.FindByRedeemCode] Gift not found")
+			return nil, nil
+		} else {
+			r.Log.Error("[GiftRepository.FindByRedeemCode] " + err.Error())
+			return nil, errors.New("[GiftRepository.FindByRedeemCode] " + err.Error())
+		}
+	}
+	return &gift, nil
+}
+
+func (r *GiftRepository) CreateGift(gift *entity.Gift) (*entity.Gift, error) {
+	tx := r.DB.Begin()
+	if tx.Error != nil {
+		return nil, errors.New("[GiftRepository.CreateGift] failed to begin transaction: " + tx.Error.Error())
+	}
+
+	if err := tx.Create(gift).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[GiftRepository.CreateGift] " + err.Error())
+		return nil, errors.New("[GiftRepository.CreateGift] " + err.Error())
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[GiftRepository.CreateGift] failed to commit transaction: " + err.Error())
+		return nil, errors.New("[GiftRepository.CreateGift] failed to commit transaction: " + err.Error())
+	}
+
+	return r.FindById(gift.ID)
+}
+
+func (r *GiftRepository) UpdateGift(gift *entity.Gift) (*entity.Gift, error) {
+	tx := r.DB.Begin()
+	if tx.Error != nil {
+		return nil, errors.New("[GiftRepository.UpdateGift] failed to begin transaction: " + tx.Error.Error())
+	}
+
+	// select the columns explicitly so zero values such as a removed limit are persisted
+	if err := tx.Model(gift).Where("id = ?", gift.ID).
+		Select("redeem_code", "name", "description", "price", "available_from", "expired_at",
+			"max_per_user", "max_per_user_daily", "max_per_user_weekly", "max_per_user_monthly", "max_daily").
+		Updates(gift).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[GiftRepository.UpdateGift] " + err.Error())
+		return nil, errors.New("[GiftRepository.UpdateGift] " + err.Error())
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[GiftRepository.UpdateGift] failed to commit transaction: " + err.Error())
+		return nil, errors.New("[GiftRepository.UpdateGift] failed to commit transaction: " + err.Error())
+	}
+
+	return r.FindById(gift.ID)
+}
+
+func (r *GiftRepository) DeleteGift(id uuid.UUID) error {
+	tx := r.DB.Begin()
+	if tx.Error != nil {
+		return errors.New("[GiftRepository.DeleteGift] failed to begin transaction: " + tx.Error.Error())
+	}
+
+	var gift entity.Gift
+	if err := tx.First(&gift, "id = ?", id).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[GiftRepository.DeleteGift] Gift not found: " + err.Error())
+		return errors.New("[GiftRepository.DeleteGift] Gift not found: " + err.Error())
+	}
+
+	if err := tx.Delete(&gift).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[GiftRepository.DeleteGift] " + err.Error())
+		return errors.New("[GiftRepository.DeleteGift] " + err.Error())
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[GiftRepository.DeleteGift] failed to commit transaction: " + err.Error())
+		return errors.New("[GiftRepository.DeleteGift] failed to commit transaction: " + err.Error())
+	}
+
+	return nil
+}
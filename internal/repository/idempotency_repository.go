@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IIdempotencyRepository interface {
+	Reserve(idempotencyKey *entity.IdempotencyKey) (*entity.IdempotencyKey, bool, error)
+	Complete(id uuid.UUID, responseCode int, responseBody string) error
+	Release(id uuid.UUID) error
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+type IdempotencyRepository struct {
+	Log *logrus.Logger
+	DB  *gorm.DB
+}
+
+func NewIdempotencyRepository(log *logrus.Logger, db *gorm.DB) IIdempotencyRepository {
+	return &IdempotencyRepository{
+		Log: log,
+		DB:  db,
+	}
+}
+
+func IdempotencyRepositoryFactory(log *logrus.Logger) IIdempotencyRepository {
+	db := config.NewDatabase()
+	return NewIdempotencyRepository(log, db)
+}
+
+// Reserve stores idempotencyKey unless the user already used the key. It
+// returns the stored key and whether it was created by this call. An expired
+// key is removed first, so the key can be used again.
+func (r *IdempotencyRepository) Reserve(idempotencyKey *entity.IdempotencyKey) (*entity.IdempotencyKey, bool, error) {
+	if err := r.DB.Where("user_id = ? AND idempotency_key = ? AND expires_at <= ?", idempotencyKey.UserID, idempotencyKey.Key, time.Now()).
+		Delete(&entity.IdempotencyKey{}).Error; err != nil {
+		r.Log.Error("[IdempotencyRepository.Reserve] " + err.Error())
+		return nil, false, errors.New("[IdempotencyRepository.Reserve] " + err.Error())
+	}
+
+	if err := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(idempotencyKey).Error; err != nil {
+		r.Log.Error("[IdempotencyRepository.Reserve] " + err.Error())
+		return nil, false, errors.New("[IdempotencyRepository.Reserve] " + err.Error())
+	}
+
+	var stored entity.IdempotencyKey
+	if err := r.DB.Where("user_id = ? AND idempotency_key = ?", idempotencyKey.UserID, idempotencyKey.Key).First(&stored).Error; err != nil {
+		r.Log.Error("[IdempotencyRepository.Reserve] " + err.Error())
+		return nil, false, errors.New("[IdempotencyRepository.Reserve] " + err.Error())
+	}
+
+	return &stored, stored.ID == idempotencyKey.ID, nil
+}
+
+func (r *IdempotencyRepository) Complete(id uuid.UUID, responseCode int, responseBody string) error {
+	if err := r.DB.Model(&entity.IdempotencyKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":        entity.IDEMPOTENCY_KEY_COMPLETED,
+		"response_code": responseCode,
+		"response_body": responseBody,
+	}).Error; err != nil {
+		r.Log.Error("[IdempotencyRepository.Complete] " + err.Error())
+		return errors.New("[IdempotencyRepository.Complete] " + err.Error())
+	}
+	return nil
+}
+
+// Release forgets a key whose request did not finish, so it can be retried.
+func (r *IdempotencyRepository) Release(id uuid.UUID) error {
+	if err := r.DB.Where("id = ?", id).Delete(&entity.IdempotencyKey{}).Error; err != nil {
+		r.Log.Error("[IdempotencyRepository.Release] " + err.Error())
+		return errors.New("[IdempotencyRepository.Release] " + err.Error())
+	}
+	return nil
+}
+
+func (r *IdempotencyRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.DB.Where("expires_at < ?", before).Delete(&entity.IdempotencyKey{})
+	if result.Error != nil {
+		r.Log.Error("[IdempotencyRepository.DeleteExpired] " + result.Error.Error())
+		return 0, errors.New("[IdempotencyRepository.DeleteExpired] " + result.Error.Error())
+	}
+	return result.RowsAffected, nil
+}
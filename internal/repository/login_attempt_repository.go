@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ILoginAttemptRepository interface {
+	FindByIdentifier(scope entity.LoginAttemptScope, identifier string) (*entity.LoginAttempt, error)
+	RecordFailure(scope entity.LoginAttemptScope, identifier string, window time.Duration) (*entity.LoginAttempt, error)
+	Lock(scope entity.LoginAttemptScope, identifier string, until time.Time) error
+	Reset(scope entity.LoginAttemptScope, identifier string) error
+}
+
+type LoginAttemptRepository struct {
+	Log *logrus.Logger
+	DB  *gorm.DB
+}
+
+func NewLoginAttemptRepository(log *logrus.Logger, db *gorm.DB) ILoginAttemptRepository {
+	return &LoginAttemptRepository{
+		Log: log,
+		DB:  db,
+	}
+}
+
+func LoginAttemptRepositoryFactory(log *logrus.Logger) ILoginAttemptRepository {
+	db := config.NewDatabase()
+	return NewLoginAttemptRepository(log, db)
+}
+
+func (r *LoginAttemptRepository) FindByIdentifier(scope entity.LoginAttemptScope, identifier string) (*entity.LoginAttempt, error) {
+	var attempt entity.LoginAttempt
+	if err := r.DB.Where("scope = ? AND identifier = ?", scope, identifier).First(&attempt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		r.Log.Error("[LoginAttemptRepository.FindByIdentifier] " + err.Error())
+		return nil, errors.New("[LoginAttemptRepository.FindByIdentifier] " + err.Error())
+	}
+	return &attempt, nil
+}
+
+// RecordFailure increments the failure counter of the identifier. The counter starts
+// over when the previous failure is older than window and no lockout is
+// running, so occasional typos never add up to a lockout.
+func (r *LoginAttemptRepository) RecordFailure(scope entity.LoginAttemptScope, identifier string, window time.Duration) (*entity.LoginAttempt, error) {
+	tx := r.DB.Begin()
+	if tx.Error != nil {
+		return nil, errors.New("[LoginAttemptRepository.RecordFailure] failed to begin transaction: " + tx.Error.Error())
+	}
+
+	// make sure the row exists so concurrent failures serialize on its lock
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.LoginAttempt{
+		Scope:        scope,
+		Identifier:   identifier,
+		LastFailedAt: time.Now(),
+	}).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[LoginAttemptRepository.RecordFailure] " + err.Error())
+		return nil, errors.New("[LoginAttemptRepository.RecordFailure] " + err.Error())
+	}
+
+	var attempt entity.LoginAttempt
+	if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Where("scope = ? AND identifier = ?", scope, identifier).First(&attempt).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[LoginAttemptRepository.RecordFailure] " + err.Error())
+		return nil, errors.New("[LoginAttemptRepository.RecordFailure] " + err.Error())
+	}
+
+	now := time.Now()
+	if !attempt.IsLocked(now) && now.Sub(attempt.LastFailedAt) > window {
+		attempt.Failures = 0
+		attempt.LockedUntil = nil
+	}
+
+	attempt.Failures++
+	attempt.LastFailedAt = now
+
+	if err := tx.Model(&attempt).Updates(map[string]interface{}{
+		"failures":       attempt.Failures,
+		"last_failed_at": attempt.LastFailedAt,
+		"locked_until":   attempt.LockedUntil,
+	}).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[LoginAttemptRepository.RecordFailure] " + err.Error())
+		return nil, errors.New("[LoginAttemptRepository.RecordFailure] " + err.Error())
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[LoginAttemptRepository.RecordFailure] failed to commit transaction: " + err.Error())
+		return nil, errors.New("[LoginAttemptRepository.RecordFailure] failed to commit transaction: " + err.Error())
+	}
+
+	return &attempt, nil
+}
+
+func (r *LoginAttemptRepository) Lock(scope entity.LoginAttemptScope, identifier string, until time.Time) error {
+	if err := r.DB.Model(&entity.LoginAttempt{}).
+		Where("scope = ? AND identifier = ?", scope, identifier).
+		Update("locked_until", until).Error; err != nil {
+		r.Log.Error("[LoginAttemptRepository.Lock] " + err.Error())
+		return errors.New("[LoginAttemptRepository.Lock] " + err.Error())
+	}
+	return nil
+}
+
+// Reset forgets the failures of the identifier, lifting a running lockout.
+func (r *LoginAttemptRepository) Reset(scope entity.LoginAttemptScope, identifier string) error {
+	if err := r.DB.Where("scope = ? AND identifier = ?", scope, identifier).Delete(&entity.LoginAttempt{}).Error; err != nil {
+		r.Log.Error("[LoginAttemptRepository.Reset] " + err.Error())
+		return errors.New("[LoginAttemptRepository.Reset] " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrAuthorizationCodeInvalid = errors.New("authorization code is invalid or expired")
+	ErrAuthorizationCodeReused  = errors.New("authorization code has already been used")
+	ErrRedirectURIMismatch      = errors.New("redirect_uri does not match the authorization request")
+	ErrCodeChallengeMismatch    = errors.New("code_verifier does not match the code challenge")
+)
+
+type IOAuthRepository interface {
+	FindAllClientsPaginated(page int, pageSize int, search string) (*[]entity.OAuthClient, int64, error)
+	FindClientById(id uuid.UUID) (*entity.OAuthClient, error)
+	FindClientByClientID(clientID string) (*entity.OAuthClient, error)
+	CreateClient(client *entity.OAuthClient) (*entity.OAuthClient, error)
+	DeleteClient(id uuid.UUID) error
+	CreateAuthorizationCode(code *entity.OAuthAuthorizationCode) error
+	ConsumeAuthorizationCode(codeHash string, clientID uuid.UUID, redirectURI string, codeChallenge string, accessTokenJTI string) (*entity.OAuthAuthorizationCode, error)
+	DeleteExpiredAuthorizationCodes(before time.Time) (int64, error)
+}
+
+type OAuthRepository struct {
+	Log *logrus.Logger
+	DB  *gorm.DB
+}
+
+func NewOAuthRepository(log *logrus.Logger, db *gorm.DB) IOAuthRepository {
+	return &OAuthRepository{
+		Log: log,
+		DB:  db,
+	}
+}
+
+func OAuthRepositoryFactory(log *logrus.Logger) IOAuthRepository {
+	db := config.NewDatabase()
+	return NewOAuthRepository(log, db)
+}
+
+func (r *OAuthRepository) FindAllClientsPaginated(page int, pageSize int, search string) (*[]entity.OAuthClient, int64, error) {
+	var clients []entity.OAuthClient
+	var total int64
+
+	query := r.DB.Model(&entity.OAuthClient{})
+
+	if search != "" {
+		query = query.Where("name LIKE ? OR client_id LIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		r.Log.Error("[OAuthRepository.FindAllClientsPaginated] " + err.Error())
+		return nil, 0, errors.New("[OAuthRepository.FindAllClientsPaginated] " + err.Error())
+	}
+
+	if err := query.Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&clients).Error; err != nil {
+		r.Log.Error("[OAuthRepository.FindAllClientsPaginated] " + err.Error())
+		return nil, 0, errors.New("[OAuthRepository.FindAllClientsPaginated] " + err.Error())
+	}
+
+	return &clients, total, nil
+}
+
+func (r *OAuthRepository) FindClientById(id uuid.UUID) (*entity.OAuthClient, error) {
+	var client entity.OAuthClient
+	err := r.DB.Where("id = ?", id).First(&client).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Warn("[OAuthRepository.FindClientById] OAuth client not found")
+			return nil, nil
+		} else {
+			r.Log.Error("[OAuthRepository.FindClientById] " + err.Error())
+			return nil, errors.New("[OAuthRepository.FindClientById] " + err.Error())
+		}
+	}
+	return &client, nil
+}
+
+func (r *OAuthRepository) FindClientByClientID(clientID string) (*entity.OAuthClient, error) {
+	var client entity.OAuthClient
+	err := r.DB.Where("client_id = ?", clientID).First(&client).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Warn("[OAuthRepository.FindClientByClientID] OAuth client not found")
+			return nil, nil
+		} else {
+			r.Log.Error("[OAuthRepository.FindClientByClientID] " + err.Error())
+			return nil, errors.New("[OAuthRepository.FindClientByClientID] " + err.Error())
+		}
+	}
+	return &client, nil
+}
+
+func (r *OAuthRepository) CreateClient(client *entity.OAuthClient) (*entity.OAuthClient, error) {
+	if err := r.DB.Create(client).Error; err != nil {
+		r.Log.Error("[OAuthRepository.CreateClient] " + err.Error())
+		return nil, errors.New("[OAuthRepository.CreateClient] " + err.Error())
+	}
+
+	return r.FindClientById(client.ID)
+}
+
+func (r *OAuthRepository) DeleteClient(id uuid.UUID) error {
+	tx := r.DB.Begin()
+	if tx.Error != nil {
+		return errors.New("[OAuthRepository.DeleteClient] failed to begin transaction: " + tx.Error.Error())
+	}
+
+	var client entity.OAuthClient
+	if err := tx.First(&client, "id = ?", id).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[OAuthRepository.DeleteClient] OAuth client not found: " + err.Error())
+		return errors.New("[OAuthRepository.DeleteClient] OAuth client not found: " + err.Error())
+	}
+
+	// outstanding codes must not be exchangeable once the client is gone
+	if err := tx.Where("oauth_client_id = ?", id).Delete(&entity.OAuthAuthorizationCode{}).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[OAuthRepository.DeleteClient] " + err.Error())
+		return errors.New("[OAuthRepository.DeleteClient] " + err.Error())
+	}
+
+	if err := tx.Delete(&client).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[OAuthRepository.DeleteClient] " + err.Error())
+		return errors.New("[OAuthRepository.DeleteClient] " + err.Error())
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[OAuthRepository.DeleteClient] failed to commit transaction: " + err.Error())
+		return errors.New("[OAuthRepository.DeleteClient] failed to commit transaction: " + err.Error())
+	}
+
+	return nil
+}
+
+func (r *OAuthRepository) CreateAuthorizationCode(code *entity.OAuthAuthorizationCode) error {
+	if err := r.DB.Omit(clause.Associations).Create(code).Error; err != nil {
+		r.Log.Error("[OAuthRepository.CreateAuthorizationCode] " + err.Error())
+		return errors.New("[OAuthRepository.CreateAuthorizationCode] " + err.Error())
+	}
+	return nil
+}
+
+// ConsumeAuthorizationCode marks the code issued to the client as used and
+// records the jti of the access token it is exchanged for. The redirect uri and
+// the PKCE challenge of the exchange must match the authorization request, else
+// the code is left unused for the client that holds the verifier. A code
+// presented a second time is returned along with ErrAuthorizationCodeReused, so
+// the caller can revoke the token issued the first time.
+func (r *OAuthRepository) ConsumeAuthorizationCode(codeHash string, clientID uuid.UUID, redirectURI string, codeChallenge string, accessTokenJTI string) (*entity.OAuthAuthorizationCode, error) {
+	tx := r.DB.Begin()
+	if tx.Error != nil {
+		return nil, errors.New("[OAuthRepository.ConsumeAuthorizationCode] failed to begin transaction: " + tx.Error.Error())
+	}
+
+	var code entity.OAuthAuthorizationCode
+	if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Where("code_hash = ? AND oauth_client_id = ?", codeHash, clientID).First(&code).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Warn("[OAuthRepository.ConsumeAuthorizationCode] Authorization code not found")
+			return nil, ErrAuthorizationCodeInvalid
+		}
+		r.Log.Error("[OAuthRepository.ConsumeAuthorizationCode] " + err.Error())
+		return nil, errors.New("[OAuthRepository.ConsumeAuthorizationCode] " + err.Error())
+	}
+
+	if code.RedirectURI != redirectURI {
+		tx.Rollback()
+		r.Log.Warn("[OAuthRepository.ConsumeAuthorizationCode] Redirect uri mismatch")
+		return nil, ErrRedirectURIMismatch
+	}
+
+	if subtle.ConstantTimeCompare([]byte(code.CodeChallenge), []byte(codeChallenge)) != 1 {
+		tx.Rollback()
+		r.Log.Warn("[OAuthRepository.ConsumeAuthorizationCode] Code challenge mismatch")
+		return nil, ErrCodeChallengeMismatch
+	}
+
+	if code.UsedAt != nil {
+		tx.Rollback()
+		r.Log.Warn("[OAuthRepository.ConsumeAuthorizationCode] Authorization code reused")
+		return &code, ErrAuthorizationCodeReused
+	}
+
+	now := time.Now()
+
+	if !code.ExpiredAt.After(now) {
+		tx.Rollback()
+		r.Log.Warn("[OAuthRepository.ConsumeAuthorizationCode] Authorization code expired")
+		return nil, ErrAuthorizationCodeInvalid
+	}
+
+	if err := tx.Model(&code).Updates(map[string]interface{}{
+		"used_at":          now,
+		"access_token_jti": accessTokenJTI,
+	}).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[OAuthRepository.ConsumeAuthorizationCode] " + err.Error())
+		return nil, errors.New("[OAuthRepository.ConsumeAuthorizationCode] " + err.Error())
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[OAuthRepository.ConsumeAuthorizationCode] failed to commit transaction: " + err.Error())
+		return nil, errors.New("[OAuthRepository.ConsumeAuthorizationCode] failed to commit transaction: " + err.Error())
+	}
+
+	return &code, nil
+}
+
+func (r *OAuthRepository) DeleteExpiredAuthorizationCodes(before time.Time) (int64, error) {
+	result := r.DB.Where("expired_at < ?", before).Delete(&entity.OAuthAuthorizationCode{})
+	if result.Error != nil {
+		r.Log.Error("[OAuthRepository.DeleteExpiredAuthorizationCodes] " + result.Error.Error())
+		return 0, errors.New("[OAuthRepository.DeleteExpiredAuthorizationCodes] " + result.Error.Error())
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type IPermissionRepository interface {
+	GetAllPermissions() (*[]entity.Permission, error)
+}
+
+type PermissionRepository struct {
+	Log *logrus.Logger
+	DB  *gorm.DB
+}
+
+func NewPermissionRepository(log *logrus.Logger, db *gorm.DB) IPermissionRepository {
+	return &PermissionRepository{
+		Log: log,
+		DB:  db,
+	}
+}
+
+func PermissionRepositoryFactory(log *logrus.Logger) IPermissionRepository {
+	db := config.NewDatabase()
+	return NewPermissionRepository(log, db)
+}
+
+func (r *PermissionRepository) GetAllPermissions() (*[]entity.Permission, error) {
+	var permissions []entity.Permission
+	if err := r.DB.Order("name ASC").Find(&permissions).Error; err != nil {
+		r.Log.Error("[PermissionRepository.GetAllPermissions] " + err.Error())
+		return nil, errors.New("[PermissionRepository.GetAllPermissions] " + err.Error())
+	}
+	return &permissions, nil
+}
@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInsufficientPoints = errors.New("not enough points")
+)
+
+type IPointRepository interface {
+	FindWalletByUserID(userID uuid.UUID) (*entity.PointWallet, error)
+	FindLedgerByUserPaginated(userID uuid.UUID, page int, pageSize int) (*[]entity.PointLedger, int64, error)
+	AddEntry(entry *entity.PointLedger) (*entity.PointLedger, error)
+	FindAllBatchesPaginated(page int, pageSize int, search string) (*[]entity.PointBatch, int64, error)
+	FindBatchById(id uuid.UUID) (*entity.PointBatch, error)
+	FindBatchByKey(batchKey string) (*entity.PointBatch, error)
+	CreateBatch(batch *entity.PointBatch) (*entity.PointBatch, bool, error)
+	FindBatchRowNumbers(batchID uuid.UUID) (map[int]bool, error)
+	CreditBatchRow(row *entity.PointBatchRow, entry *entity.PointLedger) error
+	CreateBatchRow(row *entity.PointBatchRow) error
+	CompleteBatch(batchID uuid.UUID, totalRows int) (*entity.PointBatch, error)
+}
+
+type PointRepository struct {
+	Log *logrus.Logger
+	DB  *gorm.DB
+}
+
+func NewPointRepository(log *logrus.Logger, db *gorm.DB) IPointRepository {
+	return &PointRepository{
+		Log: log,
+		DB:  db,
+	}
+}
+
+func PointRepositoryFactory(log *logrus.Logger) IPointRepository {
+	db := config.NewDatabase()
+	return NewPointRepository(log, db)
+}
+
+// FindWalletByUserID returns nil when the user never had any points.
+func (r *PointRepository) FindWalletByUserID(userID uuid.UUID) (*entity.PointWallet, error) {
+	var wallet entity.PointWallet
+	err := r.DB.Where("user_id = ?", userID).First(&wallet).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Warn("[PointRepository.FindWalletByUserID] Point wallet not found")
+			return nil, nil
+		} else {
+			r.Log.Error("[PointRepository.FindWalletByUserID] " + err.Error())
+			return nil, errors.New("[PointRepository.FindWalletByUserID] " + err.Error())
+		}
+	}
+	return &wallet, nil
+}
+
+func (r *PointRepository) FindLedgerByUserPaginated(userID uuid.UUID, page int, pageSize int) (*[]entity.PointLedger, int64, error) {
+	var entries []entity.PointLedger
+	var total int64
+
+	query := r.DB.Model(&entity.PointLedger{}).Where("user_id = ?", userID)
+
+	if err := query.Count(&total).Error; err != nil {
+		r.Log.Error("[PointRepository.FindLedgerByUserPaginated] " + err.Error())
+		return nil, 0, errors.New("[PointRepository.FindLedgerByUserPaginated] " + err.Error())
+	}
+
+	if err := query.Order("created_at DESC").Order("id").Offset((page - 1) * pageSize).Limit(pageSize).Find(&entries).Error; err != nil {
+		r.Log.Error("[PointRepository.FindLedgerByUserPaginated] " + err.Error())
+		return nil, 0, errors.New("[PointRepository.FindLedgerByUserPaginated] " + err.Error())
+	}
+
+	return &entries, total, nil
+}
+
+// AddEntry books a single entry on the wallet of its user.
+func (r *PointRepository) AddEntry(entry *entity.PointLedger) (*entity.PointLedger, error) {
+	tx := r.DB.Begin()
+	if tx.Error != nil {
+		return nil, errors.New("[PointRepository.AddEntry] failed to begin transaction: " + tx.Error.Error())
+	}
+
+	if err := applyPointEntry(tx, entry); err != nil {
+		tx.Rollback()
+		if errors.Is(err, ErrInsufficientPoints) {
+			r.Log.Warn("[PointRepository.AddEntry] Not enough points")
+			return nil, ErrInsufficientPoints
+		}
+		r.Log.Error("[PointRepository.AddEntry] " + err.Error())
+		return nil, errors.New("[PointRepository.AddEntry] " + err.Error())
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[PointRepository.AddEntry] failed to commit transaction: " + err.Error())
+		return nil, errors.New("[PointRepository.AddEntry] failed to commit transaction: " + err.Error())
+	}
+
+	return entry, nil
+}
+
+func (r *PointRepository) FindAllBatchesPaginated(page int, pageSize int, search string) (*[]entity.PointBatch, int64, error) {
+	var batches []entity.PointBatch
+	var total int64
+
+	query := r.DB.Model(&entity.PointBatch{})
+
+	if search != "" {
+		query = query.Where("batch_key LIKE ? OR file_name LIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		r.Log.Error("[PointRepository.FindAllBatchesPaginated] " + err.Error())
+		return nil, 0, errors.New("[PointRepository.FindAllBatchesPaginated] " + err.Error())
+	}
+
+	if err := query.Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&batches).Error; err != nil {
+		r.Log.Error("[PointRepository.FindAllBatchesPaginated] " + err.Error())
+		return nil, 0, errors.New("[PointRepository.FindAllBatchesPaginated] " + err.Error())
+	}
+
+	return &batches, total, nil
+}
+
+func (r *PointRepository) FindBatchById(id uuid.UUID) (*entity.PointBatch, error) {
+	return r.findBatch("[PointRepository.FindBatchById]", "id = ?", id)
+}
+
+func (r *PointRepository) FindBatchByKey(batchKey string) (*entity.PointBatch, error) {
+	return r.findBatch("[PointRepository.FindBatchByKey]", "batch_key = ?", batchKey)
+}
+
+func (r *PointRepository) findBatch(caller string, query string, args ...interface{}) (*entity.PointBatch, error) {
+	var batch entity.PointBatch
+	err := r.DB.Preload("Rows", func(db *gorm.DB) *gorm.DB {
+		return db.Order("row_number")
+	}).Where(query, args...).First(&batch).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Warn(caller + " Point batch not found")
+			return nil, nil
+		} else {
+			r.Log.Error(caller + " " + err.Error())
+			return nil, errors.New(caller + " " + err.Error())
+		}
+	}
+	return &batch, nil
+}
+
+// CreateBatch stores the batch unless one with the same key exists already, in
+// which case that one is returned and created is false.
+func (r *PointRepository) CreateBatch(batch *entity.PointBatch) (*entity.PointBatch, bool, error) {
+	if err := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Omit(clause.Associations).Create(batch).Error; err != nil {
+		r.Log.Error("[PointRepository.CreateBatch] " + err.Error())
+		return nil, false, errors.New("[PointRepository.CreateBatch] " + err.Error())
+	}
+
+	stored, err := r.FindBatchByKey(batch.BatchKey)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if stored == nil {
+		return nil, false, errors.New("[PointRepository.CreateBatch] point batch not found after create")
+	}
+
+	return stored, stored.ID == batch.ID, nil
+}
+
+func (r *PointRepository) FindBatchRowNumbers(batchID uuid.UUID) (map[int]bool, error) {
+	var rowNumbers []int
+	if err := r.DB.Model(&entity.PointBatchRow{}).Where("batch_id = ?", batchID).Pluck("row_number", &rowNumbers).Error; err != nil {
+		r.Log.Error("[PointRepository.FindBatchRowNumbers] " + err.Error())
+		return nil, errors.New("[PointRepository.FindBatchRowNumbers] " + err.Error())
+	}
+
+	processed := make(map[int]bool, len(rowNumbers))
+	for _, rowNumber := range rowNumbers {
+		processed[rowNumber] = true
+	}
+	return processed, nil
+}
+
+// CreditBatchRow books entry and records row as succeeded in one transaction,
+// so a row is never credited without being reported or the other way round.
+func (r *PointRepository) CreditBatchRow(row *entity.PointBatchRow, entry *entity.PointLedger) error {
+	tx := r.DB.Begin()
+	if tx.Error != nil {
+		return errors.New("[PointRepository.CreditBatchRow] failed to begin transaction: " + tx.Error.Error())
+	}
+
+	if err := applyPointEntry(tx, entry); err != nil {
+		tx.Rollback()
+		r.Log.Error("[PointRepository.CreditBatchRow] " + err.Error())
+		return errors.New("[PointRepository.CreditBatchRow] " + err.Error())
+	}
+
+	row.Status = entity.POINT_BATCH_ROW_SUCCESS
+	row.LedgerID = &entry.ID
+
+	if err := tx.Create(row).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[PointRepository.CreditBatchRow] " + err.Error())
+		return errors.New("[PointRepository.CreditBatchRow] " + err.Error())
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[PointRepository.CreditBatchRow] failed to commit transaction: " + err.Error())
+		return errors.New("[PointRepository.CreditBatchRow] failed to commit transaction: " + err.Error())
+	}
+
+	return nil
+}
+
+func (r *PointRepository) CreateBatchRow(row *entity.PointBatchRow) error {
+	if err := r.DB.Create(row).Error; err != nil {
+		r.Log.Error("[PointRepository.CreateBatchRow] " + err.Error())
+		return errors.New("[PointRepository.CreateBatchRow] " + err.Error())
+	}
+	return nil
+}
+
+// CompleteBatch counts the outcome of the rows and marks the batch completed.
+func (r *PointRepository) CompleteBatch(batchID uuid.UUID, totalRows int) (*entity.PointBatch, error) {
+	var succeeded, failed int64
+	if err := r.DB.Model(&entity.PointBatchRow{}).
+		Where("batch_id = ? AND status = ?", batchID, entity.POINT_BATCH_ROW_SUCCESS).Count(&succeeded).Error; err != nil {
+		r.Log.Error("[PointRepository.CompleteBatch] " + err.Error())
+		return nil, errors.New("[PointRepository.CompleteBatch] " + err.Error())
+	}
+
+	if err := r.DB.Model(&entity.PointBatchRow{}).
+		Where("batch_id = ? AND status = ?", batchID, entity.POINT_BATCH_ROW_FAILED).Count(&failed).Error; err != nil {
+		r.Log.Error("[PointRepository.CompleteBatch] " + err.Error())
+		return nil, errors.New("[PointRepository.CompleteBatch] " + err.Error())
+	}
+
+	if err := r.DB.Model(&entity.PointBatch{}).Where("id = ?", batchID).Updates(map[string]interface{}{
+		"status":         entity.POINT_BATCH_COMPLETED,
+		"total_rows":     totalRows,
+		"succeeded_rows": succeeded,
+		"failed_rows":    failed,
+	}).Error; err != nil {
+		r.Log.Error("[PointRepository.CompleteBatch] " + err.Error())
+		return nil, errors.New("[PointRepository.CompleteBatch] " + err.Error())
+	}
+
+	return r.FindBatchById(batchID)
+}
+
+// applyPointEntry books entry on the wallet of entry.UserID within tx, creating
+// the wallet on first use. The wallet row stays locked until tx ends, so
+// concurrent entries are serialized and a debit can never overdraw the wallet.
+func applyPointEntry(tx *gorm.DB, entry *entity.PointLedger) error {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.PointWallet{
+		UserID: entry.UserID,
+	}).Error; err != nil {
+		return err
+	}
+
+	var wallet entity.PointWallet
+	if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Where("user_id = ?", entry.UserID).First(&wallet).Error; err != nil {
+		return err
+	}
+
+	balance := wallet.Balance + entry.Amount
+	if entry.Amount < 0 && balance < 0 {
+		return ErrInsufficientPoints
+	}
+
+	if err := tx.Model(&wallet).Update("balance", balance).Error; err != nil {
+		return err
+	}
+
+	entry.WalletID = wallet.ID
+	entry.BalanceAfter = balance
+
+	return tx.Omit(clause.Associations).Create(entry).Error
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IRatingRepository interface {
+	FindByRedemptionID(redemptionID uuid.UUID) (*entity.Rating, error)
+	CreateRating(rating *entity.Rating) (*entity.Rating, error)
+}
+
+type RatingRepository struct {
+	Log *logrus.Logger
+	DB  *gorm.DB
+}
+
+func NewRatingRepository(log *logrus.Logger, db *gorm.DB) IRatingRepository {
+	return &RatingRepository{
+		Log: log,
+		DB:  db,
+	}
+}
+
+func RatingRepositoryFactory(log *logrus.Logger) IRatingRepository {
+	db := config.NewDatabase()
+	return NewRatingRepository(log, db)
+}
+
+func (r *RatingRepository) FindByRedemptionID(redemptionID uuid.UUID) (*entity.Rating, error) {
+	var rating entity.Rating
+	err := r.DB.Where("redemption_id = ?", redemptionID).First(&rating).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Warn("[RatingRepository.FindByRedemptionID] Rating not found")
+			return nil, nil
+		} else {
+			r.Log.Error("[RatingRepository.FindByRedemptionID] " + err.Error())
+			return nil, errors.New("[RatingRepository.FindByRedemptionID] " + err.Error())
+		}
+	}
+	return &rating, nil
+}
+
+func (r *RatingRepository) CreateRating(rating *entity.Rating) (*entity.Rating, error) {
+	tx := r.DB.Begin()
+	if tx.Error != nil {
+		return nil, errors.New("[RatingRepository.CreateRating] failed to begin transaction: " + tx.Error.Error())
+	}
+
+	if err := tx.Omit(clause.Associations).Create(rating).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[RatingRepository.CreateRating] " + err.Error())
+		return nil, errors.New("[RatingRepository.CreateRating] " + err.Error())
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[RatingRepository.CreateRating] failed to commit transaction: " + err.Error())
+		return nil, errors.New("[RatingRepository.CreateRating] failed to commit transaction: " + err.Error())
+	}
+
+	return rating, nil
+}
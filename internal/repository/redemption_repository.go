@@ -0,0 +1,434 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrGiftNotFound     = errors.New("gift not found")
+	ErrGiftOutOfStock   = errors.New("gift is out of stock")
+	ErrGiftExpired      = errors.New("gift has expired")
+	ErrGiftNotAvailable = errors.New("gift is not available yet")
+
+	ErrRedemptionNotFound          = errors.New("redemption not found")
+	ErrInvalidRedemptionTransition = errors.New("redemption cannot move to this status")
+)
+
+// RedemptionLimitError is returned when redeeming would exceed a limit of the
+// gift. ResetsAt is nil for the lifetime limit, which never resets.
+type RedemptionLimitError struct {
+	Code     entity.GiftLimitCode
+	Max      int
+	ResetsAt *time.Time
+}
+
+func (e *RedemptionLimitError) Error() string {
+	return "redemption limit reached: " + string(e.Code)
+}
+
+type IRedemptionRepository interface {
+	FindById(id uuid.UUID) (*entity.Redemption, error)
+	FindByIdAndUser(id uuid.UUID, userID uuid.UUID) (*entity.Redemption, error)
+	FindAllPaginated(status string, page int, pageSize int) (*[]entity.Redemption, int64, error)
+	FindAllByUserPaginated(userID uuid.UUID, status string, page int, pageSize int) (*[]entity.Redemption, int64, error)
+	Redeem(userID uuid.UUID, giftID uuid.UUID, now time.Time) (*entity.Redemption, error)
+	Transition(id uuid.UUID, to entity.RedemptionStatus, actorID *uuid.UUID, note string) (*entity.Redemption, error)
+}
+
+type RedemptionRepository struct {
+	Log *logrus.Logger
+	DB  *gorm.DB
+}
+
+func NewRedemptionRepository(log *logrus.Logger, db *gorm.DB) IRedemptionRepository {
+	return &RedemptionRepository{
+		Log: log,
+		DB:  db,
+	}
+}
+
+func RedemptionRepositoryFactory(log *logrus.Logger) IRedemptionRepository {
+	db := config.NewDatabase()
+	return NewRedemptionRepository(log, db)
+}
+
+// redemptionDetails preloads everything shown on a single redemption, the
+// events oldest first so they read as a timeline.
+func redemptionDetails(db *gorm.DB) *gorm.DB {
+	return db.Preload("Gift", GiftWithStockAndRatings).Preload("GiftCode").Preload("Rating").
+		Preload("Events", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at").Order("id")
+		})
+}
+
+func (r *RedemptionRepository) FindById(id uuid.UUID) (*entity.Redemption, error) {
+	var redemption entity.Redemption
+	err := r.DB.Scopes(redemptionDetails).Where("id = ?", id).First(&redemption).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Warn("[RedemptionRepository.FindById] Redemption not found")
+			return nil, nil
+		} else {
+			r.Log.Error("[RedemptionRepository.FindById] " + err.Error())
+			return nil, errors.New("[RedemptionRepository.FindById] " + err.Error())
+		}
+	}
+	return &redemption, nil
+}
+
+func (r *RedemptionRepository) FindByIdAndUser(id uuid.UUID, userID uuid.UUID) (*entity.Redemption, error) {
+	var redemption entity.Redemption
+	err := r.DB.Scopes(redemptionDetails).Where("id = ? AND user_id = ?", id, userID).First(&redemption).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Warn("[RedemptionRepository.FindByIdAndUser] Redemption not found")
+			return nil, nil
+		} else {
+			r.Log.Error("[RedemptionRepository.FindByIdAndUser] " + err.Error())
+			return nil, errors.New("[RedemptionRepository.FindByIdAndUser] " + err.Error())
+		}
+	}
+	return &redemption, nil
+}
+
+func (r *RedemptionRepository) FindAllPaginated(status string, page int, pageSize int) (*[]entity.Redemption, int64, error) {
+	return r.findAllPaginated("[RedemptionRepository.FindAllPaginated]", r.DB, status, page, pageSize)
+}
+
+func (r *RedemptionRepository) FindAllByUserPaginated(userID uuid.UUID, status string, page int, pageSize int) (*[]entity.Redemption, int64, error) {
+	return r.findAllPaginated("[RedemptionRepository.FindAllByUserPaginated]", r.DB.Where("user_id = ?", userID), status, page, pageSize)
+}
+
+func (r *RedemptionRepository) findAllPaginated(caller string, query *gorm.DB, status string, page int, pageSize int) (*[]entity.Redemption, int64, error) {
+	var redemptions []entity.Redemption
+	var total int64
+
+	query = query.Model(&entity.Redemption{})
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		r.Log.Error(caller + " " + err.Error())
+		return nil, 0, errors.New(caller + " " + err.Error())
+	}
+
+	if err := query.Preload("Gift", GiftWithStockAndRatings).Preload("Rating").
+		Order("redeemed_at DESC").Order("id").Offset((page - 1) * pageSize).Limit(pageSize).Find(&redemptions).Error; err != nil {
+		r.Log.Error(caller + " " + err.Error())
+		return nil, 0, errors.New(caller + " " + err.Error())
+	}
+
+	return &redemptions, total, nil
+}
+
+// Redeem redeems the gift at now, whose location sets the calendar periods of
+// the redemption limits. It locks the gift row for the duration of the transaction so concurrent
+// redeemers are serialized and no gift code is ever handed out twice. The code
+// is reserved for the pending redemption until it is fulfilled. The price of
+// the gift is debited from the points of the user in the same transaction.
+func (r *RedemptionRepository) Redeem(userID uuid.UUID, giftID uuid.UUID, now time.Time) (*entity.Redemption, error) {
+	tx := r.DB.Begin()
+	if tx.Error != nil {
+		return nil, errors.New("[RedemptionRepository.Redeem] failed to begin transaction: " + tx.Error.Error())
+	}
+
+	var gift entity.Gift
+	if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Where("id = ?", giftID).First(&gift).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Warn("[RedemptionRepository.Redeem] Gift not found")
+			return nil, ErrGiftNotFound
+		}
+		r.Log.Error("[RedemptionRepository.Redeem] " + err.Error())
+		return nil, errors.New("[RedemptionRepository.Redeem] " + err.Error())
+	}
+
+	if !gift.HasStarted(now) {
+		tx.Rollback()
+		r.Log.Warn("[RedemptionRepository.Redeem] Gift is not available yet")
+		return nil, ErrGiftNotAvailable
+	}
+
+	if gift.IsExpired(now) {
+		tx.Rollback()
+		r.Log.Warn("[RedemptionRepository.Redeem] Gift has expired")
+		return nil, ErrGiftExpired
+	}
+
+	if err := checkRedemptionLimits(tx, &gift, userID, now); err != nil {
+		tx.Rollback()
+		var limitErr *RedemptionLimitError
+		if errors.As(err, &limitErr) {
+			r.Log.Warn("[RedemptionRepository.Redeem] " + err.Error())
+			return nil, err
+		}
+		r.Log.Error("[RedemptionRepository.Redeem] " + err.Error())
+		return nil, errors.New("[RedemptionRepository.Redeem] " + err.Error())
+	}
+
+	var code entity.GiftCode
+	if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Where("gift_id = ? AND status = ?", gift.ID, entity.GIFT_CODE_AVAILABLE).
+		Order("created_at").First(&code).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Warn("[RedemptionRepository.Redeem] Gift is out of stock")
+			return nil, ErrGiftOutOfStock
+		}
+		r.Log.Error("[RedemptionRepository.Redeem] " + err.Error())
+		return nil, errors.New("[RedemptionRepository.Redeem] " + err.Error())
+	}
+
+	redemption := entity.Redemption{
+		UserID:      userID,
+		GiftID:      gift.ID,
+		RedeemedAt:  now,
+		PointsSpent: int64(gift.Price),
+		GiftCodeID:  &code.ID,
+		Status:      entity.REDEMPTION_PENDING,
+	}
+
+	if err := tx.Omit(clause.Associations).Create(&redemption).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[RedemptionRepository.Redeem] " + err.Error())
+		return nil, errors.New("[RedemptionRepository.Redeem] " + err.Error())
+	}
+
+	if err := tx.Model(&code).Updates(map[string]interface{}{
+		"status":        entity.GIFT_CODE_RESERVED,
+		"redemption_id": redemption.ID,
+	}).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[RedemptionRepository.Redeem] " + err.Error())
+		return nil, errors.New("[RedemptionRepository.Redeem] " + err.Error())
+	}
+
+	if err := tx.Create(&entity.RedemptionEvent{
+		RedemptionID: redemption.ID,
+		ToStatus:     entity.REDEMPTION_PENDING,
+		ActorID:      &userID,
+	}).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[RedemptionRepository.Redeem] " + err.Error())
+		return nil, errors.New("[RedemptionRepository.Redeem] " + err.Error())
+	}
+
+	if redemption.PointsSpent > 0 {
+		if err := applyPointEntry(tx, &entity.PointLedger{
+			UserID:        userID,
+			Type:          entity.POINT_DEBIT,
+			Amount:        -redemption.PointsSpent,
+			Reason:        "Redeemed " + gift.Name,
+			ReferenceType: entity.POINT_REFERENCE_REDEMPTION,
+			ReferenceID:   &redemption.ID,
+		}); err != nil {
+			tx.Rollback()
+			if errors.Is(err, ErrInsufficientPoints) {
+				r.Log.Warn("[RedemptionRepository.Redeem] Not enough points")
+				return nil, ErrInsufficientPoints
+			}
+			r.Log.Error("[RedemptionRepository.Redeem] " + err.Error())
+			return nil, errors.New("[RedemptionRepository.Redeem] " + err.Error())
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[RedemptionRepository.Redeem] failed to commit transaction: " + err.Error())
+		return nil, errors.New("[RedemptionRepository.Redeem] failed to commit transaction: " + err.Error())
+	}
+
+	return r.FindById(redemption.ID)
+}
+
+// checkRedemptionLimits counts the redemptions of the gift that were not
+// cancelled against its limits. The gift row is locked by the caller, so the
+// counts cannot change before the new redemption is stored. The limit that
+// blocks the user the longest is reported first.
+func checkRedemptionLimits(tx *gorm.DB, gift *entity.Gift, userID uuid.UUID, now time.Time) error {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	week := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	tomorrow := today.AddDate(0, 0, 1)
+	nextWeek := week.AddDate(0, 0, 7)
+	nextMonth := month.AddDate(0, 1, 0)
+
+	limits := []struct {
+		code     entity.GiftLimitCode
+		max      int
+		perUser  bool
+		since    *time.Time
+		resetsAt *time.Time
+	}{
+		{entity.GIFT_LIMIT_PER_USER, gift.MaxPerUser, true, nil, nil},
+		{entity.GIFT_LIMIT_PER_USER_MONTHLY, gift.MaxPerUserMonthly, true, &month, &nextMonth},
+		{entity.GIFT_LIMIT_PER_USER_WEEKLY, gift.MaxPerUserWeekly, true, &week, &nextWeek},
+		{entity.GIFT_LIMIT_PER_USER_DAILY, gift.MaxPerUserDaily, true, &today, &tomorrow},
+		{entity.GIFT_LIMIT_DAILY, gift.MaxDaily, false, &today, &tomorrow},
+	}
+
+	for _, limit := range limits {
+		if limit.max <= 0 {
+			continue
+		}
+
+		query := tx.Model(&entity.Redemption{}).Where("gift_id = ? AND status <> ?", gift.ID, entity.REDEMPTION_CANCELLED)
+		if limit.perUser {
+			query = query.Where("user_id = ?", userID)
+		}
+		if limit.since != nil {
+			query = query.Where("redeemed_at >= ?", *limit.since)
+		}
+
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count >= int64(limit.max) {
+			return &RedemptionLimitError{
+				Code:     limit.code,
+				Max:      limit.max,
+				ResetsAt: limit.resetsAt,
+			}
+		}
+	}
+
+	return nil
+}
+
+// Transition moves a redemption to another status and records the change. The
+// side effects of the new status happen in the same transaction:
+//   - fulfilling issues the reserved gift code to the redeemer
+//   - cancelling returns the reserved gift code to the stock and gives the
+//     points back
+//   - refunding gives the points back, the code was already handed out
+func (r *RedemptionRepository) Transition(id uuid.UUID, to entity.RedemptionStatus, actorID *uuid.UUID, note string) (*entity.Redemption, error) {
+	tx := r.DB.Begin()
+	if tx.Error != nil {
+		return nil, errors.New("[RedemptionRepository.Transition] failed to begin transaction: " + tx.Error.Error())
+	}
+
+	var redemption entity.Redemption
+	if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Where("id = ?", id).First(&redemption).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Warn("[RedemptionRepository.Transition] Redemption not found")
+			return nil, ErrRedemptionNotFound
+		}
+		r.Log.Error("[RedemptionRepository.Transition] " + err.Error())
+		return nil, errors.New("[RedemptionRepository.Transition] " + err.Error())
+	}
+
+	if !redemption.Status.CanTransitionTo(to) {
+		tx.Rollback()
+		r.Log.Warn("[RedemptionRepository.Transition] Cannot move from " + string(redemption.Status) + " to " + string(to))
+		return nil, ErrInvalidRedemptionTransition
+	}
+
+	if err := applyRedemptionTransition(tx, &redemption, to, actorID); err != nil {
+		tx.Rollback()
+		r.Log.Error("[RedemptionRepository.Transition] " + err.Error())
+		return nil, errors.New("[RedemptionRepository.Transition] " + err.Error())
+	}
+
+	if err := tx.Create(&entity.RedemptionEvent{
+		RedemptionID: redemption.ID,
+		FromStatus:   redemption.Status,
+		ToStatus:     to,
+		ActorID:      actorID,
+		Note:         note,
+	}).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[RedemptionRepository.Transition] " + err.Error())
+		return nil, errors.New("[RedemptionRepository.Transition] " + err.Error())
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[RedemptionRepository.Transition] failed to commit transaction: " + err.Error())
+		return nil, errors.New("[RedemptionRepository.Transition] failed to commit transaction: " + err.Error())
+	}
+
+	return r.FindById(redemption.ID)
+}
+
+// applyRedemptionTransition performs the side effects of moving redemption to
+// the given status within tx and updates its status.
+func applyRedemptionTransition(tx *gorm.DB, redemption *entity.Redemption, to entity.RedemptionStatus, actorID *uuid.UUID) error {
+	updates := map[string]interface{}{
+		"status": to,
+	}
+
+	switch to {
+	case entity.REDEMPTION_FULFILLED:
+		if redemption.GiftCodeID != nil {
+			if err := tx.Model(&entity.GiftCode{}).Where("id = ?", *redemption.GiftCodeID).Updates(map[string]interface{}{
+				"status":    entity.GIFT_CODE_ISSUED,
+				"issued_at": time.Now(),
+			}).Error; err != nil {
+				return err
+			}
+		}
+	case entity.REDEMPTION_CANCELLED:
+		if redemption.GiftCodeID != nil {
+			if err := tx.Model(&entity.GiftCode{}).Where("id = ?", *redemption.GiftCodeID).Updates(map[string]interface{}{
+				"status":        entity.GIFT_CODE_AVAILABLE,
+				"redemption_id": nil,
+			}).Error; err != nil {
+				return err
+			}
+
+			// the code may be redeemed again, so it can no longer point back here
+			updates["gift_code_id"] = nil
+		}
+
+		if err := reverseRedemptionDebit(tx, redemption, actorID, "Cancelled redemption"); err != nil {
+			return err
+		}
+	case entity.REDEMPTION_REFUNDED:
+		if err := reverseRedemptionDebit(tx, redemption, actorID, "Refunded redemption"); err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&entity.Redemption{}).Where("id = ?", redemption.ID).Updates(updates).Error
+}
+
+// reverseRedemptionDebit credits back the points debited for the redemption,
+// if any were.
+func reverseRedemptionDebit(tx *gorm.DB, redemption *entity.Redemption, actorID *uuid.UUID, reason string) error {
+	var debit entity.PointLedger
+	err := tx.Where("reference_type = ? AND reference_id = ? AND type = ?",
+		entity.POINT_REFERENCE_REDEMPTION, redemption.ID, entity.POINT_DEBIT).First(&debit).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return applyPointEntry(tx, &entity.PointLedger{
+		UserID:        debit.UserID,
+		Type:          entity.POINT_REVERSAL,
+		Amount:        -debit.Amount,
+		Reason:        reason,
+		ReferenceType: entity.POINT_REFERENCE_REDEMPTION,
+		ReferenceID:   &redemption.ID,
+		ReversalOfID:  &debit.ID,
+		ActorID:       actorID,
+	})
+}
@@ -8,17 +8,21 @@ import (
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type IRoleRepository interface {
 	GetAllRoles() (*[]entity.Role, error)
 	FindAllPaginated(page int, pageSize int, search string) (*[]entity.Role, int64, error)
 	FindById(id uuid.UUID) (*entity.Role, error)
-	StoreRole(role *entity.Role) (*entity.Role, error)
-	UpdateRole(role *entity.Role) (*entity.Role, error)
+	FindByName(name string) (*entity.Role, error)
+	StoreRole(role *entity.Role, permissionIDs []uuid.UUID) (*entity.Role, error)
+	UpdateRole(role *entity.Role, permissionIDs []uuid.UUID) (*entity.Role, error)
 	GetAllRolesNotInUserID(userID uuid.UUID) (*[]entity.Role, error)
 	GetAllRolesInUserID(userID uuid.UUID) (*[]entity.Role, error)
 	DeleteRole(id uuid.UUID) error
+	AssignRolesToUser(userID uuid.UUID, roleIDs []uuid.UUID) error
+	RevokeRoleFromUser(userID uuid.UUID, roleID uuid.UUID) error
 }
 
 type RoleRepository struct {
@@ -40,7 +44,7 @@ func RoleRepositoryFactory(log *logrus.Logger) IRoleRepository {
 
 func (r *RoleRepository) GetAllRoles() (*[]entity.Role, error) {
 	var roles []entity.Role
-	if err := r.DB.Preload("Users").Find(&roles).Error; err != nil {
+	if err := r.DB.Preload("Users").Preload("Permissions").Find(&roles).Error; err != nil {
 		r.Log.Error(err)
 		return nil, err
 	}
@@ -51,18 +55,18 @@ func (r *RoleRepository) FindAllPaginated(page int, pageSize int, search string)
 	var roles []entity.Role
 	var total int64
 
-	query := r.DB.Preload("Users")
+	query := r.DB.Model(&entity.Role{})
 
 	if search != "" {
-		query = query.Where("name ILIKE ?", "%"+search+"%")
+		query = query.Where("name LIKE ?", "%"+search+"%")
 	}
 
-	if err := query.Offset((page - 1) * pageSize).Limit(pageSize).Find(&roles).Error; err != nil {
+	if err := query.Count(&total).Error; err != nil {
 		r.Log.Error("[RoleRepository.FindAllPaginated] " + err.Error())
 		return nil, 0, errors.New("[RoleRepository.FindAllPaginated] " + err.Error())
 	}
 
-	if err := query.Count(&total).Error; err != nil {
+	if err := query.Preload("Permissions").Order("name ASC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&roles).Error; err != nil {
 		r.Log.Error("[RoleRepository.FindAllPaginated] " + err.Error())
 		return nil, 0, errors.New("[RoleRepository.FindAllPaginated] " + err.Error())
 	}
@@ -72,43 +76,97 @@ func (r *RoleRepository) FindAllPaginated(page int, pageSize int, search string)
 
 func (r *RoleRepository) FindById(id uuid.UUID) (*entity.Role, error) {
 	var role entity.Role
-	if err := r.DB.Preload("Users").Where("id = ?", id).First(&role).Error; err != nil {
-		r.Log.Error(err)
-		return nil, err
+	err := r.DB.Preload("Users").Preload("Permissions").Where("id = ?", id).First(&role).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Warn("[RoleRepository.FindById] Role not found")
+			return nil, nil
+		} else {
+			r.Log.Error("[RoleRepository.FindById] " + err.Error())
+			return nil, errors.New("[RoleRepository.FindById] " + err.Error())
+		}
 	}
 	return &role, nil
 }
 
-func (r *RoleRepository) StoreRole(role *entity.Role) (*entity.Role, error) {
+func (r *RoleRepository) FindByName(name string) (*entity.Role, error) {
+	var role entity.Role
+	err := r.DB.Where("name = ?", name).First(&role).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Warn("[RoleRepository.FindByName] Role not found")
+			return nil, nil
+		} else {
+			r.Log.Error("[RoleRepository.FindByName] " + err.Error())
+			return nil, errors.New("[RoleRepository.FindByName] " + err.Error())
+		}
+	}
+	return &role, nil
+}
+
+func (r *RoleRepository) StoreRole(role *entity.Role, permissionIDs []uuid.UUID) (*entity.Role, error) {
 	tx := r.DB.Begin()
 	if tx.Error != nil {
-		return nil, errors.New("[UserRepository.CreateUser] failed to begin transaction: " + tx.Error.Error())
+		return nil, errors.New("[RoleRepository.StoreRole] failed to begin transaction: " + tx.Error.Error())
 	}
 
-	if err := tx.Create(role).Error; err != nil {
+	if err := tx.Omit(clause.Associations).Create(role).Error; err != nil {
 		tx.Rollback()
-		r.Log.Error(err)
-		return nil, err
+		r.Log.Error("[RoleRepository.StoreRole] " + err.Error())
+		return nil, errors.New("[RoleRepository.StoreRole] " + err.Error())
+	}
+
+	if err := r.syncRolePermissions(tx, role.ID, permissionIDs); err != nil {
+		tx.Rollback()
+		r.Log.Error("[RoleRepository.StoreRole] " + err.Error())
+		return nil, errors.New("[RoleRepository.StoreRole] " + err.Error())
 	}
 
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
-		r.Log.Error("[RoleRepository.CreateRole] failed to commit transaction: " + err.Error())
-		return nil, errors.New("[RoleRepository.CreateRole] failed to commit transaction: " + err.Error())
+		r.Log.Error("[RoleRepository.StoreRole] failed to commit transaction: " + err.Error())
+		return nil, errors.New("[RoleRepository.StoreRole] failed to commit transaction: " + err.Error())
 	}
 
-	return role, nil
+	return r.FindById(role.ID)
 }
 
-func (r *RoleRepository) UpdateRole(role *entity.Role) (*entity.Role, error) {
+func (r *RoleRepository) UpdateRole(role *entity.Role, permissionIDs []uuid.UUID) (*entity.Role, error) {
 	tx := r.DB.Begin()
 	if tx.Error != nil {
-		return nil, errors.New("[UserRepository.CreateUser] failed to begin transaction: " + tx.Error.Error())
+		return nil, errors.New("[RoleRepository.UpdateRole] failed to begin transaction: " + tx.Error.Error())
 	}
 
-	if err := tx.Model(&role).Where("id = ?", role.ID).Updates(role).Error; err != nil {
-		r.Log.Error(err)
-		return nil, err
+	if err := tx.Model(role).Omit(clause.Associations).Where("id = ?", role.ID).Updates(entity.Role{
+		Name:      role.Name,
+		GuardName: role.GuardName,
+		Status:    role.Status,
+	}).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[RoleRepository.UpdateRole] " + err.Error())
+		return nil, errors.New("[RoleRepository.UpdateRole] " + err.Error())
+	}
+
+	// updated separately since Updates with a struct skips false values
+	if err := tx.Model(&entity.Role{}).Where("id = ?", role.ID).Update("require_two_factor", role.RequireTwoFactor).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[RoleRepository.UpdateRole] " + err.Error())
+		return nil, errors.New("[RoleRepository.UpdateRole] " + err.Error())
+	}
+
+	if len(permissionIDs) > 0 {
+		// replace the role permissions with the given ones
+		if err := tx.Where("role_id = ?", role.ID).Delete(&entity.RolePermission{}).Error; err != nil {
+			tx.Rollback()
+			r.Log.Error("[RoleRepository.UpdateRole] " + err.Error())
+			return nil, errors.New("[RoleRepository.UpdateRole] " + err.Error())
+		}
+
+		if err := r.syncRolePermissions(tx, role.ID, permissionIDs); err != nil {
+			tx.Rollback()
+			r.Log.Error("[RoleRepository.UpdateRole] " + err.Error())
+			return nil, errors.New("[RoleRepository.UpdateRole] " + err.Error())
+		}
 	}
 
 	if err := tx.Commit().Error; err != nil {
@@ -116,18 +174,39 @@ func (r *RoleRepository) UpdateRole(role *entity.Role) (*entity.Role, error) {
 		r.Log.Error("[RoleRepository.UpdateRole] failed to commit transaction: " + err.Error())
 		return nil, errors.New("[RoleRepository.UpdateRole] failed to commit transaction: " + err.Error())
 	}
-	return role, nil
+
+	return r.FindById(role.ID)
+}
+
+func (r *RoleRepository) syncRolePermissions(tx *gorm.DB, roleID uuid.UUID, permissionIDs []uuid.UUID) error {
+	for _, permissionID := range permissionIDs {
+		var permission entity.Permission
+		if err := tx.First(&permission, "id = ?", permissionID).Error; err != nil {
+			return errors.New("permission not found: " + err.Error())
+		}
+
+		if err := tx.Create(&entity.RolePermission{
+			RoleID:       roleID,
+			PermissionID: permission.ID,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (r *RoleRepository) DeleteRole(id uuid.UUID) error {
 	tx := r.DB.Begin()
 	if tx.Error != nil {
-		return errors.New("[UserRepository.DeleteUser] failed to begin transaction: " + tx.Error.Error())
+		return errors.New("[RoleRepository.DeleteRole] failed to begin transaction: " + tx.Error.Error())
 	}
+
 	if err := tx.Where("id = ?", id).Delete(&entity.Role{}).Error; err != nil {
-		r.Log.Error(err)
-		return err
+		tx.Rollback()
+		r.Log.Error("[RoleRepository.DeleteRole] " + err.Error())
+		return errors.New("[RoleRepository.DeleteRole] " + err.Error())
 	}
+
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
 		r.Log.Error("[RoleRepository.DeleteRole] failed to commit transaction: " + err.Error())
@@ -139,7 +218,7 @@ func (r *RoleRepository) DeleteRole(id uuid.UUID) error {
 func (r *RoleRepository) GetAllRolesNotInUserID(userID uuid.UUID) (*[]entity.Role, error) {
 	var roles []entity.Role
 
-	if err := r.DB.Preload("Users").Where("id NOT IN (?)", r.DB.Table("user_roles").Select("role_id").Where("user_id = ?", userID)).Find(&roles).Error; err != nil {
+	if err := r.DB.Preload("Users").Preload("Permissions").Where("id NOT IN (?)", r.DB.Table("user_roles").Select("role_id").Where("user_id = ?", userID)).Find(&roles).Error; err != nil {
 		r.Log.Error(err)
 		return nil, err
 	}
@@ -150,10 +229,53 @@ func (r *RoleRepository) GetAllRolesNotInUserID(userID uuid.UUID) (*[]entity.Rol
 func (r *RoleRepository) GetAllRolesInUserID(userID uuid.UUID) (*[]entity.Role, error) {
 	var roles []entity.Role
 
-	if err := r.DB.Preload("Users").Where("id IN (?)", r.DB.Table("user_roles").Select("role_id").Where("user_id = ?", userID)).Find(&roles).Error; err != nil {
+	if err := r.DB.Preload("Users").Preload("Permissions").Where("id IN (?)", r.DB.Table("user_roles").Select("role_id").Where("user_id = ?", userID)).Find(&roles).Error; err != nil {
 		r.Log.Error(err)
 		return nil, err
 	}
 
 	return &roles, nil
 }
+
+// AssignRolesToUser attaches the given roles to the user, skipping the ones the
+// user already holds.
+func (r *RoleRepository) AssignRolesToUser(userID uuid.UUID, roleIDs []uuid.UUID) error {
+	tx := r.DB.Begin()
+	if tx.Error != nil {
+		return errors.New("[RoleRepository.AssignRolesToUser] failed to begin transaction: " + tx.Error.Error())
+	}
+
+	for _, roleID := range roleIDs {
+		var role entity.Role
+		if err := tx.First(&role, "id = ?", roleID).Error; err != nil {
+			tx.Rollback()
+			r.Log.Error("[RoleRepository.AssignRolesToUser] Role not found: " + err.Error())
+			return errors.New("[RoleRepository.AssignRolesToUser] Role not found: " + err.Error())
+		}
+
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.UserRole{
+			UserID: userID,
+			RoleID: role.ID,
+		}).Error; err != nil {
+			tx.Rollback()
+			r.Log.Error("[RoleRepository.AssignRolesToUser] " + err.Error())
+			return errors.New("[RoleRepository.AssignRolesToUser] " + err.Error())
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[RoleRepository.AssignRolesToUser] failed to commit transaction: " + err.Error())
+		return errors.New("[RoleRepository.AssignRolesToUser] failed to commit transaction: " + err.Error())
+	}
+
+	return nil
+}
+
+func (r *RoleRepository) RevokeRoleFromUser(userID uuid.UUID, roleID uuid.UUID) error {
+	if err := r.DB.Where("user_id = ? AND role_id = ?", userID, roleID).Delete(&entity.UserRole{}).Error; err != nil {
+		r.Log.Error("[RoleRepository.RevokeRoleFromUser] " + err.Error())
+		return errors.New("[RoleRepository.RevokeRoleFromUser] " + err.Error())
+	}
+	return nil
+}
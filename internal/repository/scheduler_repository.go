@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ISchedulerRepository interface {
+	AcquireLease(jobName string, owner string, scheduledAt time.Time, lockedUntil time.Time) (bool, error)
+	ReleaseLease(jobName string, owner string) error
+	CreateRun(run *entity.SchedulerRun) error
+	FinishRun(id uuid.UUID, status entity.SchedulerRunStatus, message string) error
+	FindAllRunsPaginated(jobName string, status string, page int, pageSize int) (*[]entity.SchedulerRun, int64, error)
+}
+
+type SchedulerRepository struct {
+	Log *logrus.Logger
+	DB  *gorm.DB
+}
+
+func NewSchedulerRepository(log *logrus.Logger, db *gorm.DB) ISchedulerRepository {
+	return &SchedulerRepository{
+		Log: log,
+		DB:  db,
+	}
+}
+
+func SchedulerRepositoryFactory(log *logrus.Logger) ISchedulerRepository {
+	db := config.NewDatabase()
+	return NewSchedulerRepository(log, db)
+}
+
+// AcquireLease takes the lease of the job for the tick at scheduledAt. It fails
+// when another instance still holds the lease or the tick has already been run.
+func (r *SchedulerRepository) AcquireLease(jobName string, owner string, scheduledAt time.Time, lockedUntil time.Time) (bool, error) {
+	if err := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.SchedulerLease{
+		JobName: jobName,
+	}).Error; err != nil {
+		r.Log.Error("[SchedulerRepository.AcquireLease] " + err.Error())
+		return false, errors.New("[SchedulerRepository.AcquireLease] " + err.Error())
+	}
+
+	// a single conditional update, so two instances can never both succeed
+	result := r.DB.Model(&entity.SchedulerLease{}).
+		Where("job_name = ?", jobName).
+		Where("locked_until IS NULL OR locked_until <= ?", time.Now()).
+		Where("last_scheduled_at IS NULL OR last_scheduled_at < ?", scheduledAt).
+		Updates(map[string]interface{}{
+			"owner":             owner,
+			"locked_until":      lockedUntil,
+			"last_scheduled_at": scheduledAt,
+		})
+	if result.Error != nil {
+		r.Log.Error("[SchedulerRepository.AcquireLease] " + result.Error.Error())
+		return false, errors.New("[SchedulerRepository.AcquireLease] " + result.Error.Error())
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// ReleaseLease lets the next tick of the job run right away, on any instance.
+func (r *SchedulerRepository) ReleaseLease(jobName string, owner string) error {
+	if err := r.DB.Model(&entity.SchedulerLease{}).
+		Where("job_name = ? AND owner = ?", jobName, owner).
+		Update("locked_until", nil).Error; err != nil {
+		r.Log.Error("[SchedulerRepository.ReleaseLease] " + err.Error())
+		return errors.New("[SchedulerRepository.ReleaseLease] " + err.Error())
+	}
+	return nil
+}
+
+func (r *SchedulerRepository) CreateRun(run *entity.SchedulerRun) error {
+	if err := r.DB.Create(run).Error; err != nil {
+		r.Log.Error("[SchedulerRepository.CreateRun] " + err.Error())
+		return errors.New("[SchedulerRepository.CreateRun] " + err.Error())
+	}
+	return nil
+}
+
+func (r *SchedulerRepository) FinishRun(id uuid.UUID, status entity.SchedulerRunStatus, message string) error {
+	if err := r.DB.Model(&entity.SchedulerRun{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":      status,
+		"message":     message,
+		"finished_at": time.Now(),
+	}).Error; err != nil {
+		r.Log.Error("[SchedulerRepository.FinishRun] " + err.Error())
+		return errors.New("[SchedulerRepository.FinishRun] " + err.Error())
+	}
+	return nil
+}
+
+func (r *SchedulerRepository) FindAllRunsPaginated(jobName string, status string, page int, pageSize int) (*[]entity.SchedulerRun, int64, error) {
+	var runs []entity.SchedulerRun
+	var total int64
+
+	query := r.DB.Model(&entity.SchedulerRun{})
+
+	if jobName != "" {
+		query = query.Where("job_name = ?", jobName)
+	}
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		r.Log.Error("[SchedulerRepository.FindAllRunsPaginated] " + err.Error())
+		return nil, 0, errors.New("[SchedulerRepository.FindAllRunsPaginated] " + err.Error())
+	}
+
+	if err := query.Order("started_at DESC").Order("id").Offset((page - 1) * pageSize).Limit(pageSize).Find(&runs).Error; err != nil {
+		r.Log.Error("[SchedulerRepository.FindAllRunsPaginated] " + err.Error())
+		return nil, 0, errors.New("[SchedulerRepository.FindAllRunsPaginated] " + err.Error())
+	}
+
+	return &runs, total, nil
+}
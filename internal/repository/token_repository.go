@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used")
+)
+
+type ITokenRepository interface {
+	CreateRefreshToken(token *entity.RefreshToken) error
+	RotateRefreshToken(tokenHash string, newToken *entity.RefreshToken) (*entity.RefreshToken, error)
+	RevokeRefreshTokenFamily(tokenHash string, userID uuid.UUID) error
+	RevokeFamily(familyID uuid.UUID) error
+	RevokeAllRefreshTokens(userID uuid.UUID) error
+	RevokeAccessToken(jti string, userID uuid.UUID, expiredAt time.Time) error
+	IsAccessTokenRevoked(jti string) (bool, error)
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+type TokenRepository struct {
+	Log *logrus.Logger
+	DB  *gorm.DB
+}
+
+func NewTokenRepository(log *logrus.Logger, db *gorm.DB) ITokenRepository {
+	return &TokenRepository{
+		Log: log,
+		DB:  db,
+	}
+}
+
+func TokenRepositoryFactory(log *logrus.Logger) ITokenRepository {
+	db := config.NewDatabase()
+	return NewTokenRepository(log, db)
+}
+
+func (r *TokenRepository) CreateRefreshToken(token *entity.RefreshToken) error {
+	if err := r.DB.Omit(clause.Associations).Create(token).Error; err != nil {
+		r.Log.Error("[TokenRepository.CreateRefreshToken] " + err.Error())
+		return errors.New("[TokenRepository.CreateRefreshToken] " + err.Error())
+	}
+	return nil
+}
+
+// RotateRefreshToken consumes the refresh token with the given hash and stores
+// newToken as its successor in the same family. Presenting a token that was
+// already consumed revokes the whole family, since either the legitimate client
+// or an attacker is holding a stolen copy.
+func (r *TokenRepository) RotateRefreshToken(tokenHash string, newToken *entity.RefreshToken) (*entity.RefreshToken, error) {
+	tx := r.DB.Begin()
+	if tx.Error != nil {
+		return nil, errors.New("[TokenRepository.RotateRefreshToken] failed to begin transaction: " + tx.Error.Error())
+	}
+
+	var current entity.RefreshToken
+	if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Where("token_hash = ?", tokenHash).First(&current).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Warn("[TokenRepository.RotateRefreshToken] Refresh token not found")
+			return nil, ErrRefreshTokenInvalid
+		}
+		r.Log.Error("[TokenRepository.RotateRefreshToken] " + err.Error())
+		return nil, errors.New("[TokenRepository.RotateRefreshToken] " + err.Error())
+	}
+
+	now := time.Now()
+
+	if current.RevokedAt != nil {
+		if err := tx.Model(&entity.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", current.FamilyID).
+			Update("revoked_at", now).Error; err != nil {
+			tx.Rollback()
+			r.Log.Error("[TokenRepository.RotateRefreshToken] " + err.Error())
+			return nil, errors.New("[TokenRepository.RotateRefreshToken] " + err.Error())
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			tx.Rollback()
+			r.Log.Error("[TokenRepository.RotateRefreshToken] failed to commit transaction: " + err.Error())
+			return nil, errors.New("[TokenRepository.RotateRefreshToken] failed to commit transaction: " + err.Error())
+		}
+
+		r.Log.Warn("[TokenRepository.RotateRefreshToken] Refresh token reused, family " + current.FamilyID.String() + " revoked")
+		return nil, ErrRefreshTokenReused
+	}
+
+	if !current.ExpiredAt.After(now) {
+		tx.Rollback()
+		r.Log.Warn("[TokenRepository.RotateRefreshToken] Refresh token expired")
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	newToken.ID = uuid.New()
+	newToken.UserID = current.UserID
+	newToken.FamilyID = current.FamilyID
+	newToken.TwoFactorVerified = current.TwoFactorVerified
+
+	if err := tx.Omit(clause.Associations).Create(newToken).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[TokenRepository.RotateRefreshToken] " + err.Error())
+		return nil, errors.New("[TokenRepository.RotateRefreshToken] " + err.Error())
+	}
+
+	if err := tx.Model(&current).Updates(map[string]interface{}{
+		"revoked_at":     now,
+		"replaced_by_id": newToken.ID,
+	}).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[TokenRepository.RotateRefreshToken] " + err.Error())
+		return nil, errors.New("[TokenRepository.RotateRefreshToken] " + err.Error())
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[TokenRepository.RotateRefreshToken] failed to commit transaction: " + err.Error())
+		return nil, errors.New("[TokenRepository.RotateRefreshToken] failed to commit transaction: " + err.Error())
+	}
+
+	return newToken, nil
+}
+
+// RevokeRefreshTokenFamily revokes the family of the given refresh token as long
+// as it belongs to the user, which ends the session it was issued for.
+func (r *TokenRepository) RevokeRefreshTokenFamily(tokenHash string, userID uuid.UUID) error {
+	var token entity.RefreshToken
+	if err := r.DB.Where("token_hash = ? AND user_id = ?", tokenHash, userID).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Warn("[TokenRepository.RevokeRefreshTokenFamily] Refresh token not found")
+			return ErrRefreshTokenInvalid
+		}
+		r.Log.Error("[TokenRepository.RevokeRefreshTokenFamily] " + err.Error())
+		return errors.New("[TokenRepository.RevokeRefreshTokenFamily] " + err.Error())
+	}
+
+	return r.RevokeFamily(token.FamilyID)
+}
+
+func (r *TokenRepository) RevokeFamily(familyID uuid.UUID) error {
+	if err := r.DB.Model(&entity.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		r.Log.Error("[TokenRepository.RevokeFamily] " + err.Error())
+		return errors.New("[TokenRepository.RevokeFamily] " + err.Error())
+	}
+	return nil
+}
+
+func (r *TokenRepository) RevokeAllRefreshTokens(userID uuid.UUID) error {
+	if err := r.DB.Model(&entity.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		r.Log.Error("[TokenRepository.RevokeAllRefreshTokens] " + err.Error())
+		return errors.New("[TokenRepository.RevokeAllRefreshTokens] " + err.Error())
+	}
+	return nil
+}
+
+func (r *TokenRepository) RevokeAccessToken(jti string, userID uuid.UUID, expiredAt time.Time) error {
+	revoked := entity.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiredAt: expiredAt,
+	}
+
+	if err := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&revoked).Error; err != nil {
+		r.Log.Error("[TokenRepository.RevokeAccessToken] " + err.Error())
+		return errors.New("[TokenRepository.RevokeAccessToken] " + err.Error())
+	}
+	return nil
+}
+
+func (r *TokenRepository) IsAccessTokenRevoked(jti string) (bool, error) {
+	var count int64
+	if err := r.DB.Model(&entity.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		r.Log.Error("[TokenRepository.IsAccessTokenRevoked] " + err.Error())
+		return false, errors.New("[TokenRepository.IsAccessTokenRevoked] " + err.Error())
+	}
+	return count > 0, nil
+}
+
+// DeleteExpired removes refresh tokens and denylisted access tokens that
+// expired before the given time. Neither can be used anymore, so reuse
+// detection and the denylist do not need them.
+func (r *TokenRepository) DeleteExpired(before time.Time) (int64, error) {
+	refreshTokens := r.DB.Where("expired_at < ?", before).Delete(&entity.RefreshToken{})
+	if refreshTokens.Error != nil {
+		r.Log.Error("[TokenRepository.DeleteExpired] " + refreshTokens.Error.Error())
+		return 0, errors.New("[TokenRepository.DeleteExpired] " + refreshTokens.Error.Error())
+	}
+
+	revokedTokens := r.DB.Where("expired_at < ?", before).Delete(&entity.RevokedToken{})
+	if revokedTokens.Error != nil {
+		r.Log.Error("[TokenRepository.DeleteExpired] " + revokedTokens.Error.Error())
+		return refreshTokens.RowsAffected, errors.New("[TokenRepository.DeleteExpired] " + revokedTokens.Error.Error())
+	}
+
+	return refreshTokens.RowsAffected + revokedTokens.RowsAffected, nil
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type ITwoFactorRepository interface {
+	SetSecret(userID uuid.UUID, secret string) error
+	Enable(userID uuid.UUID, step int64, recoveryCodeHashes []string) error
+	Disable(userID uuid.UUID) error
+	ConsumeStep(userID uuid.UUID, step int64) (bool, error)
+	UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error)
+}
+
+type TwoFactorRepository struct {
+	Log *logrus.Logger
+	DB  *gorm.DB
+}
+
+func NewTwoFactorRepository(log *logrus.Logger, db *gorm.DB) ITwoFactorRepository {
+	return &TwoFactorRepository{
+		Log: log,
+		DB:  db,
+	}
+}
+
+func TwoFactorRepositoryFactory(log *logrus.Logger) ITwoFactorRepository {
+	db := config.NewDatabase()
+	return NewTwoFactorRepository(log, db)
+}
+
+// SetSecret stores a new, not yet confirmed secret for the user.
+func (r *TwoFactorRepository) SetSecret(userID uuid.UUID, secret string) error {
+	if err := r.DB.Model(&entity.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"two_factor_secret":     secret,
+		"two_factor_enabled_at": nil,
+		"two_factor_last_step":  0,
+	}).Error; err != nil {
+		r.Log.Error("[TwoFactorRepository.SetSecret] " + err.Error())
+		return errors.New("[TwoFactorRepository.SetSecret] " + err.Error())
+	}
+	return nil
+}
+
+// Enable turns on 2FA for the user and replaces their recovery codes.
+func (r *TwoFactorRepository) Enable(userID uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	tx := r.DB.Begin()
+	if tx.Error != nil {
+		return errors.New("[TwoFactorRepository.Enable] failed to begin transaction: " + tx.Error.Error())
+	}
+
+	if err := tx.Model(&entity.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"two_factor_enabled_at": time.Now(),
+		"two_factor_last_step":  step,
+	}).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[TwoFactorRepository.Enable] " + err.Error())
+		return errors.New("[TwoFactorRepository.Enable] " + err.Error())
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&entity.UserRecoveryCode{}).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[TwoFactorRepository.Enable] " + err.Error())
+		return errors.New("[TwoFactorRepository.Enable] " + err.Error())
+	}
+
+	codes := make([]entity.UserRecoveryCode, 0, len(recoveryCodeHashes))
+	for _, hash := range recoveryCodeHashes {
+		codes = append(codes, entity.UserRecoveryCode{
+			UserID:   userID,
+			CodeHash: hash,
+		})
+	}
+
+	if err := tx.Omit("User").Create(&codes).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[TwoFactorRepository.Enable] " + err.Error())
+		return errors.New("[TwoFactorRepository.Enable] " + err.Error())
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[TwoFactorRepository.Enable] failed to commit transaction: " + err.Error())
+		return errors.New("[TwoFactorRepository.Enable] failed to commit transaction: " + err.Error())
+	}
+
+	return nil
+}
+
+// Disable removes the secret and the recovery codes of the user.
+func (r *TwoFactorRepository) Disable(userID uuid.UUID) error {
+	tx := r.DB.Begin()
+	if tx.Error != nil {
+		return errors.New("[TwoFactorRepository.Disable] failed to begin transaction: " + tx.Error.Error())
+	}
+
+	if err := tx.Model(&entity.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"two_factor_secret":     "",
+		"two_factor_enabled_at": nil,
+		"two_factor_last_step":  0,
+	}).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[TwoFactorRepository.Disable] " + err.Error())
+		return errors.New("[TwoFactorRepository.Disable] " + err.Error())
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&entity.UserRecoveryCode{}).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[TwoFactorRepository.Disable] " + err.Error())
+		return errors.New("[TwoFactorRepository.Disable] " + err.Error())
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[TwoFactorRepository.Disable] failed to commit transaction: " + err.Error())
+		return errors.New("[TwoFactorRepository.Disable] failed to commit transaction: " + err.Error())
+	}
+
+	return nil
+}
+
+// ConsumeStep records a TOTP time step as used. It returns false when the step
+// or a later one was already accepted, which rejects replayed codes.
+func (r *TwoFactorRepository) ConsumeStep(userID uuid.UUID, step int64) (bool, error) {
+	result := r.DB.Model(&entity.User{}).
+		Where("id = ? AND two_factor_last_step < ?", userID, step).
+		Update("two_factor_last_step", step)
+	if result.Error != nil {
+		r.Log.Error("[TwoFactorRepository.ConsumeStep] " + result.Error.Error())
+		return false, errors.New("[TwoFactorRepository.ConsumeStep] " + result.Error.Error())
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// UseRecoveryCode marks an unused recovery code of the user as used.
+func (r *TwoFactorRepository) UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+	result := r.DB.Model(&entity.UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		r.Log.Error("[TwoFactorRepository.UseRecoveryCode] " + result.Error.Error())
+		return false, errors.New("[TwoFactorRepository.UseRecoveryCode] " + result.Error.Error())
+	}
+	return result.RowsAffected == 1, nil
+}
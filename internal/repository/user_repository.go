@@ -2,6 +2,7 @@ package repository
 
 import (
 	"errors"
+	"time"
 
 	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
 	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
@@ -15,9 +16,18 @@ type IUserRepository interface {
 	FindAllPaginated(page int, pageSize int, search string) (*[]entity.User, int64, error)
 	FindById(id uuid.UUID) (*entity.User, error)
 	CreateUser(user *entity.User, roleIDs []uuid.UUID) (*entity.User, error)
-	UpdateUser(user *entity.User, roleIDs []uuid.UUID) (*entity.User, error)
+	UpdateUser(user *entity.User) (*entity.User, error)
+	UpdateUserStatus(id uuid.UUID, status entity.UserStatus) error
 	DeleteUser(id uuid.UUID) error
-	CreateUserToken(email string, token int) error
+	CreateUserToken(email string, token int, tokenType entity.UserTokenType, expiredAt time.Time) error
+	FindUserToken(email string, token int, tokenType entity.UserTokenType) (*entity.UserToken, error)
+	FindLatestUserToken(email string, tokenType entity.UserTokenType) (*entity.UserToken, error)
+	DeleteUserTokens(email string, tokenType entity.UserTokenType) error
+	RecordUserTokenFailure(email string, tokenType entity.UserTokenType, maxAttempts int) error
+	VerifyUserEmail(user *entity.User) error
+	ResetPassword(email string, hashedPassword string) error
+	DeleteExpiredUserTokens(before time.Time) (int64, error)
+	PurgePendingUsers(createdBefore time.Time) (int64, error)
 }
 
 type UserRepository struct {
@@ -34,7 +44,7 @@ func NewUserRepository(log *logrus.Logger, db *gorm.DB) IUserRepository {
 
 func (r *UserRepository) FindByEmail(email string) (*entity.User, error) {
 	var user entity.User
-	err := r.DB.Preload("Roles").Where("email = ?", email).First(&user).Error
+	err := r.DB.Preload("Roles.Permissions").Where("email = ?", email).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			r.Log.Warn("[UserRepository.FindByEmail] User not found")
@@ -51,18 +61,18 @@ func (r *UserRepository) FindAllPaginated(page int, pageSize int, search string)
 	var users []entity.User
 	var total int64
 
-	query := r.DB.Preload("Roles")
+	query := r.DB.Model(&entity.User{})
 
 	if search != "" {
-		query = query.Where("email ILIKE ?", "%"+search+"%").Or("name ILIKE ?", "%"+search+"%").Or("username ILIKE ?", "%"+search+"%")
+		query = query.Where("email LIKE ? OR name LIKE ? OR username LIKE ?", "%"+search+"%", "%"+search+"%", "%"+search+"%")
 	}
 
-	if err := query.Offset((page - 1) * pageSize).Limit(pageSize).Find(&users).Error; err != nil {
+	if err := query.Count(&total).Error; err != nil {
 		r.Log.Error("[UserRepository.FindAllPaginated] " + err.Error())
 		return nil, 0, errors.New("[UserRepository.FindAllPaginated] " + err.Error())
 	}
 
-	if err := query.Model(&entity.User{}).Count(&total).Error; err != nil {
+	if err := query.Preload("Roles.Permissions").Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&users).Error; err != nil {
 		r.Log.Error("[UserRepository.FindAllPaginated] " + err.Error())
 		return nil, 0, errors.New("[UserRepository.FindAllPaginated] " + err.Error())
 	}
@@ -72,7 +82,7 @@ func (r *UserRepository) FindAllPaginated(page int, pageSize int, search string)
 
 func (r *UserRepository) FindById(id uuid.UUID) (*entity.User, error) {
 	var user entity.User
-	err := r.DB.Preload("Roles").Where("id = ?", id).First(&user).Error
+	err := r.DB.Preload("Roles.Permissions").Where("id = ?", id).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			r.Log.Warn("[UserRepository.FindById] User not found")
@@ -123,7 +133,7 @@ func (r *UserRepository) CreateUser(user *entity.User, roleIDs []uuid.UUID) (*en
 		return nil, errors.New("[UserRepository.CreateUser] failed to commit transaction: " + err.Error())
 	}
 
-	if err := r.DB.Preload("Roles").First(user, user.ID).Error; err != nil {
+	if err := r.DB.Preload("Roles.Permissions").First(user, user.ID).Error; err != nil {
 		r.Log.Error("[UserRepository.CreateUser] Failed to reload user: " + err.Error())
 		return nil, errors.New("[UserRepository.CreateUser] Failed to reload user: " + err.Error())
 	}
@@ -131,7 +141,7 @@ func (r *UserRepository) CreateUser(user *entity.User, roleIDs []uuid.UUID) (*en
 	return user, nil
 }
 
-func (r *UserRepository) UpdateUser(user *entity.User, roleIDs []uuid.UUID) (*entity.User, error) {
+func (r *UserRepository) UpdateUser(user *entity.User) (*entity.User, error) {
 	tx := r.DB.Begin()
 	if tx.Error != nil {
 		return nil, errors.New("[UserRepository.UpdateUser] failed to begin transaction: " + tx.Error.Error())
@@ -141,49 +151,21 @@ func (r *UserRepository) UpdateUser(user *entity.User, roleIDs []uuid.UUID) (*en
 		Name:     user.Name,
 		Username: user.Username,
 		Email:    user.Email,
+		Password: user.Password,
 		Gender:   user.Gender,
-		Status:   user.Status,
 	}).Error; err != nil {
 		tx.Rollback()
 		r.Log.Error("[UserRepository.UpdateUser] " + err.Error())
 		return nil, errors.New("[UserRepository.UpdateUser] " + err.Error())
 	}
 
-	if len(roleIDs) > 0 {
-		// delete user role by user id
-		if err := tx.Where("user_id = ?", user.ID).Delete(&entity.UserRole{}).Error; err != nil {
-			tx.Rollback()
-			r.Log.Error("[UserRepository.UpdateUser] " + err.Error())
-			return nil, errors.New("[UserRepository.UpdateUser] " + err.Error())
-		}
-		for _, roleID := range roleIDs {
-			var role entity.Role
-			if err := tx.First(&role, "id = ?", roleID).Error; err != nil {
-				tx.Rollback()
-				r.Log.Error("[UserRepository.UpdateUser] Role not found: " + err.Error())
-				return nil, errors.New("[UserRepository.UpdateUser] Role not found: " + err.Error())
-			}
-
-			var userRole = entity.UserRole{
-				UserID: user.ID,
-				RoleID: role.ID,
-			}
-
-			if err := tx.Create(&userRole).Error; err != nil {
-				tx.Rollback()
-				r.Log.Error("[UserRepository.UpdateUser] " + err.Error())
-				return nil, errors.New("[UserRepository.UpdateUser] " + err.Error())
-			}
-		}
-	}
-
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
 		r.Log.Error("[UserRepository.CreateUser] failed to commit transaction: " + err.Error())
 		return nil, errors.New("[UserRepository.CreateUser] failed to commit transaction: " + err.Error())
 	}
 
-	if err := r.DB.Preload("Roles").First(user, user.ID).Error; err != nil {
+	if err := r.DB.Preload("Roles.Permissions").First(user, user.ID).Error; err != nil {
 		r.Log.Error("[UserRepository.CreateUser] Failed to reload user: " + err.Error())
 		return nil, errors.New("[UserRepository.CreateUser] Failed to reload user: " + err.Error())
 	}
@@ -191,6 +173,14 @@ func (r *UserRepository) UpdateUser(user *entity.User, roleIDs []uuid.UUID) (*en
 	return user, nil
 }
 
+func (r *UserRepository) UpdateUserStatus(id uuid.UUID, status entity.UserStatus) error {
+	if err := r.DB.Model(&entity.User{}).Where("id = ?", id).Update("status", status).Error; err != nil {
+		r.Log.Error("[UserRepository.UpdateUserStatus] " + err.Error())
+		return errors.New("[UserRepository.UpdateUserStatus] " + err.Error())
+	}
+	return nil
+}
+
 func (r *UserRepository) DeleteUser(id uuid.UUID) error {
 	tx := r.DB.Begin()
 	if tx.Error != nil {
@@ -219,7 +209,7 @@ func (r *UserRepository) DeleteUser(id uuid.UUID) error {
 	return nil
 }
 
-func (r *UserRepository) CreateUserToken(email string, token int) error {
+func (r *UserRepository) CreateUserToken(email string, token int, tokenType entity.UserTokenType, expiredAt time.Time) error {
 	tx := r.DB.Begin()
 	if tx.Error != nil {
 		return errors.New("[UserRepository.CreateUserToken] failed to begin transaction: " + tx.Error.Error())
@@ -233,8 +223,10 @@ func (r *UserRepository) CreateUserToken(email string, token int) error {
 	}
 
 	var userToken = entity.UserToken{
-		Email: email,
-		Token: token,
+		Email:     email,
+		Token:     token,
+		TokenType: tokenType,
+		ExpiredAt: expiredAt,
 	}
 
 	if err := tx.Create(&userToken).Error; err != nil {
@@ -252,7 +244,195 @@ func (r *UserRepository) CreateUserToken(email string, token int) error {
 	return nil
 }
 
+func (r *UserRepository) FindUserToken(email string, token int, tokenType entity.UserTokenType) (*entity.UserToken, error) {
+	var userToken entity.UserToken
+	err := r.DB.Where("email = ? AND token = ? AND token_type = ?", email, token, tokenType).First(&userToken).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Warn("[UserRepository.FindUserToken] User token not found")
+			return nil, nil
+		} else {
+			r.Log.Error("[UserRepository.FindUserToken] " + err.Error())
+			return nil, errors.New("[UserRepository.FindUserToken] " + err.Error())
+		}
+	}
+	return &userToken, nil
+}
+
+func (r *UserRepository) FindLatestUserToken(email string, tokenType entity.UserTokenType) (*entity.UserToken, error) {
+	var userToken entity.UserToken
+	err := r.DB.Where("email = ? AND token_type = ?", email, tokenType).Order("created_at DESC").First(&userToken).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.Log.Warn("[UserRepository.FindLatestUserToken] User token not found")
+			return nil, nil
+		} else {
+			r.Log.Error("[UserRepository.FindLatestUserToken] " + err.Error())
+			return nil, errors.New("[UserRepository.FindLatestUserToken] " + err.Error())
+		}
+	}
+	return &userToken, nil
+}
+
+func (r *UserRepository) DeleteUserTokens(email string, tokenType entity.UserTokenType) error {
+	if err := r.DB.Where("email = ? AND token_type = ?", email, tokenType).Delete(&entity.UserToken{}).Error; err != nil {
+		r.Log.Error("[UserRepository.DeleteUserTokens] " + err.Error())
+		return errors.New("[UserRepository.DeleteUserTokens] " + err.Error())
+	}
+	return nil
+}
+
+// RecordUserTokenFailure counts a wrong code against every token of the type
+// issued to the email, and deletes them once maxAttempts wrong codes were tried.
+func (r *UserRepository) RecordUserTokenFailure(email string, tokenType entity.UserTokenType, maxAttempts int) error {
+	tx := r.DB.Begin()
+	if tx.Error != nil {
+		return errors.New("[UserRepository.RecordUserTokenFailure] failed to begin transaction: " + tx.Error.Error())
+	}
+
+	if err := tx.Model(&entity.UserToken{}).
+		Where("email = ? AND token_type = ?", email, tokenType).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1")).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[UserRepository.RecordUserTokenFailure] " + err.Error())
+		return errors.New("[UserRepository.RecordUserTokenFailure] " + err.Error())
+	}
+
+	if err := tx.Where("email = ? AND token_type = ? AND attempts >= ?", email, tokenType, maxAttempts).
+		Delete(&entity.UserToken{}).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[UserRepository.RecordUserTokenFailure] " + err.Error())
+		return errors.New("[UserRepository.RecordUserTokenFailure] " + err.Error())
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[UserRepository.RecordUserTokenFailure] failed to commit transaction: " + err.Error())
+		return errors.New("[UserRepository.RecordUserTokenFailure] failed to commit transaction: " + err.Error())
+	}
+
+	return nil
+}
+
+// VerifyUserEmail marks the user's email as verified, activates pending users
+// and consumes every outstanding verification token for the email.
+func (r *UserRepository) VerifyUserEmail(user *entity.User) error {
+	tx := r.DB.Begin()
+	if tx.Error != nil {
+		return errors.New("[UserRepository.VerifyUserEmail] failed to begin transaction: " + tx.Error.Error())
+	}
+
+	var updates = map[string]interface{}{
+		"email_verified_at": time.Now(),
+	}
+	if user.Status == entity.USER_PENDING {
+		updates["status"] = entity.USER_ACTIVE
+	}
+
+	if err := tx.Model(&entity.User{}).Where("id = ?", user.ID).Updates(updates).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[UserRepository.VerifyUserEmail] " + err.Error())
+		return errors.New("[UserRepository.VerifyUserEmail] " + err.Error())
+	}
+
+	if err := tx.Where("email = ? AND token_type = ?", user.Email, entity.UserTokenVerification).Delete(&entity.UserToken{}).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[UserRepository.VerifyUserEmail] " + err.Error())
+		return errors.New("[UserRepository.VerifyUserEmail] " + err.Error())
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[UserRepository.VerifyUserEmail] failed to commit transaction: " + err.Error())
+		return errors.New("[UserRepository.VerifyUserEmail] failed to commit transaction: " + err.Error())
+	}
+
+	return nil
+}
+
+// ResetPassword stores the new password hash and invalidates every outstanding
+// token issued to the email, whatever its type.
+func (r *UserRepository) ResetPassword(email string, hashedPassword string) error {
+	tx := r.DB.Begin()
+	if tx.Error != nil {
+		return errors.New("[UserRepository.ResetPassword] failed to begin transaction: " + tx.Error.Error())
+	}
+
+	if err := tx.Model(&entity.User{}).Where("email = ?", email).Update("password", hashedPassword).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[UserRepository.ResetPassword] " + err.Error())
+		return errors.New("[UserRepository.ResetPassword] " + err.Error())
+	}
+
+	if err := tx.Where("email = ?", email).Delete(&entity.UserToken{}).Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[UserRepository.ResetPassword] " + err.Error())
+		return errors.New("[UserRepository.ResetPassword] " + err.Error())
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		r.Log.Error("[UserRepository.ResetPassword] failed to commit transaction: " + err.Error())
+		return errors.New("[UserRepository.ResetPassword] failed to commit transaction: " + err.Error())
+	}
+
+	return nil
+}
+
 func UserRepositoryFactory(log *logrus.Logger) IUserRepository {
 	db := config.NewDatabase()
 	return NewUserRepository(log, db)
 }
+
+func (r *UserRepository) DeleteExpiredUserTokens(before time.Time) (int64, error) {
+	result := r.DB.Where("expired_at < ?", before).Delete(&entity.UserToken{})
+	if result.Error != nil {
+		r.Log.Error("[UserRepository.DeleteExpiredUserTokens] " + result.Error.Error())
+		return 0, errors.New("[UserRepository.DeleteExpiredUserTokens] " + result.Error.Error())
+	}
+	return result.RowsAffected, nil
+}
+
+// PurgePendingUsers permanently deletes users that registered before
+// createdBefore and never verified their email, together with their tokens.
+// Users that already have points are kept, so no ledger entry is lost.
+func (r *UserRepository) PurgePendingUsers(createdBefore time.Time) (int64, error) {
+	var users []entity.User
+	if err := r.DB.Where("status = ? AND email_verified_at IS NULL AND created_at < ?", entity.USER_PENDING, createdBefore).
+		Where("NOT EXISTS (SELECT 1 FROM point_ledgers WHERE point_ledgers.user_id = users.id)").
+		Find(&users).Error; err != nil {
+		r.Log.Error("[UserRepository.PurgePendingUsers] " + err.Error())
+		return 0, errors.New("[UserRepository.PurgePendingUsers] " + err.Error())
+	}
+
+	var purged int64
+	for _, user := range users {
+		tx := r.DB.Begin()
+		if tx.Error != nil {
+			return purged, errors.New("[UserRepository.PurgePendingUsers] failed to begin transaction: " + tx.Error.Error())
+		}
+
+		if err := tx.Where("email = ?", user.Email).Delete(&entity.UserToken{}).Error; err != nil {
+			tx.Rollback()
+			r.Log.Error("[UserRepository.PurgePendingUsers] " + err.Error())
+			return purged, errors.New("[UserRepository.PurgePendingUsers] " + err.Error())
+		}
+
+		// roles and other rows of the user are removed by their foreign keys
+		if err := tx.Unscoped().Where("id = ?", user.ID).Delete(&entity.User{}).Error; err != nil {
+			tx.Rollback()
+			r.Log.Error("[UserRepository.PurgePendingUsers] " + err.Error())
+			return purged, errors.New("[UserRepository.PurgePendingUsers] " + err.Error())
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			tx.Rollback()
+			r.Log.Error("[UserRepository.PurgePendingUsers] failed to commit transaction: " + err.Error())
+			return purged, errors.New("[UserRepository.PurgePendingUsers] failed to commit transaction: " + err.Error())
+		}
+
+		purged++
+	}
+
+	return purged, nil
+}
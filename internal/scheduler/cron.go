@@ -0,0 +1,272 @@
+package scheduler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression with the five standard fields: minute,
+// hour, day of month, month and day of week. Every field accepts *, values,
+// ranges (1-5), steps (*/15, 1-30/5) and comma separated lists of them. Day of
+// week 0 and 7 are both Sunday. As in cron, a day matches when it matches the
+// day of month or the day of week if both are restricted.
+//
+// The shortcuts @hourly, @daily (or @midnight), @weekly, @monthly and @yearly
+// (or @annually) are accepted as well.
+//
+// Daylight saving time is handled like cron does. A schedule with a fixed
+// minute and hour runs once when the clock goes back, at the first of the two
+// times, and runs right after the gap when the clock skips its time. A schedule
+// with a * in the minute or hour field runs at every time the clock shows.
+type Schedule struct {
+	minutes     uint64
+	hours       uint64
+	daysOfMonth uint64
+	months      uint64
+	daysOfWeek  uint64
+
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+	// wildcard is set when the minute or hour field starts with *
+	wildcard bool
+}
+
+type cronField struct {
+	name string
+	min  int
+	max  int
+}
+
+var (
+	minuteField     = cronField{"minute", 0, 59}
+	hourField       = cronField{"hour", 0, 23}
+	dayOfMonthField = cronField{"day of month", 1, 31}
+	monthField      = cronField{"month", 1, 12}
+	dayOfWeekField  = cronField{"day of week", 0, 7}
+)
+
+var cronShortcuts = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+}
+
+// maxScheduleLookahead bounds the search for the next run, so a schedule that
+// can never match, such as 30 February, does not loop forever.
+const maxScheduleLookahead = 5 * 366 * 24 * time.Hour
+
+func ParseSchedule(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if expanded, ok := cronShortcuts[strings.ToLower(spec)]; ok {
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, errors.New("[Schedule] expected 5 fields in " + strconv.Quote(spec))
+	}
+
+	schedule := &Schedule{
+		anyDayOfMonth: fields[2] == "*",
+		anyDayOfWeek:  fields[4] == "*",
+		wildcard:      strings.HasPrefix(fields[0], "*") || strings.HasPrefix(fields[1], "*"),
+	}
+
+	var err error
+	if schedule.minutes, err = parseCronField(fields[0], minuteField); err != nil {
+		return nil, err
+	}
+	if schedule.hours, err = parseCronField(fields[1], hourField); err != nil {
+		return nil, err
+	}
+	if schedule.daysOfMonth, err = parseCronField(fields[2], dayOfMonthField); err != nil {
+		return nil, err
+	}
+	if schedule.months, err = parseCronField(fields[3], monthField); err != nil {
+		return nil, err
+	}
+	if schedule.daysOfWeek, err = parseCronField(fields[4], dayOfWeekField); err != nil {
+		return nil, err
+	}
+
+	// 7 is another name for Sunday
+	if schedule.daysOfWeek&(1<<7) != 0 {
+		schedule.daysOfWeek |= 1
+	}
+
+	return schedule, nil
+}
+
+// parseCronField returns the bit set of the values the field matches.
+func parseCronField(value string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(value, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rangePart = part[:i]
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return 0, errors.New("[Schedule] invalid step in " + field.name + " " + strconv.Quote(part))
+			}
+		}
+
+		start, end := field.min, field.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if start, err = parseCronValue(bounds[0], field); err != nil {
+				return 0, err
+			}
+			if end, err = parseCronValue(bounds[1], field); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, errors.New("[Schedule] invalid range in " + field.name + " " + strconv.Quote(part))
+			}
+		default:
+			var err error
+			if start, err = parseCronValue(rangePart, field); err != nil {
+				return 0, err
+			}
+			// a single value with a step runs from the value to the maximum
+			end = start
+			if strings.Contains(part, "/") {
+				end = field.max
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func parseCronValue(value string, field cronField) (int, error) {
+	v, err := strconv.Atoi(value)
+	if err != nil || v < field.min || v > field.max {
+		return 0, errors.New("[Schedule] invalid " + field.name + " " + strconv.Quote(value))
+	}
+	return v, nil
+}
+
+// Next returns the first time after t the schedule matches, in the location of
+// t. It returns the zero time when the schedule never matches.
+func (s *Schedule) Next(t time.Time) time.Time {
+	location := t.Location()
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxScheduleLookahead)
+
+	for next.Before(limit) {
+		if s.months&(1<<uint(next.Month())) == 0 {
+			wall := time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			following := startOfDay(wall, location)
+			if s.firedInGap(wall, following) {
+				return following
+			}
+			next = following
+			continue
+		}
+
+		if !s.matchesDay(next) {
+			wall := time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, time.UTC)
+			following := startOfDay(wall, location)
+			if s.firedInGap(wall, following) {
+				return following
+			}
+			next = following
+			continue
+		}
+
+		// hours and minutes advance by elapsed time, so a time the clock shows
+		// twice is visited twice and a skipped time is noticed
+		if s.hours&(1<<uint(next.Hour())) == 0 {
+			minutes := time.Duration(60-next.Minute()) * time.Minute
+			following := next.Add(minutes)
+			if s.firedInGap(wallClock(next).Add(minutes), following) {
+				return following
+			}
+			next = following
+			continue
+		}
+
+		if s.minutes&(1<<uint(next.Minute())) == 0 {
+			following := next.Add(time.Minute)
+			if s.firedInGap(wallClock(next).Add(time.Minute), following) {
+				return following
+			}
+			next = following
+			continue
+		}
+
+		if !s.wildcard && !firstOccurrence(next).Equal(next) {
+			next = next.Add(time.Minute)
+			continue
+		}
+
+		return next
+	}
+
+	return time.Time{}
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dayOfMonth := s.daysOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeek := s.daysOfWeek&(1<<uint(t.Weekday())) != 0
+
+	if s.anyDayOfMonth || s.anyDayOfWeek {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
+
+// firedInGap reports whether a schedule with a fixed minute and hour matches a
+// wall clock time the clock skipped between wall and reaching following.
+func (s *Schedule) firedInGap(wall time.Time, following time.Time) bool {
+	if s.wildcard {
+		return false
+	}
+
+	for end := wallClock(following); wall.Before(end); wall = wall.Add(time.Minute) {
+		if s.months&(1<<uint(wall.Month())) != 0 && s.matchesDay(wall) &&
+			s.hours&(1<<uint(wall.Hour())) != 0 && s.minutes&(1<<uint(wall.Minute())) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// wallClock returns the date and time t shows, as a UTC time.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+}
+
+// startOfDay returns the first moment of the day of wall in location.
+func startOfDay(wall time.Time, location *time.Location) time.Time {
+	return firstOccurrence(time.Date(wall.Year(), wall.Month(), wall.Day(), 0, 0, 0, 0, location))
+}
+
+// firstOccurrence returns the earlier of the two moments showing the same time
+// as t when the clock went back, and t otherwise.
+func firstOccurrence(t time.Time) time.Time {
+	_, offset := t.Zone()
+	_, earlierOffset := t.Add(-3 * time.Hour).Zone()
+	if earlierOffset <= offset {
+		return t
+	}
+
+	earlier := t.Add(-time.Duration(earlierOffset-offset) * time.Second)
+	if wallClock(earlier).Equal(wallClock(t)) {
+		return earlier
+	}
+	return t
+}
@@ -0,0 +1,379 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%q): %v", name, err)
+	}
+	return location
+}
+
+// nextRuns returns the next n runs of spec after from.
+func nextRuns(t *testing.T, spec string, from time.Time, n int) []time.Time {
+	t.Helper()
+
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		t.Fatalf("ParseSchedule(%q): %v", spec, err)
+	}
+
+	runs := make([]time.Time, 0, n)
+	for i := 0; i < n; i++ {
+		from = schedule.Next(from)
+		runs = append(runs, from)
+	}
+	return runs
+}
+
+func assertRuns(t *testing.T, spec string, got []time.Time, want []time.Time) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("%s: got %d runs, want %d", spec, len(got), len(want))
+	}
+
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("%s: run %d = %s, want %s", spec, i+1, got[i], want[i])
+		}
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr string
+	}{
+		{spec: "", wantErr: "expected 5 fields"},
+		{spec: "* * * *", wantErr: "expected 5 fields"},
+		{spec: "* * * * * *", wantErr: "expected 5 fields"},
+		{spec: "@reboot", wantErr: "expected 5 fields"},
+		{spec: "60 * * * *", wantErr: "invalid minute"},
+		{spec: "* 24 * * *", wantErr: "invalid hour"},
+		{spec: "* * 0 * *", wantErr: "invalid day of month"},
+		{spec: "* * 32 * *", wantErr: "invalid day of month"},
+		{spec: "* * * 0 *", wantErr: "invalid month"},
+		{spec: "* * * 13 *", wantErr: "invalid month"},
+		{spec: "* * * * 8", wantErr: "invalid day of week"},
+		{spec: "* * * JAN *", wantErr: "invalid month"},
+		{spec: "*/0 * * * *", wantErr: "invalid step"},
+		{spec: "*/x * * * *", wantErr: "invalid step"},
+		{spec: "30-10 * * * *", wantErr: "invalid range"},
+		{spec: "1-2-3 * * * *", wantErr: "invalid minute"},
+		{spec: "1,,2 * * * *", wantErr: "invalid minute"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseSchedule(tt.spec)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ParseSchedule(%q) error = %v, want it to contain %q", tt.spec, err, tt.wantErr)
+		}
+	}
+}
+
+func TestScheduleRangesAndSteps(t *testing.T) {
+	from := time.Date(2026, 10, 19, 10, 7, 30, 0, time.UTC) // a Monday
+
+	tests := []struct {
+		spec string
+		want []time.Time
+	}{
+		{
+			spec: "*/15 * * * *",
+			want: []time.Time{
+				time.Date(2026, 10, 19, 10, 15, 0, 0, time.UTC),
+				time.Date(2026, 10, 19, 10, 30, 0, 0, time.UTC),
+				time.Date(2026, 10, 19, 10, 45, 0, 0, time.UTC),
+				time.Date(2026, 10, 19, 11, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			spec: "5-20/5 * * * *",
+			want: []time.Time{
+				time.Date(2026, 10, 19, 10, 10, 0, 0, time.UTC),
+				time.Date(2026, 10, 19, 10, 15, 0, 0, time.UTC),
+				time.Date(2026, 10, 19, 10, 20, 0, 0, time.UTC),
+				time.Date(2026, 10, 19, 11, 5, 0, 0, time.UTC),
+			},
+		},
+		{
+			// a single value with a step runs up to the end of the field
+			spec: "10/20 * * * *",
+			want: []time.Time{
+				time.Date(2026, 10, 19, 10, 10, 0, 0, time.UTC),
+				time.Date(2026, 10, 19, 10, 30, 0, 0, time.UTC),
+				time.Date(2026, 10, 19, 10, 50, 0, 0, time.UTC),
+				time.Date(2026, 10, 19, 11, 10, 0, 0, time.UTC),
+			},
+		},
+		{
+			spec: "0,30 9-17/4 * * *",
+			want: []time.Time{
+				time.Date(2026, 10, 19, 13, 0, 0, 0, time.UTC),
+				time.Date(2026, 10, 19, 13, 30, 0, 0, time.UTC),
+				time.Date(2026, 10, 19, 17, 0, 0, 0, time.UTC),
+				time.Date(2026, 10, 19, 17, 30, 0, 0, time.UTC),
+				time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			spec: "0 8 * * 1-5",
+			want: []time.Time{
+				time.Date(2026, 10, 20, 8, 0, 0, 0, time.UTC),
+				time.Date(2026, 10, 21, 8, 0, 0, 0, time.UTC),
+				time.Date(2026, 10, 22, 8, 0, 0, 0, time.UTC),
+				time.Date(2026, 10, 23, 8, 0, 0, 0, time.UTC),
+				time.Date(2026, 10, 26, 8, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			spec: "@hourly",
+			want: []time.Time{
+				time.Date(2026, 10, 19, 11, 0, 0, 0, time.UTC),
+				time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			spec: "@WEEKLY",
+			want: []time.Time{
+				time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		assertRuns(t, tt.spec, nextRuns(t, tt.spec, from, len(tt.want)), tt.want)
+	}
+}
+
+func TestScheduleNextIsAfter(t *testing.T) {
+	// a run at exactly the scheduled minute is not returned again
+	at := time.Date(2026, 10, 19, 10, 15, 0, 0, time.UTC)
+	runs := nextRuns(t, "*/15 * * * *", at, 1)
+	assertRuns(t, "*/15 * * * *", runs, []time.Time{time.Date(2026, 10, 19, 10, 30, 0, 0, time.UTC)})
+}
+
+func TestScheduleDayOfMonthAndDayOfWeek(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC) // a Thursday
+
+	tests := []struct {
+		spec string
+		want []time.Time
+	}{
+		{
+			// both restricted: the 13th or any Friday
+			spec: "0 0 13 * 5",
+			want: []time.Time{
+				time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 10, 9, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			spec: "0 0 13 * *",
+			want: []time.Time{
+				time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 11, 13, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			spec: "0 0 * * 5",
+			want: []time.Time{
+				time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 10, 9, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			// 0 and 7 are both Sunday
+			spec: "0 0 * * 7",
+			want: []time.Time{
+				time.Date(2026, 10, 4, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 10, 11, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			spec: "0 0 * * 0",
+			want: []time.Time{
+				time.Date(2026, 10, 4, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 10, 11, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		assertRuns(t, tt.spec, nextRuns(t, tt.spec, from, len(tt.want)), tt.want)
+	}
+}
+
+func TestScheduleRollover(t *testing.T) {
+	tests := []struct {
+		spec string
+		from time.Time
+		want []time.Time
+	}{
+		{
+			// months without a 31st are skipped
+			spec: "0 0 31 * *",
+			from: time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			spec: "59 23 * * *",
+			from: time.Date(2026, 12, 31, 23, 59, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2027, 1, 1, 23, 59, 0, 0, time.UTC),
+			},
+		},
+		{
+			spec: "@yearly",
+			from: time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2028, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			spec: "0 12 29 2 *",
+			from: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC),
+				time.Date(2032, 2, 29, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			spec: "0 0 1 */3 *",
+			from: time.Date(2026, 11, 15, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2027, 4, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		assertRuns(t, tt.spec, nextRuns(t, tt.spec, tt.from, len(tt.want)), tt.want)
+	}
+}
+
+func TestScheduleInLocation(t *testing.T) {
+	jakarta := mustLoadLocation(t, "Asia/Jakarta")
+	from := time.Date(2026, 10, 18, 18, 0, 0, 0, time.UTC) // 01:00 WIB on the 19th
+
+	// the schedule follows the location of the time it is asked about
+	assertRuns(t, "30 3 * * *", nextRuns(t, "30 3 * * *", from, 1), []time.Time{
+		time.Date(2026, 10, 19, 3, 30, 0, 0, time.UTC),
+	})
+
+	got := nextRuns(t, "30 3 * * *", from.In(jakarta), 1)
+	assertRuns(t, "30 3 * * *", got, []time.Time{
+		time.Date(2026, 10, 19, 3, 30, 0, 0, jakarta),
+	})
+
+	if got[0].Location() != jakarta {
+		t.Errorf("Next returned a time in %s, want Asia/Jakarta", got[0].Location())
+	}
+}
+
+func TestScheduleDaylightSavingTime(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+
+	// New York skips 02:00-02:59 on 8 March 2026 and shows 01:00-01:59 twice on
+	// 1 November 2026. Berlin shows 02:00-02:59 twice on 25 October 2026.
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want []time.Time
+	}{
+		{
+			name: "fixed time in the gap runs right after it",
+			spec: "30 2 * * *",
+			from: time.Date(2026, 3, 7, 12, 0, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2026, 3, 8, 3, 0, 0, 0, newYork),
+				time.Date(2026, 3, 9, 2, 30, 0, 0, newYork),
+			},
+		},
+		{
+			name: "fixed time after the gap runs once",
+			spec: "0 3 * * *",
+			from: time.Date(2026, 3, 7, 12, 0, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2026, 3, 8, 3, 0, 0, 0, newYork),
+				time.Date(2026, 3, 9, 3, 0, 0, 0, newYork),
+			},
+		},
+		{
+			name: "wildcard hour skips the times in the gap",
+			spec: "30 * * * *",
+			from: time.Date(2026, 3, 8, 1, 0, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2026, 3, 8, 1, 30, 0, 0, newYork),
+				time.Date(2026, 3, 8, 3, 30, 0, 0, newYork),
+			},
+		},
+		{
+			name: "fixed time shown twice runs once, west of UTC",
+			spec: "30 1 * * *",
+			from: time.Date(2026, 10, 31, 12, 0, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC), // 01:30 EDT
+				time.Date(2026, 11, 2, 6, 30, 0, 0, time.UTC), // 01:30 EST
+			},
+		},
+		{
+			name: "fixed time shown twice runs once, east of UTC",
+			spec: "30 2 * * *",
+			from: time.Date(2026, 10, 24, 12, 0, 0, 0, berlin),
+			want: []time.Time{
+				time.Date(2026, 10, 25, 0, 30, 0, 0, time.UTC), // 02:30 CEST
+				time.Date(2026, 10, 26, 1, 30, 0, 0, time.UTC), // 02:30 CET
+			},
+		},
+		{
+			name: "wildcard hour runs in both repeated hours",
+			spec: "0 * * * *",
+			from: time.Date(2026, 11, 1, 4, 30, 0, 0, time.UTC).In(newYork), // 00:30 EDT
+			want: []time.Time{
+				time.Date(2026, 11, 1, 5, 0, 0, 0, time.UTC), // 01:00 EDT
+				time.Date(2026, 11, 1, 6, 0, 0, 0, time.UTC), // 01:00 EST
+				time.Date(2026, 11, 1, 7, 0, 0, 0, time.UTC), // 02:00 EST
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertRuns(t, tt.spec, nextRuns(t, tt.spec, tt.from, len(tt.want)), tt.want)
+		})
+	}
+}
+
+func TestScheduleNeverFires(t *testing.T) {
+	from := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+	for _, spec := range []string{"0 0 30 2 *", "0 0 31 4 *", "0 0 31 2,4,6,9,11 *"} {
+		schedule, err := ParseSchedule(spec)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q): %v", spec, err)
+		}
+
+		if next := schedule.Next(from); !next.IsZero() {
+			t.Errorf("%s: Next = %s, want the zero time", spec, next)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package scheduler
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const (
+	// defaultGiftExpiryGracePeriod gives admins time to extend a gift before its
+	// codes are voided, in hours.
+	defaultGiftExpiryGracePeriod = 24
+	// defaultPendingUserMaxAge is how long a registration may stay unverified, in
+	// hours.
+	defaultPendingUserMaxAge = 168
+)
+
+// TokenCleanupJob deletes expired verification, reset and unlock tokens,
+// refresh tokens, denylisted access tokens, OAuth2 authorization codes and
+// idempotency keys.
+type TokenCleanupJob struct {
+	Log                   *logrus.Logger
+	UserRepository        repository.IUserRepository
+	TokenRepository       repository.ITokenRepository
+	OAuthRepository       repository.IOAuthRepository
+	IdempotencyRepository repository.IIdempotencyRepository
+}
+
+func NewTokenCleanupJob(
+	log *logrus.Logger,
+	userRepository repository.IUserRepository,
+	tokenRepository repository.ITokenRepository,
+	oauthRepository repository.IOAuthRepository,
+	idempotencyRepository repository.IIdempotencyRepository,
+) Job {
+	return &TokenCleanupJob{
+		Log:                   log,
+		UserRepository:        userRepository,
+		TokenRepository:       tokenRepository,
+		OAuthRepository:       oauthRepository,
+		IdempotencyRepository: idempotencyRepository,
+	}
+}
+
+func TokenCleanupJobFactory(log *logrus.Logger) Job {
+	userRepository := repository.UserRepositoryFactory(log)
+	tokenRepository := repository.TokenRepositoryFactory(log)
+	oauthRepository := repository.OAuthRepositoryFactory(log)
+	idempotencyRepository := repository.IdempotencyRepositoryFactory(log)
+	return NewTokenCleanupJob(log, userRepository, tokenRepository, oauthRepository, idempotencyRepository)
+}
+
+func (j *TokenCleanupJob) Name() string {
+	return "token_cleanup"
+}
+
+func (j *TokenCleanupJob) DefaultSchedule() string {
+	return "0 * * * *"
+}
+
+func (j *TokenCleanupJob) Run(ctx context.Context) (string, error) {
+	now := time.Now()
+
+	userTokens, err := j.UserRepository.DeleteExpiredUserTokens(now)
+	if err != nil {
+		return "", err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	tokens, err := j.TokenRepository.DeleteExpired(now)
+	if err != nil {
+		return "", err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	authorizationCodes, err := j.OAuthRepository.DeleteExpiredAuthorizationCodes(now)
+	if err != nil {
+		return "", err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	idempotencyKeys, err := j.IdempotencyRepository.DeleteExpired(now)
+	if err != nil {
+		return "", err
+	}
+
+	return "deleted " + strconv.FormatInt(userTokens, 10) + " user tokens, " +
+		strconv.FormatInt(tokens, 10) + " refresh and revoked tokens, " +
+		strconv.FormatInt(authorizationCodes, 10) + " authorization codes and " +
+		strconv.FormatInt(idempotencyKeys, 10) + " idempotency keys", nil
+}
+
+// GiftExpiryJob voids the available codes of gifts that expired more than
+// scheduler.jobs.gift_expiry.grace_period hours ago. Expired gifts are already
+// hidden from the catalog, this takes their codes out of the stock for good.
+type GiftExpiryJob struct {
+	Log                *logrus.Logger
+	Viper              *viper.Viper
+	GiftCodeRepository repository.IGiftCodeRepository
+}
+
+func NewGiftExpiryJob(log *logrus.Logger, viper *viper.Viper, giftCodeRepository repository.IGiftCodeRepository) Job {
+	return &GiftExpiryJob{
+		Log:                log,
+		Viper:              viper,
+		GiftCodeRepository: giftCodeRepository,
+	}
+}
+
+func GiftExpiryJobFactory(log *logrus.Logger, viper *viper.Viper) Job {
+	giftCodeRepository := repository.GiftCodeRepositoryFactory(log)
+	return NewGiftExpiryJob(log, viper, giftCodeRepository)
+}
+
+func (j *GiftExpiryJob) Name() string {
+	return "gift_expiry"
+}
+
+func (j *GiftExpiryJob) DefaultSchedule() string {
+	return "*/15 * * * *"
+}
+
+func (j *GiftExpiryJob) Run(ctx context.Context) (string, error) {
+	gracePeriod := defaultGiftExpiryGracePeriod
+	if j.Viper.IsSet("scheduler.jobs.gift_expiry.grace_period") {
+		gracePeriod = j.Viper.GetInt("scheduler.jobs.gift_expiry.grace_period")
+	}
+
+	voided, err := j.GiftCodeRepository.VoidCodesOfExpiredGifts(time.Now().Add(-time.Duration(gracePeriod) * time.Hour))
+	if err != nil {
+		return "", err
+	}
+
+	return "voided " + strconv.FormatInt(voided, 10) + " codes of expired gifts", nil
+}
+
+// PendingUserPurgeJob deletes registrations that were not verified within
+// scheduler.jobs.pending_user_purge.max_age hours, which frees their email and
+// username again.
+type PendingUserPurgeJob struct {
+	Log            *logrus.Logger
+	Viper          *viper.Viper
+	UserRepository repository.IUserRepository
+}
+
+func NewPendingUserPurgeJob(log *logrus.Logger, viper *viper.Viper, userRepository repository.IUserRepository) Job {
+	return &PendingUserPurgeJob{
+		Log:            log,
+		Viper:          viper,
+		UserRepository: userRepository,
+	}
+}
+
+func PendingUserPurgeJobFactory(log *logrus.Logger, viper *viper.Viper) Job {
+	userRepository := repository.UserRepositoryFactory(log)
+	return NewPendingUserPurgeJob(log, viper, userRepository)
+}
+
+func (j *PendingUserPurgeJob) Name() string {
+	return "pending_user_purge"
+}
+
+func (j *PendingUserPurgeJob) DefaultSchedule() string {
+	return "30 3 * * *"
+}
+
+func (j *PendingUserPurgeJob) Run(ctx context.Context) (string, error) {
+	maxAge := j.Viper.GetInt("scheduler.jobs.pending_user_purge.max_age")
+	if maxAge <= 0 {
+		maxAge = defaultPendingUserMaxAge
+	}
+
+	purged, err := j.UserRepository.PurgePendingUsers(time.Now().Add(-time.Duration(maxAge) * time.Hour))
+	if err != nil {
+		return "", err
+	}
+
+	return "purged " + strconv.FormatInt(purged, 10) + " pending users", nil
+}
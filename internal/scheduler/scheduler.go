@@ -0,0 +1,233 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/entity"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/repository"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// defaultLeaseDuration bounds a run when scheduler.lease_duration is not set.
+const defaultLeaseDuration = 10 * time.Minute
+
+// Job is a unit of background work. Run returns a short summary of what it did
+// for the run history, and should stop when ctx is done.
+type Job interface {
+	Name() string
+	DefaultSchedule() string
+	Run(ctx context.Context) (string, error)
+}
+
+type scheduledJob struct {
+	job      Job
+	spec     string
+	schedule *Schedule
+}
+
+// Scheduler runs the registered jobs on their schedules, evaluated in
+// app.timezone. Every instance of the app runs a scheduler, and a lease in the
+// database makes sure each tick of a job runs on one instance only.
+type Scheduler struct {
+	Log        *logrus.Logger
+	Viper      *viper.Viper
+	Repository repository.ISchedulerRepository
+	Location   *time.Location
+	Owner      string
+	jobs       []*scheduledJob
+}
+
+func NewScheduler(
+	log *logrus.Logger,
+	viper *viper.Viper,
+	repository repository.ISchedulerRepository,
+	location *time.Location,
+) *Scheduler {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return &Scheduler{
+		Log:        log,
+		Viper:      viper,
+		Repository: repository,
+		Location:   location,
+		Owner:      hostname + "-" + uuid.New().String()[:8],
+	}
+}
+
+func SchedulerFactory(log *logrus.Logger, viper *viper.Viper) (*Scheduler, error) {
+	location, err := utils.LoadTimezone(viper)
+	if err != nil {
+		return nil, err
+	}
+	schedulerRepository := repository.SchedulerRepositoryFactory(log)
+	return NewScheduler(log, viper, schedulerRepository, location), nil
+}
+
+// InitScheduler registers the jobs of the app and runs them until the process
+// exits. It does nothing when scheduler.enabled is false.
+func InitScheduler(viper *viper.Viper, log *logrus.Logger) {
+	if viper.IsSet("scheduler.enabled") && !viper.GetBool("scheduler.enabled") {
+		log.Info("[Scheduler] disabled by scheduler.enabled")
+		return
+	}
+
+	scheduler, err := SchedulerFactory(log, viper)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, job := range []Job{
+		TokenCleanupJobFactory(log),
+		GiftExpiryJobFactory(log, viper),
+		PendingUserPurgeJobFactory(log, viper),
+	} {
+		if err := scheduler.Register(job); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	scheduler.Start(context.Background())
+}
+
+// Register adds a job, scheduled by scheduler.jobs.<name>.schedule or its
+// default schedule. A job is skipped when scheduler.jobs.<name>.enabled is
+// false.
+func (s *Scheduler) Register(job Job) error {
+	for _, registered := range s.jobs {
+		if registered.job.Name() == job.Name() {
+			return errors.New("[Scheduler] job " + job.Name() + " is already registered")
+		}
+	}
+
+	key := "scheduler.jobs." + job.Name()
+	if s.Viper.IsSet(key+".enabled") && !s.Viper.GetBool(key+".enabled") {
+		s.Log.Info("[Scheduler] job " + job.Name() + " is disabled")
+		return nil
+	}
+
+	spec := s.Viper.GetString(key + ".schedule")
+	if spec == "" {
+		spec = job.DefaultSchedule()
+	}
+
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("[Scheduler] job %s: %w", job.Name(), err)
+	}
+
+	s.jobs = append(s.jobs, &scheduledJob{
+		job:      job,
+		spec:     spec,
+		schedule: schedule,
+	})
+	return nil
+}
+
+// Start runs the registered jobs until ctx is done.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, scheduled := range s.jobs {
+		s.Log.Info("[Scheduler] scheduled " + scheduled.job.Name() + " at " + scheduled.spec)
+		go s.loop(ctx, scheduled)
+	}
+
+	<-ctx.Done()
+}
+
+func (s *Scheduler) loop(ctx context.Context, scheduled *scheduledJob) {
+	for {
+		next := scheduled.schedule.Next(time.Now().In(s.Location))
+		if next.IsZero() {
+			s.Log.Error("[Scheduler] job " + scheduled.job.Name() + " never runs at " + scheduled.spec)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.run(ctx, scheduled.job, next)
+	}
+}
+
+// run executes the tick of the job at scheduledAt unless another instance has
+// taken or already run it, and records the outcome in the run history.
+func (s *Scheduler) run(ctx context.Context, job Job, scheduledAt time.Time) {
+	leaseDuration := time.Duration(s.Viper.GetInt("scheduler.lease_duration")) * time.Minute
+	if leaseDuration <= 0 {
+		leaseDuration = defaultLeaseDuration
+	}
+
+	acquired, err := s.Repository.AcquireLease(job.Name(), s.Owner, scheduledAt.UTC(), time.Now().Add(leaseDuration))
+	if err != nil {
+		s.Log.Error("[Scheduler] " + err.Error())
+		return
+	}
+
+	if !acquired {
+		s.Log.Debug("[Scheduler] job " + job.Name() + " runs on another instance")
+		return
+	}
+
+	defer func() {
+		if err := s.Repository.ReleaseLease(job.Name(), s.Owner); err != nil {
+			s.Log.Error("[Scheduler] " + err.Error())
+		}
+	}()
+
+	run := &entity.SchedulerRun{
+		JobName:     job.Name(),
+		Owner:       s.Owner,
+		ScheduledAt: scheduledAt.UTC(),
+		StartedAt:   time.Now(),
+		Status:      entity.SCHEDULER_RUN_RUNNING,
+	}
+	if err := s.Repository.CreateRun(run); err != nil {
+		s.Log.Error("[Scheduler] " + err.Error())
+		return
+	}
+
+	// the run has to finish before the lease runs out and another instance may
+	// start the next tick
+	runCtx, cancel := context.WithTimeout(ctx, leaseDuration)
+	defer cancel()
+
+	status := entity.SCHEDULER_RUN_SUCCEEDED
+	message, err := execute(runCtx, job)
+	if err != nil {
+		status = entity.SCHEDULER_RUN_FAILED
+		message = err.Error()
+		s.Log.Error("[Scheduler] job " + job.Name() + " failed: " + message)
+	} else {
+		s.Log.Info("[Scheduler] job " + job.Name() + ": " + message)
+	}
+
+	if err := s.Repository.FinishRun(run.ID, status, message); err != nil {
+		s.Log.Error("[Scheduler] " + err.Error())
+	}
+}
+
+// execute runs the job, turning a panic into an error so one broken job does
+// not take down the app.
+func execute(ctx context.Context, job Job) (message string, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	return job.Run(ctx)
+}
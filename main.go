@@ -9,6 +9,8 @@ import (
 	"github.com/IlhamSetiaji/gift-redeem-be/internal/config"
 	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/rabbitmq"
 	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/route"
+	"github.com/IlhamSetiaji/gift-redeem-be/internal/scheduler"
+	"github.com/IlhamSetiaji/gift-redeem-be/utils"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-contrib/sessions/cookie"
@@ -20,10 +22,21 @@ func main() {
 	viper := config.NewViper()
 	log := config.NewLogrus(viper)
 
+	if _, err := utils.LoadTimezone(viper); err != nil {
+		log.Fatal(err)
+	}
+
 	go rabbitmq.InitConsumer(viper, log)
 	go rabbitmq.InitProducer(viper, log)
+	go scheduler.InitScheduler(viper, log)
 
 	app := gin.Default()
+
+	// ClientIP only reads X-Forwarded-For when the request comes through one of
+	// these proxies, otherwise clients could pick the IP login throttling sees
+	if err := app.SetTrustedProxies(viper.GetStringSlice("web.trusted_proxies")); err != nil {
+		log.Fatal(err)
+	}
 	app.Static("/storage", "./storage")
 	app.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("App-Name", viper.GetString("app.name"))
@@ -36,8 +49,8 @@ func main() {
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     strings.Split(viper.GetString("frontend.urls"), ","), // Frontend URL
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "Idempotency-Key"},
+		ExposeHeaders:    []string{"Content-Length", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -0,0 +1,240 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// EncryptedCode is a gift code sealed with envelope encryption: the code is
+// encrypted with a random data key, and the data key is wrapped with the key
+// encryption key named by KeyID. Rotating keys only rewraps the data key.
+type EncryptedCode struct {
+	KeyID      string
+	WrappedKey string
+	Ciphertext string
+}
+
+// CodeCipher holds the key encryption keys of gift codes. Only the active key
+// encrypts, every configured key decrypts, so retired keys keep working until
+// all codes are rewrapped.
+type CodeCipher struct {
+	ActiveKID string
+	Keys      map[string]cipher.AEAD
+	HashKey   []byte
+}
+
+type codeKeyConfig struct {
+	KID string `mapstructure:"kid"`
+	Key string `mapstructure:"key"`
+}
+
+var (
+	codeCipherOnce sync.Once
+	codeCipher     *CodeCipher
+	codeCipherErr  error
+)
+
+// LoadCodeCipher parses the keys configured under gift_codes.encryption once and
+// returns the same cipher for the rest of the process.
+func LoadCodeCipher(viper *viper.Viper) (*CodeCipher, error) {
+	codeCipherOnce.Do(func() {
+		codeCipher, codeCipherErr = NewCodeCipher(viper)
+	})
+	return codeCipher, codeCipherErr
+}
+
+func NewCodeCipher(viper *viper.Viper) (*CodeCipher, error) {
+	var configs []codeKeyConfig
+	if err := viper.UnmarshalKey("gift_codes.encryption.keys", &configs); err != nil {
+		return nil, errors.New("[CodeCipher] invalid gift_codes.encryption.keys: " + err.Error())
+	}
+
+	c := &CodeCipher{
+		ActiveKID: viper.GetString("gift_codes.encryption.active_kid"),
+		Keys:      make(map[string]cipher.AEAD),
+	}
+
+	for _, cfg := range configs {
+		if cfg.KID == "" {
+			return nil, errors.New("[CodeCipher] key without kid")
+		}
+
+		if _, exists := c.Keys[cfg.KID]; exists {
+			return nil, fmt.Errorf("[CodeCipher] duplicate kid %q", cfg.KID)
+		}
+
+		aead, err := newAEAD(cfg.Key)
+		if err != nil {
+			return nil, fmt.Errorf("[CodeCipher] key %q: %w", cfg.KID, err)
+		}
+		c.Keys[cfg.KID] = aead
+	}
+
+	if _, ok := c.Keys[c.ActiveKID]; !ok {
+		return nil, fmt.Errorf("[CodeCipher] active kid %q is not configured", c.ActiveKID)
+	}
+
+	hashKey, err := base64.StdEncoding.DecodeString(viper.GetString("gift_codes.encryption.hash_key"))
+	if err != nil || len(hashKey) < 32 {
+		return nil, errors.New("[CodeCipher] gift_codes.encryption.hash_key must be at least 32 base64 encoded bytes")
+	}
+	c.HashKey = hashKey
+
+	return c, nil
+}
+
+// Encrypt seals the code with a fresh data key wrapped by the active key.
+func (c *CodeCipher) Encrypt(code string) (*EncryptedCode, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+
+	dataAEAD, err := aeadFromKey(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := seal(dataAEAD, []byte(code), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	wrappedKey, err := seal(c.Keys[c.ActiveKID], dataKey, []byte(c.ActiveKID))
+	if err != nil {
+		return nil, err
+	}
+
+	return &EncryptedCode{
+		KeyID:      c.ActiveKID,
+		WrappedKey: wrappedKey,
+		Ciphertext: ciphertext,
+	}, nil
+}
+
+func (c *CodeCipher) Decrypt(encrypted *EncryptedCode) (string, error) {
+	dataKey, err := c.unwrap(encrypted)
+	if err != nil {
+		return "", err
+	}
+
+	dataAEAD, err := aeadFromKey(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	code, err := open(dataAEAD, encrypted.Ciphertext, nil)
+	if err != nil {
+		return "", errors.New("[CodeCipher] failed to decrypt code: " + err.Error())
+	}
+
+	return string(code), nil
+}
+
+// Rewrap wraps the data key of the code with the active key. The ciphertext of
+// the code itself stays the same.
+func (c *CodeCipher) Rewrap(encrypted *EncryptedCode) (*EncryptedCode, error) {
+	dataKey, err := c.unwrap(encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	wrappedKey, err := seal(c.Keys[c.ActiveKID], dataKey, []byte(c.ActiveKID))
+	if err != nil {
+		return nil, err
+	}
+
+	return &EncryptedCode{
+		KeyID:      c.ActiveKID,
+		WrappedKey: wrappedKey,
+		Ciphertext: encrypted.Ciphertext,
+	}, nil
+}
+
+// Hash returns a keyed hash of the code, so codes can be looked up and checked
+// for uniqueness without decrypting them. The hash key is never rotated.
+func (c *CodeCipher) Hash(code string) string {
+	mac := hmac.New(sha256.New, c.HashKey)
+	mac.Write([]byte(code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (c *CodeCipher) unwrap(encrypted *EncryptedCode) ([]byte, error) {
+	keyAEAD, ok := c.Keys[encrypted.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("[CodeCipher] unknown kid: %s", encrypted.KeyID)
+	}
+
+	dataKey, err := open(keyAEAD, encrypted.WrappedKey, []byte(encrypted.KeyID))
+	if err != nil {
+		return nil, errors.New("[CodeCipher] failed to unwrap data key: " + err.Error())
+	}
+
+	return dataKey, nil
+}
+
+func newAEAD(encodedKey string) (cipher.AEAD, error) {
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, errors.New("key is not valid base64")
+	}
+
+	if len(key) != 32 {
+		return nil, errors.New("key must be 32 bytes")
+	}
+
+	return aeadFromKey(key)
+}
+
+func aeadFromKey(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext and returns the nonce followed by the ciphertext as
+// base64.
+func seal(aead cipher.AEAD, plaintext []byte, additionalData []byte) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, additionalData)), nil
+}
+
+func open(aead cipher.AEAD, sealed string, additionalData []byte) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], additionalData)
+}
+
+// CodeHint returns the last characters of a code, at most a quarter of it and
+// never more than four, so admins can tell codes apart without seeing them.
+func CodeHint(code string) string {
+	runes := []rune(code)
+	length := len(runes) / 4
+	if length > 4 {
+		length = 4
+	}
+	return string(runes[len(runes)-length:])
+}
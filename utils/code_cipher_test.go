@@ -0,0 +1,306 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+func newTestCodeCipher(t *testing.T, activeKID string, keys ...map[string]string) *CodeCipher {
+	t.Helper()
+
+	v := viper.New()
+	v.Set("gift_codes.encryption.active_kid", activeKID)
+	v.Set("gift_codes.encryption.hash_key", testKey(0xAA))
+	v.Set("gift_codes.encryption.keys", keys)
+
+	c, err := NewCodeCipher(v)
+	if err != nil {
+		t.Fatalf("NewCodeCipher: %v", err)
+	}
+	return c
+}
+
+func TestNewCodeCipher(t *testing.T) {
+	tests := []struct {
+		name      string
+		activeKID string
+		keys      []map[string]string
+		hashKey   string
+		wantErr   string
+	}{
+		{
+			name:      "valid",
+			activeKID: "k1",
+			keys:      []map[string]string{{"kid": "k1", "key": testKey(1)}},
+			hashKey:   testKey(0xAA),
+		},
+		{
+			name:      "missing kid",
+			activeKID: "k1",
+			keys:      []map[string]string{{"key": testKey(1)}},
+			hashKey:   testKey(0xAA),
+			wantErr:   "key without kid",
+		},
+		{
+			name:      "duplicate kid",
+			activeKID: "k1",
+			keys:      []map[string]string{{"kid": "k1", "key": testKey(1)}, {"kid": "k1", "key": testKey(2)}},
+			hashKey:   testKey(0xAA),
+			wantErr:   "duplicate kid",
+		},
+		{
+			name:      "key not base64",
+			activeKID: "k1",
+			keys:      []map[string]string{{"kid": "k1", "key": "not base64!"}},
+			hashKey:   testKey(0xAA),
+			wantErr:   "not valid base64",
+		},
+		{
+			name:      "short key",
+			activeKID: "k1",
+			keys:      []map[string]string{{"kid": "k1", "key": base64.StdEncoding.EncodeToString([]byte("short"))}},
+			hashKey:   testKey(0xAA),
+			wantErr:   "must be 32 bytes",
+		},
+		{
+			name:      "active kid not configured",
+			activeKID: "k2",
+			keys:      []map[string]string{{"kid": "k1", "key": testKey(1)}},
+			hashKey:   testKey(0xAA),
+			wantErr:   "is not configured",
+		},
+		{
+			name:      "short hash key",
+			activeKID: "k1",
+			keys:      []map[string]string{{"kid": "k1", "key": testKey(1)}},
+			hashKey:   base64.StdEncoding.EncodeToString([]byte("short")),
+			wantErr:   "hash_key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := viper.New()
+			v.Set("gift_codes.encryption.active_kid", tt.activeKID)
+			v.Set("gift_codes.encryption.hash_key", tt.hashKey)
+			v.Set("gift_codes.encryption.keys", tt.keys)
+
+			_, err := NewCodeCipher(v)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCodeCipherRoundTrip(t *testing.T) {
+	c := newTestCodeCipher(t, "k1", map[string]string{"kid": "k1", "key": testKey(1)})
+
+	for _, code := range []string{"A", "GODOFWAR-0001", "kode-hadiah-ünïcode", strings.Repeat("x", 1024)} {
+		encrypted, err := c.Encrypt(code)
+		if err != nil {
+			t.Fatalf("Encrypt(%q): %v", code, err)
+		}
+
+		if encrypted.KeyID != "k1" {
+			t.Errorf("KeyID = %q, want k1", encrypted.KeyID)
+		}
+
+		// short codes can turn up in base64 by chance
+		if len(code) > 4 && strings.Contains(encrypted.Ciphertext, code) {
+			t.Errorf("ciphertext of %q contains the code", code)
+		}
+
+		decrypted, err := c.Decrypt(encrypted)
+		if err != nil {
+			t.Fatalf("Decrypt(%q): %v", code, err)
+		}
+
+		if decrypted != code {
+			t.Errorf("Decrypt = %q, want %q", decrypted, code)
+		}
+	}
+}
+
+func TestCodeCipherEncryptUsesFreshDataKey(t *testing.T) {
+	c := newTestCodeCipher(t, "k1", map[string]string{"kid": "k1", "key": testKey(1)})
+
+	first, err := c.Encrypt("SAME")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := c.Encrypt("SAME")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.Ciphertext == second.Ciphertext || first.WrappedKey == second.WrappedKey {
+		t.Error("encrypting the same code twice gave the same ciphertext or wrapped key")
+	}
+}
+
+func TestCodeCipherRewrap(t *testing.T) {
+	oldKey := map[string]string{"kid": "k1", "key": testKey(1)}
+	newKey := map[string]string{"kid": "k2", "key": testKey(2)}
+
+	before := newTestCodeCipher(t, "k1", oldKey)
+	encrypted, err := before.Encrypt("WITCHER3-0007")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after := newTestCodeCipher(t, "k2", oldKey, newKey)
+	rewrapped, err := after.Rewrap(encrypted)
+	if err != nil {
+		t.Fatalf("Rewrap: %v", err)
+	}
+
+	if rewrapped.KeyID != "k2" {
+		t.Errorf("KeyID = %q, want k2", rewrapped.KeyID)
+	}
+
+	if rewrapped.Ciphertext != encrypted.Ciphertext {
+		t.Error("Rewrap changed the ciphertext of the code")
+	}
+
+	if rewrapped.WrappedKey == encrypted.WrappedKey {
+		t.Error("Rewrap kept the old wrapped key")
+	}
+
+	// once every code is rewrapped, the old key can be retired
+	retired := newTestCodeCipher(t, "k2", newKey)
+	code, err := retired.Decrypt(rewrapped)
+	if err != nil {
+		t.Fatalf("Decrypt after retiring k1: %v", err)
+	}
+
+	if code != "WITCHER3-0007" {
+		t.Errorf("Decrypt = %q, want WITCHER3-0007", code)
+	}
+}
+
+func TestCodeCipherDecryptFailures(t *testing.T) {
+	c := newTestCodeCipher(t, "k1",
+		map[string]string{"kid": "k1", "key": testKey(1)},
+		map[string]string{"kid": "k2", "key": testKey(2)},
+	)
+
+	encrypted, err := c.Encrypt("REDEMPTION2-0001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flip := func(sealed string) string {
+		data, _ := base64.StdEncoding.DecodeString(sealed)
+		data[len(data)-1] ^= 0x01
+		return base64.StdEncoding.EncodeToString(data)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(e EncryptedCode) EncryptedCode
+		wantErr string
+	}{
+		{
+			name:    "tampered ciphertext",
+			modify:  func(e EncryptedCode) EncryptedCode { e.Ciphertext = flip(e.Ciphertext); return e },
+			wantErr: "failed to decrypt code",
+		},
+		{
+			name:    "tampered wrapped key",
+			modify:  func(e EncryptedCode) EncryptedCode { e.WrappedKey = flip(e.WrappedKey); return e },
+			wantErr: "failed to unwrap data key",
+		},
+		{
+			name:    "wrapped key moved to another kid",
+			modify:  func(e EncryptedCode) EncryptedCode { e.KeyID = "k2"; return e },
+			wantErr: "failed to unwrap data key",
+		},
+		{
+			name:    "unknown kid",
+			modify:  func(e EncryptedCode) EncryptedCode { e.KeyID = "k9"; return e },
+			wantErr: "unknown kid",
+		},
+		{
+			name:    "truncated ciphertext",
+			modify:  func(e EncryptedCode) EncryptedCode { e.Ciphertext = "AAAA"; return e },
+			wantErr: "ciphertext too short",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modified := tt.modify(*encrypted)
+
+			if _, err := c.Decrypt(&modified); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Decrypt error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCodeCipherRewrapUnknownKID(t *testing.T) {
+	before := newTestCodeCipher(t, "k1", map[string]string{"kid": "k1", "key": testKey(1)})
+	encrypted, err := before.Encrypt("GODOFWAR-0002")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// k1 was removed before the code was rewrapped
+	after := newTestCodeCipher(t, "k2", map[string]string{"kid": "k2", "key": testKey(2)})
+	if _, err := after.Rewrap(encrypted); err == nil || !strings.Contains(err.Error(), "unknown kid") {
+		t.Fatalf("Rewrap error = %v, want unknown kid", err)
+	}
+}
+
+func TestCodeCipherHash(t *testing.T) {
+	c := newTestCodeCipher(t, "k1", map[string]string{"kid": "k1", "key": testKey(1)})
+
+	if c.Hash("CODE") != c.Hash("CODE") {
+		t.Error("Hash is not deterministic")
+	}
+
+	if c.Hash("CODE") == c.Hash("code") {
+		t.Error("Hash of different codes collides")
+	}
+
+	if len(c.Hash("CODE")) != 64 {
+		t.Errorf("len(Hash) = %d, want 64", len(c.Hash("CODE")))
+	}
+}
+
+func TestCodeHint(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{code: "", want: ""},
+		{code: "ABC", want: ""},
+		{code: "ABCD", want: "D"},
+		{code: "ABCDEFGH", want: "GH"},
+		{code: "GODOFWAR-0001", want: "001"},
+		{code: "ABCDEFGHIJKLMNOPQRST", want: "QRST"},
+		{code: "ÄÖÜßÄÖÜß", want: "Üß"},
+	}
+
+	for _, tt := range tests {
+		if got := CodeHint(tt.code); got != tt.want {
+			t.Errorf("CodeHint(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/viper"
+)
+
+// SigningKey is a single entry of jwt.keys. Keys without a private key are
+// only used for verification, which keeps tokens signed by a retired key
+// valid until they expire.
+type SigningKey struct {
+	KID        string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+// JWK is the public part of a signing key as published in the JWKS document.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// KeySet holds the keys tokens are signed and verified with. Without an
+// active_kid tokens fall back to HS256 signed with jwt.secret.
+type KeySet struct {
+	Active     *SigningKey
+	Keys       map[string]*SigningKey
+	HMACSecret []byte
+}
+
+type signingKeyConfig struct {
+	KID            string `mapstructure:"kid"`
+	Algorithm      string `mapstructure:"algorithm"`
+	PrivateKey     string `mapstructure:"private_key"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PublicKey      string `mapstructure:"public_key"`
+	PublicKeyFile  string `mapstructure:"public_key_file"`
+}
+
+var (
+	keySetOnce sync.Once
+	keySet     *KeySet
+	keySetErr  error
+)
+
+// LoadKeySet parses the keys configured under jwt once and returns the same key
+// set for the rest of the process.
+func LoadKeySet(viper *viper.Viper) (*KeySet, error) {
+	keySetOnce.Do(func() {
+		keySet, keySetErr = NewKeySet(viper)
+	})
+	return keySet, keySetErr
+}
+
+func NewKeySet(viper *viper.Viper) (*KeySet, error) {
+	var configs []signingKeyConfig
+	if err := viper.UnmarshalKey("jwt.keys", &configs); err != nil {
+		return nil, errors.New("[KeySet] invalid jwt.keys: " + err.Error())
+	}
+
+	keys := &KeySet{
+		Keys:       make(map[string]*SigningKey),
+		HMACSecret: []byte(viper.GetString("jwt.secret")),
+	}
+
+	for _, cfg := range configs {
+		key, err := parseSigningKey(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("[KeySet] key %q: %w", cfg.KID, err)
+		}
+
+		if _, exists := keys.Keys[key.KID]; exists {
+			return nil, fmt.Errorf("[KeySet] duplicate kid %q", key.KID)
+		}
+		keys.Keys[key.KID] = key
+	}
+
+	activeKID := viper.GetString("jwt.active_kid")
+	if activeKID == "" {
+		if len(keys.HMACSecret) == 0 {
+			return nil, errors.New("[KeySet] either jwt.active_kid or jwt.secret must be configured")
+		}
+		return keys, nil
+	}
+
+	active, ok := keys.Keys[activeKID]
+	if !ok {
+		return nil, fmt.Errorf("[KeySet] active kid %q is not configured", activeKID)
+	}
+
+	if active.PrivateKey == nil {
+		return nil, fmt.Errorf("[KeySet] active kid %q has no private key", activeKID)
+	}
+	keys.Active = active
+
+	return keys, nil
+}
+
+// Sign signs the claims with the active key, adding its kid to the header.
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if k.Active == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(k.HMACSecret)
+	}
+
+	token := jwt.NewWithClaims(k.Active.Method, claims)
+	token.Header["kid"] = k.Active.KID
+	return token.SignedString(k.Active.PrivateKey)
+}
+
+// Keyfunc resolves the verification key of a token from its kid header and
+// makes sure the token is signed with the algorithm that key belongs to.
+func (k *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		// HS256 tokens are only trusted as long as no asymmetric key is active
+		if k.Active != nil {
+			return nil, errors.New("token has no kid header")
+		}
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return k.HMACSecret, nil
+	}
+
+	key, ok := k.Keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid: %s", kid)
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.PublicKey, nil
+}
+
+// JWKS returns the public keys in the JSON Web Key Set format.
+func (k *KeySet) JWKS() []JWK {
+	jwks := make([]JWK, 0, len(k.Keys))
+	for _, key := range k.Keys {
+		switch publicKey := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwks = append(jwks, JWK{
+				Kty: "RSA",
+				Kid: key.KID,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks = append(jwks, JWK{
+				Kty: "OKP",
+				Kid: key.KID,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(publicKey),
+			})
+		}
+	}
+	sort.Slice(jwks, func(i, j int) bool { return jwks[i].Kid < jwks[j].Kid })
+	return jwks
+}
+
+func parseSigningKey(cfg signingKeyConfig) (*SigningKey, error) {
+	if cfg.KID == "" {
+		return nil, errors.New("kid is required")
+	}
+
+	key := &SigningKey{KID: cfg.KID}
+
+	privatePEM, err := readKeyMaterial(cfg.PrivateKey, cfg.PrivateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	publicPEM, err := readKeyMaterial(cfg.PublicKey, cfg.PublicKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if privatePEM == nil && publicPEM == nil {
+		return nil, errors.New("either a private or a public key is required")
+	}
+
+	switch cfg.Algorithm {
+	case "RS256", "RS384", "RS512":
+		key.Method = jwt.GetSigningMethod(cfg.Algorithm)
+		if privatePEM != nil {
+			privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+			if err != nil {
+				return nil, err
+			}
+			key.PrivateKey = privateKey
+			key.PublicKey = privateKey.Public()
+		} else {
+			publicKey, err := jwt.ParseRSAPublicKeyFromPEM(publicPEM)
+			if err != nil {
+				return nil, err
+			}
+			key.PublicKey = publicKey
+		}
+	case "EdDSA":
+		key.Method = jwt.SigningMethodEdDSA
+		if privatePEM != nil {
+			privateKey, err := jwt.ParseEdPrivateKeyFromPEM(privatePEM)
+			if err != nil {
+				return nil, err
+			}
+			signer, ok := privateKey.(ed25519.PrivateKey)
+			if !ok {
+				return nil, errors.New("private key is not an Ed25519 key")
+			}
+			key.PrivateKey = signer
+			key.PublicKey = signer.Public()
+		} else {
+			publicKey, err := jwt.ParseEdPublicKeyFromPEM(publicPEM)
+			if err != nil {
+				return nil, err
+			}
+			key.PublicKey = publicKey
+		}
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", cfg.Algorithm)
+	}
+
+	return key, nil
+}
+
+func readKeyMaterial(inline string, file string) ([]byte, error) {
+	if inline != "" {
+		return []byte(inline), nil
+	}
+
+	if file == "" {
+		return nil, nil
+	}
+
+	return os.ReadFile(file)
+}
@@ -5,11 +5,43 @@ import (
 
 	"github.com/IlhamSetiaji/gift-redeem-be/internal/http/response"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
 
-func GenerateToken(user *response.UserResponse) (string, error) {
+// Values of the token_use claim. Only access tokens are accepted by the auth
+// middleware, challenge tokens can solely be exchanged at /api/login/2fa.
+const (
+	TokenUseAccess             = "access"
+	TokenUseTwoFactorChallenge = "2fa_challenge"
+)
+
+// AccessTokenTTL returns how long an access token is valid, read from
+// jwt.access_token_ttl in minutes and defaulting to 15 minutes.
+func AccessTokenTTL(viper *viper.Viper) time.Duration {
+	ttl := viper.GetInt("jwt.access_token_ttl")
+	if ttl <= 0 {
+		ttl = 15
+	}
+
+	return time.Duration(ttl) * time.Minute
+}
+
+// RefreshTokenTTL returns how long a refresh token is valid, read from
+// jwt.refresh_token_ttl in hours and defaulting to 30 days.
+func RefreshTokenTTL(viper *viper.Viper) time.Duration {
+	ttl := viper.GetInt("jwt.refresh_token_ttl")
+	if ttl <= 0 {
+		ttl = 720
+	}
+
+	return time.Duration(ttl) * time.Hour
+}
+
+// GenerateToken signs an access token for the user. twoFactorVerified tells
+// whether the session was started with a second factor.
+func GenerateToken(user *response.UserResponse, twoFactorVerified bool) (string, error) {
 	viper := viper.New()
 	logger := logrus.New()
 
@@ -22,30 +54,73 @@ func GenerateToken(user *response.UserResponse) (string, error) {
 		logger.Fatalf("Fatal error config file: %v", err)
 	}
 
-	roles := make([]map[string]interface{}, len(*user.Roles))
-	for i, role := range *user.Roles {
-		roles[i] = map[string]interface{}{
-			"name": role.Name,
+	now := time.Now()
+
+	roles := make([]map[string]interface{}, 0)
+	if user.Roles != nil {
+		for _, role := range *user.Roles {
+			roles = append(roles, map[string]interface{}{
+				"name":   role.Name,
+				"status": role.Status,
+			})
 		}
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":       user.ID,
-		"name":     user.Name,
-		"username": user.Username,
-		"email":    user.Email,
-		"roles":    roles,
-		"exp":      time.Now().Add(time.Hour * 72).Unix(),
+	keys, err := LoadKeySet(viper)
+	if err != nil {
+		return "", err
+	}
+
+	return keys.Sign(jwt.MapClaims{
+		"id":           user.ID,
+		"name":         user.Name,
+		"username":     user.Username,
+		"email":        user.Email,
+		"roles":        roles,
+		"permissions":  user.Permissions,
+		"token_use":    TokenUseAccess,
+		"mfa":          twoFactorVerified,
+		"mfa_required": user.TwoFactorRequired,
+		"jti":          uuid.New().String(),
+		"iat":          now.Unix(),
+		"exp":          now.Add(AccessTokenTTL(viper)).Unix(),
 	})
+}
+
+// GenerateTwoFactorChallengeToken signs the short lived token handed out after
+// a correct password when the user still has to enter a TOTP code.
+func GenerateTwoFactorChallengeToken(userID uuid.UUID, ttl time.Duration) (string, error) {
+	viper := viper.New()
+	logger := logrus.New()
+
+	viper.SetConfigName("config")
+	viper.SetConfigType("json")
+	viper.AddConfigPath("./")
+	err := viper.ReadInConfig()
 
-	tokenString, err := token.SignedString([]byte(viper.GetString("jwt.secret")))
+	if err != nil {
+		logger.Fatalf("Fatal error config file: %v", err)
+	}
+
+	keys, err := LoadKeySet(viper)
 	if err != nil {
 		return "", err
 	}
 
-	return tokenString, nil
+	now := time.Now()
+
+	return keys.Sign(jwt.MapClaims{
+		"sub":       userID.String(),
+		"token_use": TokenUseTwoFactorChallenge,
+		"jti":       uuid.New().String(),
+		"iat":       now.Unix(),
+		"exp":       now.Add(ttl).Unix(),
+	})
 }
 
+// GenerateTokenForOAuth2 signs an access token issued through the OAuth2
+// endpoints. The given claims are kept as they are, a jti is only generated
+// when the caller did not provide one.
 func GenerateTokenForOAuth2(data *map[string]interface{}) (string, error) {
 	viper := viper.New()
 	logger := logrus.New()
@@ -59,15 +134,26 @@ func GenerateTokenForOAuth2(data *map[string]interface{}) (string, error) {
 		logger.Fatalf("Fatal error config file: %v", err)
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"data": data,
-		"exp":  time.Now().Add(time.Hour * 72).Unix(),
-	})
-
-	tokenString, err := token.SignedString([]byte(viper.GetString("jwt.secret")))
+	keys, err := LoadKeySet(viper)
 	if err != nil {
 		return "", err
 	}
 
-	return tokenString, nil
+	now := time.Now()
+
+	claims := jwt.MapClaims{}
+	for key, value := range *data {
+		claims[key] = value
+	}
+
+	if _, ok := claims["jti"]; !ok {
+		claims["jti"] = uuid.New().String()
+	}
+	if _, ok := claims["token_use"]; !ok {
+		claims["token_use"] = TokenUseAccess
+	}
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(AccessTokenTTL(viper)).Unix()
+
+	return keys.Sign(claims)
 }
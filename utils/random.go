@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"math/big"
+)
+
+// GenerateNumericToken returns a cryptographically random number with exactly
+// the given amount of digits, suitable for codes sent to users by email.
+func GenerateNumericToken(digits int) (int, error) {
+	lower := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits-1)), nil)
+	upper := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+
+	n, err := rand.Int(rand.Reader, new(big.Int).Sub(upper, lower))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n.Add(n, lower).Int64()), nil
+}
+
+// GenerateRandomString returns the given amount of random bytes encoded as an
+// unpadded, URL safe base64 string.
+func GenerateRandomString(bytes int) (string, error) {
+	buf := make([]byte, bytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -12,9 +12,17 @@ type Meta struct {
 	Message string `json:"message"`
 }
 
+type Pagination struct {
+	Page       int   `json:"page"`
+	PageSize   int   `json:"page_size"`
+	Total      int64 `json:"total"`
+	TotalPages int   `json:"total_pages"`
+}
+
 type Response struct {
-	Meta Meta        `json:"meta"`
-	Data interface{} `json:"data,omitempty"`
+	Meta       Meta        `json:"meta"`
+	Data       interface{} `json:"data,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
 }
 
 func FormatResponse(c *gin.Context, code int, status string, message string, data interface{}) {
@@ -53,3 +61,29 @@ func BadRequestResponse(c *gin.Context, message string, data interface{}) {
 func SuccessResponse(c *gin.Context, code int, message string, data interface{}) {
 	FormatResponse(c, code, "success", message, data)
 }
+
+func NewPagination(page int, pageSize int, total int64) *Pagination {
+	totalPages := 0
+	if pageSize > 0 {
+		totalPages = int((total + int64(pageSize) - 1) / int64(pageSize))
+	}
+
+	return &Pagination{
+		Page:       page,
+		PageSize:   pageSize,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+}
+
+func PaginatedResponse(c *gin.Context, code int, message string, data interface{}, pagination *Pagination) {
+	c.JSON(code, Response{
+		Meta: Meta{
+			Code:    code,
+			Status:  "success",
+			Message: message,
+		},
+		Data:       data,
+		Pagination: pagination,
+	})
+}
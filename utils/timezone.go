@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	// embed the time zone database so app.timezone resolves on hosts without one
+	_ "time/tzdata"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	timezoneOnce sync.Once
+	timezone     *time.Location
+	timezoneErr  error
+)
+
+// LoadTimezone resolves app.timezone, e.g. "Asia/Jakarta", once and returns the
+// same location for the rest of the process. Timestamps are stored in UTC and
+// only converted to this location for display and for calendar periods such as
+// the daily redemption limits. It defaults to UTC.
+func LoadTimezone(viper *viper.Viper) (*time.Location, error) {
+	timezoneOnce.Do(func() {
+		name := viper.GetString("app.timezone")
+		if name == "" {
+			timezone = time.UTC
+			return
+		}
+
+		timezone, timezoneErr = time.LoadLocation(name)
+		if timezoneErr != nil {
+			timezoneErr = errors.New("[Timezone] invalid app.timezone: " + timezoneErr.Error())
+		}
+	})
+	return timezone, timezoneErr
+}
@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 seed of RFC 6238 appendix B ("12345678901234567890")
+// in base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func testTOTPCode(t *testing.T, secret string, step int64) string {
+	t.Helper()
+
+	code, err := TOTPCode(secret, step)
+	if err != nil {
+		t.Fatalf("TOTPCode: %v", err)
+	}
+	return code
+}
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// RFC 6238 lists 8 digit codes; a 6 digit code is the last 6 digits.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		got := testTOTPCode(t, rfc6238Secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if got != tt.want {
+			t.Errorf("TOTPCode at %d = %q, want %q", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestTOTPCodeSecretFormat(t *testing.T) {
+	step := TOTPStep(time.Unix(59, 0))
+
+	for _, secret := range []string{
+		strings.ToLower(rfc6238Secret),
+		"  " + rfc6238Secret + "\n",
+	} {
+		if got := testTOTPCode(t, secret, step); got != "287082" {
+			t.Errorf("TOTPCode(%q) = %q, want %q", secret, got, "287082")
+		}
+	}
+
+	if _, err := TOTPCode("not base32!", step); err == nil {
+		t.Error("TOTPCode with an invalid secret: expected error")
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := TOTPStep(now)
+
+	tests := []struct {
+		name   string
+		offset int64
+		skew   int64
+		wantOK bool
+	}{
+		{"current step", 0, 0, true},
+		{"previous step without skew", -1, 0, false},
+		{"next step without skew", 1, 0, false},
+		{"previous step with skew", -1, 1, true},
+		{"next step with skew", 1, 1, true},
+		{"two steps behind", -2, 1, false},
+		{"two steps ahead", 2, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := testTOTPCode(t, rfc6238Secret, current+tt.offset)
+
+			step, ok := ValidateTOTP(rfc6238Secret, code, now, tt.skew)
+			if ok != tt.wantOK {
+				t.Fatalf("ValidateTOTP ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && step != current+tt.offset {
+				t.Errorf("ValidateTOTP step = %d, want %d", step, current+tt.offset)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPRejectsMalformedCodes(t *testing.T) {
+	now := time.Unix(59, 0)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		wantOK bool
+	}{
+		{"valid", rfc6238Secret, "287082", true},
+		{"surrounding whitespace", rfc6238Secret, " 287082 ", true},
+		{"wrong code", rfc6238Secret, "287083", false},
+		{"empty", rfc6238Secret, "", false},
+		{"too short", rfc6238Secret, "28708", false},
+		{"eight digits", rfc6238Secret, "94287082", false},
+		{"invalid secret", "not base32!", "287082", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := ValidateTOTP(tt.secret, tt.code, now, 1); ok != tt.wantOK {
+				t.Errorf("ValidateTOTP(%q) ok = %v, want %v", tt.code, ok, tt.wantOK)
+			}
+		})
+	}
+}
+
+// TestValidateTOTPReplay checks that a code keeps reporting the step it was
+// issued for while it is inside the skew window, which is what the two factor
+// use case stores to refuse the same code twice.
+func TestValidateTOTPReplay(t *testing.T) {
+	issued := time.Unix(1234567890, 0)
+	code := testTOTPCode(t, rfc6238Secret, TOTPStep(issued))
+
+	var lastStep int64
+	consume := func(now time.Time) bool {
+		step, ok := ValidateTOTP(rfc6238Secret, code, now, 1)
+		if !ok || step <= lastStep {
+			return false
+		}
+		lastStep = step
+		return true
+	}
+
+	if !consume(issued) {
+		t.Fatal("first use of the code was rejected")
+	}
+	if consume(issued) {
+		t.Error("same code was accepted twice in the same step")
+	}
+	if consume(issued.Add(TOTPPeriod * time.Second)) {
+		t.Error("same code was accepted again in the next step")
+	}
+
+	next := testTOTPCode(t, rfc6238Secret, TOTPStep(issued)+1)
+	if step, ok := ValidateTOTP(rfc6238Secret, next, issued.Add(TOTPPeriod*time.Second), 1); !ok || step <= lastStep {
+		t.Errorf("code of the next step = (%d, %v), want a step after %d", step, ok, lastStep)
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	a, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret: %v", err)
+	}
+	b, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret: %v", err)
+	}
+	if a == b {
+		t.Error("GenerateTOTPSecret returned the same secret twice")
+	}
+	if _, err := TOTPCode(a, 1); err != nil {
+		t.Errorf("generated secret is not usable: %v", err)
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	raw := TOTPURI("Gift Redeem", "jane@example.com", rfc6238Secret)
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("url.Parse: %v", err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" {
+		t.Errorf("TOTPURI = %q, want an otpauth://totp URI", raw)
+	}
+
+	q := u.Query()
+	if q.Get("secret") != rfc6238Secret {
+		t.Errorf("secret = %q, want %q", q.Get("secret"), rfc6238Secret)
+	}
+	if q.Get("issuer") != "Gift Redeem" {
+		t.Errorf("issuer = %q, want %q", q.Get("issuer"), "Gift Redeem")
+	}
+}